@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"event-metrics-service/internal/config"
+	"event-metrics-service/internal/export"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+)
+
+// runExport implements the `export` subcommand. It streams raw events to a
+// file, stdout, or a directory of chunked files without starting the server.
+func runExport(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	eventName := flags.String("event-name", "", "event name to export (required)")
+	from := flags.String("from", "", "start of the window, RFC3339, unix seconds or unix milliseconds (default: 30 days before -to)")
+	to := flags.String("to", "", "end of the window, RFC3339, unix seconds or unix milliseconds (default: now)")
+	channel := flags.String("channel", "", "only export events from this channel")
+	tenantID := flags.String("tenant", model.DefaultTenant, "tenant whose events are exported")
+	consistency := flags.String("consistency", "", "eventual or dedup (default: METRICS_CONSISTENCY)")
+	formatName := flags.String("format", "ndjson", "csv, ndjson or parquet")
+	out := flags.String("out", "-", "output file, - for stdout")
+	dir := flags.String("dir", "", "write chunked files to this directory instead of -out")
+	chunkRows := flags.Int("chunk-rows", 1_000_000, "events per file when -dir is set (0 writes one file)")
+	metadata := metadataFlag{}
+	flags.Var(metadata, "metadata", "metadata equality filter key=value (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	if !model.TenantIDPattern.MatchString(*tenantID) {
+		return fmt.Errorf("invalid -tenant %q", *tenantID)
+	}
+
+	filter := model.MetricsFilter{TenantID: *tenantID, EventName: *eventName, Consistency: *consistency}
+	if filter.From, err = parseExportTime(*from); err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	if filter.To, err = parseExportTime(*to); err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+	if *channel != "" {
+		filter.Channel = channel
+	}
+	if len(metadata) > 0 {
+		filter.Metadata = metadata
+	}
+
+	// Exports only read; never migrate from here.
+	readOnly := *cfg
+	readOnly.AutoMigrate = false
+
+	opts, err := schemaOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStorage(ctx, &readOnly, opts)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	svc := service.NewEventService(store.events, nil, 0, service.MetricsLimits{},
+		service.WithDefaultConsistency(cfg.MetricsConsistency),
+		service.WithExportLimits(exportLimits(cfg)),
+	)
+
+	if *dir != "" {
+		w, err := export.NewDirWriter(*dir, filter.EventName, format, *chunkRows)
+		if err != nil {
+			return err
+		}
+		count, err := exportTo(ctx, svc, filter, w)
+		if err != nil {
+			return err
+		}
+		log.Printf("exported %d event(s) to %d file(s) in %s", count, len(w.Files()), *dir)
+		return nil
+	}
+
+	var dst io.Writer = os.Stdout
+	if *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		dst = file
+	}
+
+	buf := bufio.NewWriter(dst)
+	w, err := export.NewWriter(format, buf)
+	if err != nil {
+		return err
+	}
+	count, err := exportTo(ctx, svc, filter, w)
+	if err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	log.Printf("exported %d event(s)", count)
+	return nil
+}
+
+func exportTo(ctx context.Context, svc service.EventService, filter model.MetricsFilter, w export.Writer) (int, error) {
+	count := 0
+	err := svc.ExportEvents(ctx, filter, func(event model.Event) error {
+		count++
+		return w.Write(event)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, w.Close()
+}
+
+// parseExportTime accepts RFC3339, unix seconds or unix milliseconds. Empty
+// means unset.
+func parseExportTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return model.ParseTimestamp(raw, "")
+}
+
+func exportLimits(cfg *config.Config) service.ExportLimits {
+	return service.ExportLimits{Timeout: cfg.ExportTimeout, MaxWindow: cfg.ExportMaxWindow}
+}
+
+// metadataFlag collects repeated -metadata key=value flags.
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	parts := make([]string, 0, len(m))
+	for k, v := range m {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m metadataFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return errors.New("expected key=value")
+	}
+	m[key] = val
+	return nil
+}
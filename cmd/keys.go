@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/config"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+)
+
+const keysUsage = "usage: keys list | create -name NAME -scopes write,read,admin [-tenant ID] | revoke -id ID | rotate -id ID [-grace 24h] | limits -id ID [-ingest-rate N] [-ingest-burst N] [-query-rate N] [-query-burst N] [-daily-events N]"
+
+// runKeys implements the `keys` subcommand. It edits API_KEYS_FILE directly;
+// a running server reads the changes back within API_KEYS_RELOAD_INTERVAL.
+func runKeys(cfg *config.Config, args []string) error {
+	if cfg.APIKeysFile == "" {
+		return errors.New("API_KEYS_FILE is not set")
+	}
+	if len(args) == 0 {
+		return errors.New(keysUsage)
+	}
+
+	store, err := auth.LoadKeyStore(cfg.APIKeysFile)
+	if err != nil {
+		return err
+	}
+	svc := service.NewAPIKeyService(store)
+
+	flags := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	name := flags.String("name", "", "key name (create)")
+	scopes := flags.String("scopes", "", "comma-separated scopes: write, read, admin (create)")
+	tenant := flags.String("tenant", "", "tenant the key is pinned to (create)")
+	id := flags.String("id", "", "key id (revoke, rotate)")
+	grace := flags.Duration("grace", 0, "how long the old key stays valid after rotation")
+	var limits model.ClientLimits
+	flags.Float64Var(&limits.IngestRate, "ingest-rate", 0, "ingest requests per second (limits)")
+	flags.IntVar(&limits.IngestBurst, "ingest-burst", 0, "ingest burst size (limits)")
+	flags.Float64Var(&limits.QueryRate, "query-rate", 0, "query requests per second (limits)")
+	flags.IntVar(&limits.QueryBurst, "query-burst", 0, "query burst size (limits)")
+	flags.Int64Var(&limits.DailyEvents, "daily-events", 0, "events accepted per UTC day (limits)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var out any
+	switch args[0] {
+	case "list":
+		out = svc.ListKeys()
+	case "create":
+		out, err = svc.CreateKey(*name, splitScopes(*scopes), *tenant)
+	case "revoke":
+		out, err = svc.RevokeKey(*id)
+	case "rotate":
+		out, err = svc.RotateKey(*id, *grace)
+	case "limits":
+		out, err = svc.SetKeyLimits(*id, limits)
+	default:
+		return fmt.Errorf("unknown keys command %q\n%s", args[0], keysUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func splitScopes(raw string) []string {
+	var scopes []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"event-metrics-service/internal/audit"
+	"event-metrics-service/internal/auth"
 	"event-metrics-service/internal/config"
 	"event-metrics-service/internal/controller"
 	"event-metrics-service/internal/db"
+	"event-metrics-service/internal/enrich"
 	httpserver "event-metrics-service/internal/http"
-	"event-metrics-service/internal/repository"
+	"event-metrics-service/internal/pii"
+	"event-metrics-service/internal/ratelimit"
+	"event-metrics-service/internal/routes"
+	"event-metrics-service/internal/rules"
+	"event-metrics-service/internal/schema"
 	"event-metrics-service/internal/service"
+	"event-metrics-service/internal/tenant"
 )
 
 func main() {
@@ -25,25 +36,272 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	conn, err := db.NewConnection(ctx, cfg)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(ctx, cfg, os.Args[2:]); err != nil {
+				log.Fatalf("migrate: %v", err)
+			}
+			return
+		case "export":
+			if err := runExport(ctx, cfg, os.Args[2:]); err != nil {
+				log.Fatalf("export: %v", err)
+			}
+			return
+		case "keys":
+			if err := runKeys(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("keys: %v", err)
+			}
+			return
+		case "serve":
+		default:
+			log.Fatalf("unknown command %q (expected serve, migrate, export or keys)", os.Args[1])
+		}
+	}
+
+	schemaOpts, err := schemaOptions(cfg)
+	if err != nil {
+		log.Fatalf("load schema options: %v", err)
+	}
+
+	schemaMode, err := schema.ParseMode(cfg.SchemaMode)
+	if err != nil {
+		log.Fatalf("load schema registry: %v", err)
+	}
+	schemas, err := schema.LoadRegistry(schemaMode, cfg.SchemaRegistryFile)
+	if err != nil {
+		log.Fatalf("load schema registry: %v", err)
+	}
+
+	keys, err := auth.LoadKeyStore(cfg.APIKeysFile)
 	if err != nil {
-		log.Fatalf("connect db: %v", err)
+		log.Fatalf("load api keys: %v", err)
+	}
+	if cfg.APIKeysFile != "" {
+		go keys.Watch(ctx, cfg.APIKeysReload)
 	}
-	defer conn.Close()
 
-	if err := db.RunMigrations(ctx, conn); err != nil {
-		log.Fatalf("migrate: %v", err)
+	tenants, err := tenant.Load(cfg.TenantsFile)
+	if err != nil {
+		log.Fatalf("load tenants: %v", err)
+	}
+	tenantSchemas, err := tenantSchemaRegistries(tenants, schemaMode)
+	if err != nil {
+		log.Fatalf("load tenant schema registries: %v", err)
+	}
+
+	transforms, err := pii.Load(cfg.PIIConfigFile, cfg.PIIHMACKeys)
+	if err != nil {
+		log.Fatalf("load pii config: %v", err)
+	}
+	if transforms != nil {
+		log.Printf("pii transforms enabled (config version %d)", transforms.Version())
 	}
 
-	repo := repository.NewEventRepository(conn)
+	enrichers, err := enrichers(cfg)
+	if err != nil {
+		log.Fatalf("load enrichers: %v", err)
+	}
+
+	ingestRules, err := rules.Load(cfg.RulesFile)
+	if err != nil {
+		log.Fatalf("load rules: %v", err)
+	}
+	if ingestRules != nil {
+		log.Printf("ingest rules enabled (version %d)", ingestRules.Version())
+		go ingestRules.Watch(ctx, cfg.RulesReloadInterval)
+	}
+
+	store, err := openStorage(ctx, cfg, schemaOpts)
+	if err != nil {
+		log.Fatalf("open storage: %v", err)
+	}
+	defer store.close()
+
+	repo := store.events
 	worker := service.NewbatchEventWorker(repo, cfg.WorkerBufferSize, cfg.WorkerBatchSize, cfg.WorkerFlushEvery)
-	eventService := service.NewEventService(repo, worker, cfg.FutureTolerance)
+	limits := service.MetricsLimits{
+		Timeout: cfg.MetricsQueryTimeout,
+		MaxWindow: map[string]time.Duration{
+			"hour":     cfg.MetricsMaxWindowHour,
+			"day":      cfg.MetricsMaxWindowDay,
+			"channel":  cfg.MetricsMaxWindowChannel,
+			"metadata": cfg.MetricsMaxWindowMetadata,
+		},
+	}
+	late := service.LateEventPolicy{PastTolerance: cfg.PastTolerance, Policy: cfg.LateEventPolicy}
+	if late.Policy == service.LatePolicyRoute {
+		lateWorker := service.NewbatchEventWorker(store.late, cfg.WorkerBufferSize, cfg.WorkerBatchSize, cfg.WorkerFlushEvery)
+		defer lateWorker.Shutdown()
+		late.Worker = lateWorker
+	}
+	eventService := service.NewEventService(repo, worker, cfg.FutureTolerance, limits,
+		service.WithSchemaRegistry(schemas),
+		service.WithTenantSchemaRegistries(tenantSchemas),
+		service.WithDefaultConsistency(cfg.MetricsConsistency),
+		service.WithExportLimits(exportLimits(cfg)),
+		service.WithPIITransforms(transforms),
+		service.WithEnrichers(enrichers),
+		service.WithRules(ingestRules),
+		service.WithLateEventPolicy(late),
+	)
 	eventController := controller.NewEventController(eventService)
 
-	server := httpserver.NewServer(cfg, eventController)
+	adminService := service.NewAdminService(store.partitions, schemas)
+	adminController := controller.NewAdminController(adminService)
+
+	privacyService := service.NewPrivacyService(store.privacy, repo, service.WithPseudonyms(transforms))
+	privacyController := controller.NewPrivacyController(privacyService)
+
+	keyController := controller.NewKeyController(service.NewAPIKeyService(keys))
+
+	rateLimits := ratelimit.NewEnforcer(ratelimit.Policy{
+		Ingest:      ratelimit.NewLimit(cfg.IngestRateLimit, cfg.IngestRateBurst),
+		Query:       ratelimit.NewLimit(cfg.QueryRateLimit, cfg.QueryRateBurst),
+		DailyEvents: cfg.DailyEventQuota,
+	}, tenants)
+	usageController := controller.NewUsageController(rateLimits)
+
+	signatures, err := signatureVerifier(cfg)
+	if err != nil {
+		log.Fatalf("load signing secrets: %v", err)
+	}
+	signingController := controller.NewSigningController(signatures)
+
+	tokens, err := tokenVerifier(ctx, cfg)
+	if err != nil {
+		log.Fatalf("load oidc keys: %v", err)
+	}
+
+	var auditRecorder audit.Recorder
+	if cfg.AuditEnabled {
+		auditWorker := service.NewAuditWorker(store.audit, cfg.AuditBufferSize, cfg.AuditBatchSize, cfg.AuditFlushEvery)
+		defer auditWorker.Shutdown()
+		auditRecorder = auditWorker
+	}
+	auditController := controller.NewAuditController(service.NewAuditService(store.audit))
+
+	server := httpserver.NewServer(cfg, httpserver.Controllers{
+		Event: eventController,
+		Admin: routes.AdminControllers{
+			Admin:   adminController,
+			Privacy: privacyController,
+			Keys:    keyController,
+			Usage:   usageController,
+			Signing: signingController,
+			Audit:   auditController,
+			Rules:   controller.NewRulesController(ingestRules),
+		},
+	}, httpserver.Security{
+		Keys:       keys,
+		Tenants:    tenants,
+		Limits:     rateLimits,
+		Signatures: signatures,
+		Tokens:     tokens,
+		Audit:      auditRecorder,
+	})
 
 	log.Printf("starting server on %s", cfg.HTTPPort)
 	if err := server.Listen(cfg.HTTPPort); err != nil {
 		log.Fatalf("server stopped: %v", err)
 	}
 }
+
+// signatureVerifier builds the request signature verifier, or returns nil
+// when no signing secrets are configured.
+func signatureVerifier(cfg *config.Config) (*auth.SignatureVerifier, error) {
+	secrets, err := auth.ParseSigningSecrets(cfg.SigningSecrets)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	return auth.NewSignatureVerifier(secrets, cfg.SigningReplayWindow, cfg.SigningRequired), nil
+}
+
+// tokenVerifier builds the identity token verifier, or returns nil when no
+// JWKS is configured.
+func tokenVerifier(ctx context.Context, cfg *config.Config) (*auth.TokenVerifier, error) {
+	if cfg.OIDCJWKS == "" {
+		return nil, nil
+	}
+
+	keys, err := auth.LoadJWKS(ctx, cfg.OIDCJWKS, cfg.OIDCJWKSRefresh)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewTokenVerifier(keys, auth.TokenConfig{
+		Issuer:          cfg.OIDCIssuer,
+		Audience:        cfg.OIDCAudience,
+		TenantsClaim:    cfg.OIDCTenantsClaim,
+		EventNamesClaim: cfg.OIDCEventNamesClaim,
+	}), nil
+}
+
+// enrichers builds the ingest enrichment chain from configuration, in a
+// fixed order.
+func enrichers(cfg *config.Config) (enrich.Chain, error) {
+	var chain enrich.Chain
+	if cfg.EnrichReceiveTime {
+		chain = append(chain, enrich.ReceiveTime{})
+	}
+	if cfg.EnrichUserAgent {
+		chain = append(chain, enrich.UserAgent{})
+	}
+	if cfg.EnrichGeoIPFile != "" {
+		geoIP, err := enrich.LoadGeoIP(cfg.EnrichGeoIPFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, geoIP)
+	}
+	if cfg.EnrichCampaignsFile != "" {
+		campaigns, err := enrich.LoadCampaigns(cfg.EnrichCampaignsFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, campaigns)
+	}
+	return chain, nil
+}
+
+// tenantSchemaRegistries loads the schema registries of tenants that
+// configure their own, keyed by tenant ID.
+func tenantSchemaRegistries(tenants *tenant.Registry, mode schema.Mode) (map[string]*schema.Registry, error) {
+	registries := map[string]*schema.Registry{}
+	for _, t := range tenants.List() {
+		if t.SchemaRegistryFile == "" {
+			continue
+		}
+		registry, err := schema.LoadRegistry(mode, t.SchemaRegistryFile)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+		registries[t.ID] = registry
+	}
+	return registries, nil
+}
+
+func schemaOptions(cfg *config.Config) (db.SchemaOptions, error) {
+	promoted, err := db.ParsePromotedColumns(cfg.PromotedMetadata)
+	if err != nil {
+		return db.SchemaOptions{}, err
+	}
+
+	tenants, err := tenant.Load(cfg.TenantsFile)
+	if err != nil {
+		return db.SchemaOptions{}, err
+	}
+
+	return db.SchemaOptions{
+		Retention: db.RetentionPolicy{
+			RetentionDays:       cfg.EventsRetentionDays,
+			ColdVolume:          cfg.EventsColdVolume,
+			ColdAfterDays:       cfg.EventsColdAfterDays,
+			StoragePolicy:       cfg.EventsStoragePolicy,
+			TenantRetentionDays: tenants.RetentionDays(),
+		},
+		PromotedColumns: promoted,
+	}, nil
+}
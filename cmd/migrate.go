@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"event-metrics-service/internal/config"
+	"event-metrics-service/internal/db"
+)
+
+// runMigrate implements the `migrate` subcommand. It applies pending schema
+// migrations and the configured retention without starting the server.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list pending migrations without applying them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch cfg.StorageBackend {
+	case "memory":
+		log.Println("memory backend has no schema to migrate")
+		return nil
+	case "postgres":
+		return migratePostgres(ctx, cfg, *dryRun)
+	}
+
+	opts, err := schemaOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.NewConnection(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect db: %w", err)
+	}
+	defer conn.Close()
+
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := migrator.Up(ctx, opts, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		if len(migrations) == 0 {
+			log.Println("no pending migrations")
+		}
+		for _, m := range migrations {
+			log.Printf("pending: %04d_%s", m.Version, m.Name)
+		}
+		return nil
+	}
+
+	log.Printf("applied %d migration(s)", len(migrations))
+	return nil
+}
+
+// migratePostgres creates the PostgreSQL schema. It is a single idempotent
+// script, so there is nothing to list in dry-run mode.
+func migratePostgres(ctx context.Context, cfg *config.Config, dryRun bool) error {
+	if dryRun {
+		log.Println("postgres schema is applied idempotently; nothing to list")
+		return nil
+	}
+
+	pool, err := db.NewPostgresPool(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect db: %w", err)
+	}
+	defer pool.Close()
+
+	if err := db.EnsurePostgresSchema(ctx, pool); err != nil {
+		return err
+	}
+	log.Println("postgres schema is up to date")
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"event-metrics-service/internal/config"
+	"event-metrics-service/internal/db"
+	"event-metrics-service/internal/repository"
+)
+
+// storage bundles the repositories of the configured backend.
+type storage struct {
+	events     repository.EventRepository
+	partitions repository.PartitionRepository
+	privacy    repository.PrivacyRepository
+	late       repository.LateEventRepository
+	audit      repository.AuditRepository
+	close      func()
+}
+
+// openStorage connects to the backend selected by STORAGE_BACKEND and, when
+// AUTO_MIGRATE is enabled, brings its schema up to date.
+func openStorage(ctx context.Context, cfg *config.Config, schemaOpts db.SchemaOptions) (*storage, error) {
+	switch cfg.StorageBackend {
+	case "memory":
+		events := repository.NewMemoryEventRepository()
+		return &storage{
+			events:     events,
+			partitions: repository.NewUnsupportedPartitionRepository(),
+			privacy:    repository.NewMemoryPrivacyRepository(events),
+			late:       repository.NewMemoryLateEventRepository(events),
+			audit:      repository.NewMemoryAuditRepository(),
+			close:      func() {},
+		}, nil
+	case "postgres":
+		pool, err := db.NewPostgresPool(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("connect db: %w", err)
+		}
+
+		if cfg.AutoMigrate {
+			if err := db.EnsurePostgresSchema(ctx, pool); err != nil {
+				pool.Close()
+				return nil, fmt.Errorf("migrate: %w", err)
+			}
+		}
+
+		return &storage{
+			events:     repository.NewPostgresEventRepository(pool),
+			partitions: repository.NewUnsupportedPartitionRepository(),
+			privacy:    repository.NewPostgresPrivacyRepository(pool),
+			late:       repository.NewPostgresLateEventRepository(pool),
+			audit:      repository.NewPostgresAuditRepository(pool),
+			close:      pool.Close,
+		}, nil
+	default:
+		conn, err := db.NewConnection(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("connect db: %w", err)
+		}
+
+		if cfg.AutoMigrate {
+			if err := db.RunMigrations(ctx, conn, schemaOpts); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("migrate: %w", err)
+			}
+		}
+
+		return &storage{
+			events: repository.NewEventRepository(conn, repository.QueryLimits{
+				MaxRowsToRead:  uint64(cfg.MetricsMaxRowsToRead),
+				MaxMemoryUsage: uint64(cfg.MetricsMaxMemoryUsage),
+			}, schemaOpts.PromotedColumns),
+			partitions: repository.NewPartitionRepository(conn),
+			privacy:    repository.NewPrivacyRepository(conn, cfg.PrivacyDeleteMode),
+			late:       repository.NewLateEventRepository(conn),
+			audit:      repository.NewAuditRepository(conn),
+			close:      func() { conn.Close() },
+		}, nil
+	}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Recorder persists audit records. Record must not block the request.
+type Recorder interface {
+	Record(record model.AuditRecord)
+}
+
+// Middleware records every request it sees, including ones the guards after
+// it reject. It must run before the auth and tenant guards so it can
+// observe their outcome.
+func Middleware(recorder Recorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		ctx, trace := WithTrace(c.UserContext())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		filter, rowsRead := trace.snapshot()
+		// Admin routes are not tenant scoped; their records carry no tenant.
+		tenantID, _ := tenant.Resolved(c)
+		record := model.AuditRecord{
+			At:         start.UTC(),
+			Principal:  principal(c),
+			TenantID:   tenantID,
+			Action:     c.Method() + " " + c.Route().Path,
+			Path:       c.Path(),
+			ClientIP:   c.IP(),
+			Filter:     filter,
+			DurationMs: uint64(time.Since(start).Milliseconds()),
+			RowsRead:   rowsRead,
+			Status:     status(c, err),
+		}
+		recorder.Record(record)
+		return err
+	}
+}
+
+// principal identifies the authenticated caller, if any.
+func principal(c *fiber.Ctx) string {
+	if key, ok := auth.KeyFromContext(c); ok {
+		return "key:" + key.ID
+	}
+	if p, ok := auth.PrincipalFromContext(c); ok {
+		return "sub:" + p.Subject
+	}
+	return ""
+}
+
+// status is the response status the error handler will send for err.
+func status(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
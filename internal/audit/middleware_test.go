@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	records []model.AuditRecord
+}
+
+func (r *recorder) Record(record model.AuditRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+}
+
+func TestMiddleware(t *testing.T) {
+	registry, err := tenant.NewRegistry([]tenant.Config{{ID: "acme"}})
+	require.NoError(t, err)
+
+	store := auth.NewKeyStore("")
+	key, token, err := store.Create("dashboards", []auth.Scope{auth.ScopeRead, auth.ScopeAdmin}, "")
+	require.NoError(t, err)
+
+	channel := "web"
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	rec := &recorder{}
+
+	app := fiber.New()
+	app.Get("/metrics", Middleware(rec), auth.RequireScope(store, auth.ScopeRead), tenant.Middleware(registry), func(c *fiber.Ctx) error {
+		RecordFilter(c.UserContext(), model.MetricsFilter{
+			EventName: "purchase",
+			From:      from,
+			To:        from.Add(time.Hour),
+			GroupBy:   "channel",
+			Channel:   &channel,
+		})
+		AddRowsRead(c.UserContext(), 40)
+		AddRowsRead(c.UserContext(), 2)
+		return c.SendStatus(fiber.StatusOK)
+	})
+	admin := app.Group("/admin", Middleware(rec), auth.RequireScope(store, auth.ScopeAdmin))
+	admin.Delete("/keys/:id", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusNotFound, "api key not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?event_name=purchase", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(tenant.HeaderTenantID, "acme")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics?event_name=purchase", nil)
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodDelete, "/admin/keys/k1", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.Len(t, rec.records, 3)
+
+	query := rec.records[0]
+	require.Equal(t, "key:"+key.ID, query.Principal)
+	require.Equal(t, "acme", query.TenantID)
+	require.Equal(t, "GET /metrics", query.Action)
+	require.Equal(t, "/metrics", query.Path)
+	require.Equal(t, http.StatusOK, query.Status)
+	require.Equal(t, uint64(42), query.RowsRead)
+	require.JSONEq(t, `{
+		"event_name": "purchase",
+		"from": "2025-03-01T00:00:00Z",
+		"to": "2025-03-01T01:00:00Z",
+		"group_by": "channel",
+		"channel": "web"
+	}`, query.Filter)
+
+	rejected := rec.records[1]
+	require.Empty(t, rejected.Principal)
+	require.Empty(t, rejected.TenantID)
+	require.Empty(t, rejected.Filter)
+	require.Equal(t, http.StatusUnauthorized, rejected.Status)
+
+	action := rec.records[2]
+	require.Equal(t, "key:"+key.ID, action.Principal)
+	require.Empty(t, action.TenantID)
+	require.Equal(t, "DELETE /admin/keys/:id", action.Action)
+	require.Equal(t, "/admin/keys/k1", action.Path)
+	require.Equal(t, http.StatusNotFound, action.Status)
+}
+
+func TestTraceHelpersWithoutTrace(t *testing.T) {
+	// Code paths outside audited requests must not need a trace.
+	ctx := t.Context()
+	RecordFilter(ctx, model.MetricsFilter{EventName: "purchase"})
+	AddRowsRead(ctx, 1)
+}
@@ -0,0 +1,94 @@
+// Package audit records who queried metrics and who performed admin
+// actions.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+type traceKey struct{}
+
+// Trace collects the details of an audited request that only the service
+// and repository layers know: the normalized filter and the rows read.
+type Trace struct {
+	mu       sync.Mutex
+	filter   string
+	rowsRead uint64
+}
+
+// WithTrace returns a context carrying a new Trace.
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+func traceFrom(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// RecordFilter stores the normalized filter of the audited query. It is a
+// no-op when ctx carries no Trace.
+func RecordFilter(ctx context.Context, filter model.MetricsFilter) {
+	trace := traceFrom(ctx)
+	if trace == nil {
+		return
+	}
+
+	encoded := encodeFilter(filter)
+	trace.mu.Lock()
+	trace.filter = encoded
+	trace.mu.Unlock()
+}
+
+// AddRowsRead adds to the rows the audited request read from storage. It is
+// a no-op when ctx carries no Trace.
+func AddRowsRead(ctx context.Context, rows uint64) {
+	trace := traceFrom(ctx)
+	if trace == nil {
+		return
+	}
+
+	trace.mu.Lock()
+	trace.rowsRead += rows
+	trace.mu.Unlock()
+}
+
+func (t *Trace) snapshot() (string, uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.filter, t.rowsRead
+}
+
+// auditFilter is the stored form of a MetricsFilter.
+type auditFilter struct {
+	EventName   string            `json:"event_name"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	GroupBy     string            `json:"group_by,omitempty"`
+	Channel     *string           `json:"channel,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Consistency string            `json:"consistency,omitempty"`
+}
+
+func encodeFilter(filter model.MetricsFilter) string {
+	// encoding/json sorts map keys, so equal filters encode equally.
+	encoded, err := json.Marshal(auditFilter{
+		EventName:   filter.EventName,
+		From:        filter.From.UTC(),
+		To:          filter.To.UTC(),
+		GroupBy:     filter.GroupBy,
+		Channel:     filter.Channel,
+		Metadata:    filter.Metadata,
+		Consistency: filter.Consistency,
+	})
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
@@ -0,0 +1,476 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	// ScopeWrite allows ingesting events.
+	ScopeWrite Scope = "write"
+	// ScopeRead allows querying metrics and events.
+	ScopeRead Scope = "read"
+	// ScopeAdmin allows the /admin endpoints, including key management.
+	ScopeAdmin Scope = "admin"
+)
+
+// ParseScope validates a scope name.
+func ParseScope(raw string) (Scope, error) {
+	switch Scope(raw) {
+	case ScopeWrite, ScopeRead, ScopeAdmin:
+		return Scope(raw), nil
+	default:
+		return "", fmt.Errorf("invalid scope %q, expected write, read or admin", raw)
+	}
+}
+
+// tokenPrefix marks API keys so they are recognisable in logs and secret
+// scanners.
+const tokenPrefix = "emk_"
+
+var (
+	// ErrKeyNotFound is returned for unknown key IDs.
+	ErrKeyNotFound = errors.New("api key not found")
+	// ErrInvalidKey is returned when a presented key does not authenticate.
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrKeyInactive is returned when rotating a revoked or expired key.
+	ErrKeyInactive = errors.New("api key is revoked or expired")
+)
+
+// APIKey is a stored key. Only the SHA-256 of its secret is kept; the
+// plaintext token is shown once when the key is created or rotated.
+type APIKey struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Scopes []Scope `json:"scopes"`
+
+	// Tenant pins every request made with the key to one tenant. Keys
+	// without a tenant act for the default tenant, except admin keys, which
+	// may choose any tenant per request. Pinned keys are refused on admin
+	// endpoints.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Limits overrides the deployment-wide rate limits and daily event
+	// quota for requests made with the key.
+	Limits *model.ClientLimits `json:"limits,omitempty"`
+
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Has reports whether the key grants scope.
+func (k APIKey) Has(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key can authenticate at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Document is the on-disk key file format.
+type Document struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// KeyStore holds API keys. It is safe for concurrent use; keys can be
+// managed at runtime through the admin API and are written back to the file.
+// The file stays the source of truth: changes made to it by another process,
+// such as the keys CLI, are read back before every change and by Watch.
+type KeyStore struct {
+	mu   sync.RWMutex
+	path string
+	keys map[string]APIKey
+	now  func() time.Time
+
+	// modTime and size identify the file version keys was read from or
+	// last written as.
+	modTime time.Time
+	size    int64
+}
+
+// NewKeyStore creates an empty store. When path is set, changes are written
+// back to that file.
+func NewKeyStore(path string) *KeyStore {
+	return &KeyStore{path: path, keys: map[string]APIKey{}, now: time.Now}
+}
+
+// LoadKeyStore reads a key file from path. A missing file yields an empty
+// store that will be created on the first change.
+func LoadKeyStore(path string) (*KeyStore, error) {
+	s := NewKeyStore(path)
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the key file again. On error the current keys are kept.
+func (s *KeyStore) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+// Watch checks the key file every interval and reloads it when its size or
+// modification time changed, until ctx is done. Unreadable files are logged
+// and the current keys stay active.
+func (s *KeyStore) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			changed, err := s.refreshLocked()
+			s.mu.Unlock()
+			if err != nil {
+				// Report a broken file once, not every tick.
+				if err.Error() != lastErr {
+					log.Printf("[WARN] reload api keys, keeping the current keys: %v", err)
+				}
+				lastErr = err.Error()
+				continue
+			}
+			lastErr = ""
+			if changed {
+				log.Printf("[INFO] api keys reloaded")
+			}
+		}
+	}
+}
+
+// refreshLocked reloads the key file when it changed since it was last read
+// or written, and reports whether it did.
+func (s *KeyStore) refreshLocked() (bool, error) {
+	if s.path == "" {
+		return false, nil
+	}
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing to merge; the next change creates the file again.
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat api keys: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return false, nil
+	}
+	return true, s.loadLocked()
+}
+
+// loadLocked replaces the keys with the contents of the key file. A missing
+// file yields no keys.
+func (s *KeyStore) loadLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.keys = map[string]APIKey{}
+		s.modTime, s.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read api keys: %w", err)
+	}
+	body, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("read api keys: %w", err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("parse api keys: %w", err)
+	}
+
+	keys := make(map[string]APIKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		keys[k.ID] = k
+	}
+	s.keys = keys
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// Authenticate returns the active key matching token.
+func (s *KeyStore) Authenticate(token string) (APIKey, error) {
+	id, secret, ok := splitToken(token)
+	if !ok {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	s.mu.RLock()
+	key, found := s.keys[id]
+	s.mu.RUnlock()
+
+	// Compare against a dummy hash for unknown IDs so both paths do the
+	// same work.
+	want := key.Hash
+	if !found {
+		want = hashSecret("")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(want)) != 1 || !found {
+		return APIKey{}, ErrInvalidKey
+	}
+	if !key.Active(s.now()) {
+		return APIKey{}, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// List returns all keys, including revoked and expired ones, sorted by
+// creation time.
+func (s *KeyStore) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked()
+}
+
+// Create adds a key and returns it together with its plaintext token. An
+// empty tenant leaves the key unpinned.
+func (s *KeyStore) Create(name string, scopes []Scope, tenant string) (APIKey, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.refreshLocked(); err != nil {
+		return APIKey{}, "", err
+	}
+	return s.createLocked(APIKey{Name: name, Scopes: scopes, Tenant: tenant})
+}
+
+// Revoke disables a key immediately.
+func (s *KeyStore) Revoke(id string) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.refreshLocked(); err != nil {
+		return APIKey{}, err
+	}
+
+	key, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, ErrKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	previous := key
+	now := s.now().UTC()
+	key.RevokedAt = &now
+	s.keys[id] = key
+	if err := s.saveLocked(); err != nil {
+		s.keys[id] = previous
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+// SetLimits replaces the rate limits and quota of a key. Nil or zero limits
+// restore the defaults.
+func (s *KeyStore) SetLimits(id string, limits *model.ClientLimits) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.refreshLocked(); err != nil {
+		return APIKey{}, err
+	}
+
+	key, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, ErrKeyNotFound
+	}
+
+	previous := key
+	key.Limits = nil
+	if limits != nil && !limits.IsZero() {
+		copied := *limits
+		key.Limits = &copied
+	}
+	s.keys[id] = key
+	if err := s.saveLocked(); err != nil {
+		s.keys[id] = previous
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+// Rotate creates a replacement key with the same name, scopes, tenant and
+// limits. The old key keeps working for grace so clients can switch over,
+// or is revoked at once when grace is zero. Revoked and expired keys cannot
+// be rotated.
+func (s *KeyStore) Rotate(id string, grace time.Duration) (APIKey, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.refreshLocked(); err != nil {
+		return APIKey{}, "", err
+	}
+
+	old, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, "", ErrKeyNotFound
+	}
+
+	now := s.now().UTC()
+	if !old.Active(now) {
+		return APIKey{}, "", ErrKeyInactive
+	}
+
+	retired := old
+	if grace > 0 {
+		expires := now.Add(grace)
+		if retired.ExpiresAt == nil || expires.Before(*retired.ExpiresAt) {
+			retired.ExpiresAt = &expires
+		}
+	} else {
+		retired.RevokedAt = &now
+	}
+	s.keys[id] = retired
+
+	key, token, err := s.createLocked(old)
+	if err != nil {
+		s.keys[id] = old
+		return APIKey{}, "", err
+	}
+	return key, token, nil
+}
+
+// createLocked stores a new key with the name, scopes, tenant and limits of
+// template.
+func (s *KeyStore) createLocked(template APIKey) (APIKey, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	key := APIKey{
+		ID:        id,
+		Name:      template.Name,
+		Scopes:    append([]Scope{}, template.Scopes...),
+		Tenant:    template.Tenant,
+		Limits:    template.Limits,
+		Hash:      hashSecret(secret),
+		CreatedAt: s.now().UTC(),
+	}
+	s.keys[id] = key
+	if err := s.saveLocked(); err != nil {
+		delete(s.keys, id)
+		return APIKey{}, "", err
+	}
+	return key, tokenPrefix + id + "_" + secret, nil
+}
+
+// splitToken parses "emk_<id>_<secret>". The ID is hex, so the first
+// underscore after the prefix separates it from the secret.
+func splitToken(token string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(token, tokenPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *KeyStore) sortedLocked() []APIKey {
+	out := make([]APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// saveLocked writes the key file via a temp file and rename so a crash never
+// leaves a truncated document behind.
+func (s *KeyStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	body, err := json.MarshalIndent(Document{Keys: s.sortedLocked()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode api keys: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".api-keys-*.json")
+	if err != nil {
+		return fmt.Errorf("write api keys: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(body, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write api keys: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write api keys: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write api keys: %w", err)
+	}
+
+	// Remember the version just written so Watch does not read it back.
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
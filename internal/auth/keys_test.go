@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyStore_CreateAndAuthenticate(t *testing.T) {
+	store := NewKeyStore("")
+
+	key, token, err := store.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, tokenPrefix+key.ID+"_"))
+	require.NotContains(t, key.Hash, strings.TrimPrefix(token, tokenPrefix+key.ID+"_"))
+
+	got, err := store.Authenticate(token)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, got.ID)
+	require.True(t, got.Has(ScopeWrite))
+	require.False(t, got.Has(ScopeRead))
+}
+
+func TestKeyStore_RejectsWrongSecretsAndFormats(t *testing.T) {
+	store := NewKeyStore("")
+	key, _, err := store.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+
+	for _, token := range []string{
+		"",
+		"not-a-key",
+		tokenPrefix + key.ID + "_wrong",
+		tokenPrefix + "deadbeefdeadbeef_secret",
+		tokenPrefix + key.ID,
+	} {
+		_, err := store.Authenticate(token)
+		require.ErrorIs(t, err, ErrInvalidKey, token)
+	}
+}
+
+func TestKeyStore_Revoke(t *testing.T) {
+	store := NewKeyStore("")
+	key, token, err := store.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+
+	revoked, err := store.Revoke(key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+
+	_, err = store.Authenticate(token)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = store.Revoke("missing")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestKeyStore_RotateWithGrace(t *testing.T) {
+	store := NewKeyStore("")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	old, oldToken, err := store.Create("backend", []Scope{ScopeRead, ScopeWrite}, "acme")
+	require.NoError(t, err)
+	old, err = store.SetLimits(old.ID, &model.ClientLimits{IngestRate: 50})
+	require.NoError(t, err)
+
+	replacement, newToken, err := store.Rotate(old.ID, time.Hour)
+	require.NoError(t, err)
+	require.NotEqual(t, old.ID, replacement.ID)
+	require.Equal(t, old.Scopes, replacement.Scopes)
+	require.Equal(t, "acme", replacement.Tenant)
+	require.Equal(t, "backend", replacement.Name)
+	require.Equal(t, &model.ClientLimits{IngestRate: 50}, replacement.Limits)
+
+	_, err = store.Authenticate(oldToken)
+	require.NoError(t, err, "old key works during the grace period")
+
+	now = now.Add(2 * time.Hour)
+	_, err = store.Authenticate(oldToken)
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = store.Authenticate(newToken)
+	require.NoError(t, err)
+}
+
+func TestKeyStore_RotateWithoutGraceRevokes(t *testing.T) {
+	store := NewKeyStore("")
+	old, oldToken, err := store.Create("backend", []Scope{ScopeRead}, "")
+	require.NoError(t, err)
+
+	_, _, err = store.Rotate(old.ID, 0)
+	require.NoError(t, err)
+
+	_, err = store.Authenticate(oldToken)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestKeyStore_RotateRefusesInactiveKeys(t *testing.T) {
+	store := NewKeyStore("")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	revoked, _, err := store.Create("revoked", []Scope{ScopeRead}, "")
+	require.NoError(t, err)
+	_, err = store.Revoke(revoked.ID)
+	require.NoError(t, err)
+
+	expiring, _, err := store.Create("expiring", []Scope{ScopeRead}, "")
+	require.NoError(t, err)
+	_, _, err = store.Rotate(expiring.ID, time.Hour)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Hour)
+
+	for _, id := range []string{revoked.ID, expiring.ID} {
+		_, _, err := store.Rotate(id, 0)
+		require.ErrorIs(t, err, ErrKeyInactive)
+	}
+	require.Len(t, store.List(), 3, "no replacement is issued for inactive keys")
+}
+
+// TestKeyStore_MergesChangesFromOtherWriters covers the keys CLI editing the
+// file while the server holds its own copy.
+func TestKeyStore_MergesChangesFromOtherWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	server, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	key, token, err := server.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+
+	cli, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	_, err = cli.Revoke(key.ID)
+	require.NoError(t, err)
+
+	// A change made through the server must not undo the CLI's revoke.
+	_, _, err = server.Create("backend", []Scope{ScopeRead}, "")
+	require.NoError(t, err)
+	_, err = server.Authenticate(token)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	reloaded, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	require.Len(t, reloaded.List(), 2)
+	_, err = reloaded.Authenticate(token)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestKeyStore_WatchPicksUpRevokes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	server, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	key, token, err := server.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Watch(ctx, 10*time.Millisecond)
+
+	cli, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	_, err = cli.Revoke(key.ID)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := server.Authenticate(token)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestKeyStore_SetLimits(t *testing.T) {
+	store := NewKeyStore("")
+	key, _, err := store.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+
+	key, err = store.SetLimits(key.ID, &model.ClientLimits{IngestRate: 10, DailyEvents: 1000})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), key.Limits.DailyEvents)
+
+	key, err = store.SetLimits(key.ID, &model.ClientLimits{})
+	require.NoError(t, err)
+	require.Nil(t, key.Limits)
+
+	_, err = store.SetLimits("missing", nil)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestKeyStore_PersistsHashesOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	store, err := LoadKeyStore(path)
+	require.NoError(t, err)
+
+	key, token, err := store.Create("web-sdk", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+
+	reloaded, err := LoadKeyStore(path)
+	require.NoError(t, err)
+	require.Equal(t, []APIKey{key}, reloaded.List())
+
+	_, err = reloaded.Authenticate(token)
+	require.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireScope(t *testing.T) {
+	store := NewKeyStore("")
+	_, writeToken, err := store.Create("ingest", []Scope{ScopeWrite}, "")
+	require.NoError(t, err)
+	readKey, readToken, err := store.Create("dashboard", []Scope{ScopeRead}, "")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Get("/metrics", RequireScope(store, ScopeRead), func(c *fiber.Ctx) error {
+		key, ok := KeyFromContext(c)
+		require.True(t, ok)
+		return c.SendString(key.ID)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"invalid key", HeaderAPIKey, "emk_0000_nope", http.StatusUnauthorized},
+		{"wrong scope", HeaderAPIKey, writeToken, http.StatusForbidden},
+		{"bearer", fiber.HeaderAuthorization, "Bearer " + readToken, http.StatusOK},
+		{"header", HeaderAPIKey, readToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(HeaderAPIKey, readToken)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	require.Equal(t, readKey.ID, string(body[:n]))
+}
+
+func TestRequireScope_PinnedAdminKey(t *testing.T) {
+	store := NewKeyStore("")
+	// Pinned admin keys can no longer be created, but may exist in older
+	// key files.
+	_, pinnedToken, err := store.Create("acme-ops", []Scope{ScopeAdmin}, "acme")
+	require.NoError(t, err)
+	_, adminToken, err := store.Create("ops", []Scope{ScopeAdmin}, "")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Post("/admin/privacy/erase", RequireScope(store, ScopeAdmin), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusAccepted)
+	})
+
+	for token, status := range map[string]int{pinnedToken: http.StatusForbidden, adminToken: http.StatusAccepted} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/privacy/erase", strings.NewReader(`{"tenant_id":"other","user_id":"u1"}`))
+		req.Header.Set(HeaderAPIKey, token)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode)
+	}
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of a signed request. The signature is "v1=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" under the client's secret.
+const (
+	HeaderSignatureClient    = "X-Signature-Client"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	HeaderSignature          = "X-Signature"
+)
+
+// signatureVersion prefixes signatures so the scheme can change later.
+const signatureVersion = "v1="
+
+// pruneEvery is how many verified signatures pass between sweeps of the
+// replay cache.
+const pruneEvery = 1024
+
+// Reasons a signature is rejected, reported in the 401 body and counted.
+const (
+	ReasonMissingSignature   = "missing_signature"
+	ReasonUnknownClient      = "unknown_client"
+	ReasonMalformedSignature = "malformed_signature"
+	ReasonStaleTimestamp     = "stale_timestamp"
+	ReasonInvalidSignature   = "invalid_signature"
+	ReasonReplayedRequest    = "replayed_request"
+)
+
+// SignatureError describes why a request signature was rejected.
+type SignatureError struct {
+	Reason  string
+	Message string
+}
+
+func (e *SignatureError) Error() string {
+	return e.Message
+}
+
+// SignatureStats counts verification outcomes since start.
+type SignatureStats struct {
+	Verified uint64            `json:"verified"`
+	Failures map[string]uint64 `json:"failures"`
+}
+
+// SignatureVerifier checks HMAC-signed requests against per-client secrets.
+// A timestamp outside the replay window is rejected, and so is a signature
+// already seen within it.
+type SignatureVerifier struct {
+	secrets  map[string][]byte
+	window   time.Duration
+	required bool
+	now      func() time.Time
+
+	mu       sync.Mutex
+	seen     map[string]time.Time
+	accepted int
+	verified uint64
+	failures map[string]uint64
+}
+
+// NewSignatureVerifier builds a verifier. Unless required, requests without
+// any signature header pass unverified.
+func NewSignatureVerifier(secrets map[string][]byte, window time.Duration, required bool) *SignatureVerifier {
+	return &SignatureVerifier{
+		secrets:  secrets,
+		window:   window,
+		required: required,
+		now:      time.Now,
+		seen:     map[string]time.Time{},
+		failures: map[string]uint64{},
+	}
+}
+
+// ParseSigningSecrets parses "client_id=base64secret" pairs separated by
+// commas.
+func ParseSigningSecrets(raw string) (map[string][]byte, error) {
+	secrets := map[string][]byte{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(pair, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid signing secret %q, expected client_id=base64secret", id)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing secret %s: %w", id, err)
+		}
+		if len(secret) < 16 {
+			return nil, fmt.Errorf("signing secret %s must be at least 16 bytes", id)
+		}
+		secrets[id] = secret
+	}
+	return secrets, nil
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	return signatureVersion + hex.EncodeToString(signatureMAC(secret, timestamp, body))
+}
+
+func signatureMAC(secret []byte, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Verify checks a request's signature headers against its body.
+func (v *SignatureVerifier) Verify(clientID, timestamp, signature string, body []byte) error {
+	err := v.verify(clientID, timestamp, signature, body)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err != nil {
+		v.failures[err.Reason]++
+		return err
+	}
+	v.verified++
+	return nil
+}
+
+func (v *SignatureVerifier) verify(clientID, timestamp, signature string, body []byte) *SignatureError {
+	if clientID == "" || timestamp == "" || signature == "" {
+		return &SignatureError{Reason: ReasonMissingSignature, Message: "signature headers are required"}
+	}
+
+	secret, ok := v.secrets[clientID]
+	if !ok {
+		return &SignatureError{Reason: ReasonUnknownClient, Message: "unknown signing client " + clientID}
+	}
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return &SignatureError{Reason: ReasonMalformedSignature, Message: "signature timestamp must be unix seconds"}
+	}
+	encoded, ok := strings.CutPrefix(signature, signatureVersion)
+	if !ok {
+		return &SignatureError{Reason: ReasonMalformedSignature, Message: "signature must start with " + signatureVersion}
+	}
+	presented, err := hex.DecodeString(encoded)
+	if err != nil {
+		return &SignatureError{Reason: ReasonMalformedSignature, Message: "signature must be hex encoded"}
+	}
+
+	now := v.now()
+	if skew := now.Sub(time.Unix(sentAt, 0)); skew > v.window || skew < -v.window {
+		return &SignatureError{Reason: ReasonStaleTimestamp, Message: "signature timestamp is outside the replay window"}
+	}
+
+	if !hmac.Equal(presented, signatureMAC(secret, sentAt, body)) {
+		return &SignatureError{Reason: ReasonInvalidSignature, Message: "signature does not match"}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	replayKey := clientID + ":" + hex.EncodeToString(presented)
+	if _, seen := v.seen[replayKey]; seen {
+		return &SignatureError{Reason: ReasonReplayedRequest, Message: "signature was already used"}
+	}
+	v.seen[replayKey] = time.Unix(sentAt, 0)
+	if v.accepted++; v.accepted%pruneEvery == 0 {
+		v.pruneLocked(now)
+	}
+	return nil
+}
+
+// pruneLocked forgets signatures whose timestamp has left the replay
+// window; they are rejected as stale from then on.
+func (v *SignatureVerifier) pruneLocked(now time.Time) {
+	for key, sentAt := range v.seen {
+		if now.Sub(sentAt) > v.window {
+			delete(v.seen, key)
+		}
+	}
+}
+
+// Stats returns the verification counters.
+func (v *SignatureVerifier) Stats() SignatureStats {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	failures := make(map[string]uint64, len(v.failures))
+	for reason, count := range v.failures {
+		failures[reason] = count
+	}
+	return SignatureStats{Verified: v.verified, Failures: failures}
+}
+
+// Middleware verifies signed requests and answers failures with 401 and a
+// JSON body naming the reason.
+func (v *SignatureVerifier) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID := c.Get(HeaderSignatureClient)
+		timestamp := c.Get(HeaderSignatureTimestamp)
+		signature := c.Get(HeaderSignature)
+		if !v.required && clientID == "" && timestamp == "" && signature == "" {
+			return c.Next()
+		}
+
+		var sigErr *SignatureError
+		if err := v.Verify(clientID, timestamp, signature, c.Body()); errors.As(err, &sigErr) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":  sigErr.Message,
+				"reason": sigErr.Reason,
+			})
+		}
+		return c.Next()
+	}
+}
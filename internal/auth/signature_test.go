@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var testSigningSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestParseSigningSecrets(t *testing.T) {
+	secrets, err := ParseSigningSecrets("partner-a=MDEyMzQ1Njc4OWFiY2RlZg==, partner-b=MDEyMzQ1Njc4OWFiY2RlZjAx")
+	require.NoError(t, err)
+	require.Equal(t, []byte("0123456789abcdef"), secrets["partner-a"])
+	require.Len(t, secrets, 2)
+
+	for _, raw := range []string{"partner-a", "=MDEyMzQ1Njc4OWFiY2RlZg==", "partner-a=not base64", "partner-a=c2hvcnQ="} {
+		_, err := ParseSigningSecrets(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestSignatureVerifier_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event_name":"signup"}`)
+	valid := Sign(testSigningSecret, now.Unix(), body)
+	stamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		client    string
+		timestamp string
+		signature string
+		body      []byte
+		reason    string
+	}{
+		{"valid", "partner", stamp, valid, body, ""},
+		{"missing headers", "", "", "", body, ReasonMissingSignature},
+		{"unknown client", "other", stamp, valid, body, ReasonUnknownClient},
+		{"bad timestamp", "partner", "yesterday", valid, body, ReasonMalformedSignature},
+		{"missing version", "partner", stamp, strings.TrimPrefix(valid, "v1="), body, ReasonMalformedSignature},
+		{"stale", "partner", strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10), Sign(testSigningSecret, now.Add(-6*time.Minute).Unix(), body), body, ReasonStaleTimestamp},
+		{"from the future", "partner", strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10), Sign(testSigningSecret, now.Add(6*time.Minute).Unix(), body), body, ReasonStaleTimestamp},
+		{"tampered body", "partner", stamp, valid, []byte(`{"event_name":"purchase"}`), ReasonInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewSignatureVerifier(map[string][]byte{"partner": testSigningSecret}, 5*time.Minute, true)
+			verifier.now = func() time.Time { return now }
+
+			err := verifier.Verify(tt.client, tt.timestamp, tt.signature, tt.body)
+			if tt.reason == "" {
+				require.NoError(t, err)
+				return
+			}
+			var sigErr *SignatureError
+			require.ErrorAs(t, err, &sigErr)
+			require.Equal(t, tt.reason, sigErr.Reason)
+		})
+	}
+}
+
+func TestSignatureVerifier_RejectsReplays(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewSignatureVerifier(map[string][]byte{"partner": testSigningSecret}, 5*time.Minute, true)
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{}`)
+	signature := Sign(testSigningSecret, now.Unix(), body)
+	stamp := strconv.FormatInt(now.Unix(), 10)
+
+	require.NoError(t, verifier.Verify("partner", stamp, signature, body))
+	err := verifier.Verify("partner", stamp, strings.ToUpper(signature[3:]), body)
+	require.Error(t, err)
+	err = verifier.Verify("partner", stamp, "v1="+strings.ToUpper(signature[3:]), body)
+	var sigErr *SignatureError
+	require.ErrorAs(t, err, &sigErr)
+	require.Equal(t, ReasonReplayedRequest, sigErr.Reason)
+
+	require.Equal(t, SignatureStats{
+		Verified: 1,
+		Failures: map[string]uint64{ReasonMalformedSignature: 1, ReasonReplayedRequest: 1},
+	}, verifier.Stats())
+}
+
+func TestSignatureVerifier_Middleware(t *testing.T) {
+	now := time.Now()
+	body := `{"event_name":"signup"}`
+
+	for _, required := range []bool{false, true} {
+		verifier := NewSignatureVerifier(map[string][]byte{"partner": testSigningSecret}, 5*time.Minute, required)
+		app := fiber.New()
+		app.Post("/events", verifier.Middleware(), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusAccepted)
+		})
+
+		unsigned := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		resp, err := app.Test(unsigned, -1)
+		require.NoError(t, err)
+		if required {
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			var payload map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+			require.Equal(t, ReasonMissingSignature, payload["reason"])
+		} else {
+			require.Equal(t, http.StatusAccepted, resp.StatusCode, "unsigned requests pass unless signing is required")
+		}
+
+		signed := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		signed.Header.Set(HeaderSignatureClient, "partner")
+		signed.Header.Set(HeaderSignatureTimestamp, strconv.FormatInt(now.Unix(), 10))
+		signed.Header.Set(HeaderSignature, Sign(testSigningSecret, now.Unix(), []byte(body)))
+		resp, err = app.Test(signed, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		forged := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		forged.Header.Set(HeaderSignatureClient, "partner")
+		forged.Header.Set(HeaderSignatureTimestamp, strconv.FormatInt(now.Unix(), 10))
+		forged.Header.Set(HeaderSignature, Sign([]byte("not-the-partner-secret"), now.Unix(), []byte(body)))
+		resp, err = app.Test(forged, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+// tokenLeeway tolerates clock differences with the identity provider.
+const tokenLeeway = 30 * time.Second
+
+// AllTenants in a token's tenants claim grants every tenant.
+const AllTenants = "*"
+
+// ErrInvalidToken is returned when a bearer token does not verify.
+var ErrInvalidToken = errors.New("invalid token")
+
+// signingAlgorithms maps the accepted JWS algorithms to their hash. "none"
+// and the HMAC algorithms are deliberately absent.
+var signingAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ecdsaCurveBits is the curve size each ES algorithm requires.
+var ecdsaCurveBits = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+// TokenConfig configures how identity tokens are validated and which claims
+// carry a caller's permissions.
+type TokenConfig struct {
+	// Issuer and Audience must match the iss and aud claims when set.
+	Issuer   string
+	Audience string
+
+	// TenantsClaim lists the tenants the caller may query. Without it the
+	// caller may only query the default tenant.
+	TenantsClaim string
+	// EventNamesClaim lists the event names the caller may query. Without
+	// it every event name is allowed.
+	EventNamesClaim string
+}
+
+// Principal is the caller identified by a verified token.
+type Principal struct {
+	Subject    string
+	Tenants    []string
+	EventNames []string
+}
+
+// AllowsTenant reports whether the principal may query tenantID.
+func (p Principal) AllowsTenant(tenantID string) bool {
+	if len(p.Tenants) == 0 {
+		return tenantID == model.DefaultTenant
+	}
+	return slices.Contains(p.Tenants, AllTenants) || slices.Contains(p.Tenants, tenantID)
+}
+
+// DefaultTenant is the tenant queried when the request names none.
+func (p Principal) DefaultTenant() string {
+	if len(p.Tenants) == 0 || slices.Contains(p.Tenants, AllTenants) {
+		return model.DefaultTenant
+	}
+	return p.Tenants[0]
+}
+
+// AllowsEvent reports whether the principal may query eventName.
+func (p Principal) AllowsEvent(eventName string) bool {
+	return p.EventNames == nil || slices.Contains(p.EventNames, eventName)
+}
+
+// TokenVerifier validates JWT bearer tokens against a JWKS.
+type TokenVerifier struct {
+	keys *JWKS
+	cfg  TokenConfig
+	now  func() time.Time
+}
+
+// NewTokenVerifier builds a TokenVerifier.
+func NewTokenVerifier(keys *JWKS, cfg TokenConfig) *TokenVerifier {
+	return &TokenVerifier{keys: keys, cfg: cfg, now: time.Now}
+}
+
+// Verify checks the token's signature and registered claims and returns the
+// principal it identifies. Errors wrap ErrInvalidToken.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	principal, err := v.verify(ctx, token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return principal, nil
+}
+
+func (v *TokenVerifier) verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("header: %w", err)
+	}
+	hash, ok := signingAlgorithms[header.Alg]
+	if !ok {
+		return Principal{}, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("signature: %w", err)
+	}
+	if err := verifySignature(header.Alg, hash, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("claims: %w", err)
+	}
+	if err := v.checkRegisteredClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return Principal{}, errors.New("sub claim is required")
+	}
+	tenants, err := stringList(claims, v.cfg.TenantsClaim)
+	if err != nil {
+		return Principal{}, err
+	}
+	eventNames, err := stringList(claims, v.cfg.EventNamesClaim)
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{Subject: subject, Tenants: tenants, EventNames: eventNames}, nil
+}
+
+func (v *TokenVerifier) checkRegisteredClaims(claims map[string]any) error {
+	now := v.now()
+
+	exp, ok := numericDate(claims, "exp")
+	if !ok {
+		return errors.New("exp claim is required")
+	}
+	if now.After(exp.Add(tokenLeeway)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := numericDate(claims, "nbf"); ok && now.Add(tokenLeeway).Before(nbf) {
+		return errors.New("token is not valid yet")
+	}
+
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if v.cfg.Audience != "" {
+		audiences, err := stringList(claims, "aud")
+		if err != nil || !slices.Contains(audiences, v.cfg.Audience) {
+			return errors.New("token is not meant for this audience")
+		}
+	}
+	return nil
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed string, signature []byte) error {
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match an rsa key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return errors.New("signature does not match")
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().BitSize != ecdsaCurveBits[alg] {
+			return fmt.Errorf("algorithm %s does not match the ec key", alg)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("signature does not match")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("signature does not match")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func numericDate(claims map[string]any, name string) (time.Time, bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// stringList reads a claim holding a string or an array of strings. A
+// missing claim yields nil.
+func stringList(claims map[string]any, name string) ([]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	switch v := claims[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s claim must contain strings", name)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s claim must be a string or an array of strings", name)
+	}
+}
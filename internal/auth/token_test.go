@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// testIssuer signs tokens with locally generated keys and publishes them
+// as a JWKS document.
+type testIssuer struct {
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+}
+
+func (i *testIssuer) jwks(t *testing.T) []byte {
+	t.Helper()
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	body, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(i.rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(i.rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(i.ecKey.X.FillBytes(make([]byte, 32))), "y": b64(i.ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+	}})
+	require.NoError(t, err)
+	return body
+}
+
+func (i *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest.Sum(nil))
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, i.ecKey, digest.Sum(nil))
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		signature = []byte("forged")
+	}
+	return signed + "." + b64(signature)
+}
+
+func validClaims(now time.Time) map[string]any {
+	return map[string]any{
+		"sub":     "alice",
+		"iss":     "https://idp.example.com",
+		"aud":     []string{"event-metrics"},
+		"exp":     now.Add(time.Hour).Unix(),
+		"tenants": []string{"acme", "globex"},
+	}
+}
+
+func newTestVerifier(t *testing.T, issuer *testIssuer, now time.Time) *TokenVerifier {
+	t.Helper()
+
+	keys, err := NewJWKS(issuer.jwks(t))
+	require.NoError(t, err)
+	verifier := NewTokenVerifier(keys, TokenConfig{
+		Issuer:          "https://idp.example.com",
+		Audience:        "event-metrics",
+		TenantsClaim:    "tenants",
+		EventNamesClaim: "event_names",
+	})
+	verifier.now = func() time.Time { return now }
+	return verifier
+}
+
+func TestTokenVerifier_Verify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Unix(1700000000, 0)
+	verifier := newTestVerifier(t, issuer, now)
+
+	with := func(key string, value any) map[string]any {
+		claims := validClaims(now)
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"rsa", issuer.sign(t, "RS256", "rsa-1", validClaims(now)), true},
+		{"ec", issuer.sign(t, "ES256", "ec-1", validClaims(now)), true},
+		{"within leeway", issuer.sign(t, "RS256", "rsa-1", with("exp", now.Add(-10*time.Second).Unix())), true},
+		{"expired", issuer.sign(t, "RS256", "rsa-1", with("exp", now.Add(-time.Minute).Unix())), false},
+		{"no exp", issuer.sign(t, "RS256", "rsa-1", with("exp", nil)), false},
+		{"not yet valid", issuer.sign(t, "RS256", "rsa-1", with("nbf", now.Add(time.Minute).Unix())), false},
+		{"wrong issuer", issuer.sign(t, "RS256", "rsa-1", with("iss", "https://evil.example.com")), false},
+		{"wrong audience", issuer.sign(t, "RS256", "rsa-1", with("aud", "other")), false},
+		{"no subject", issuer.sign(t, "RS256", "rsa-1", with("sub", nil)), false},
+		{"unknown key", issuer.sign(t, "RS256", "rsa-2", validClaims(now)), false},
+		{"algorithm does not match key", issuer.sign(t, "ES256", "rsa-1", validClaims(now)), false},
+		{"hmac", issuer.sign(t, "HS256", "hmac", validClaims(now)), false},
+		{"none", issuer.sign(t, "none", "rsa-1", validClaims(now)), false},
+		{"malformed", "not.a-token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+			if !tt.ok {
+				require.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "alice", principal.Subject)
+			require.Equal(t, []string{"acme", "globex"}, principal.Tenants)
+			require.Nil(t, principal.EventNames)
+		})
+	}
+
+	t.Run("tampered claims", func(t *testing.T) {
+		token := issuer.sign(t, "RS256", "rsa-1", validClaims(now))
+		parts := strings.Split(token, ".")
+		forged, err := json.Marshal(with("tenants", "*"))
+		require.NoError(t, err)
+		parts[1] = base64.RawURLEncoding.EncodeToString(forged)
+
+		_, err = verifier.Verify(context.Background(), strings.Join(parts, "."))
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestPrincipal_Permissions(t *testing.T) {
+	unrestricted := Principal{Subject: "alice"}
+	require.True(t, unrestricted.AllowsTenant("default"))
+	require.False(t, unrestricted.AllowsTenant("acme"))
+	require.True(t, unrestricted.AllowsEvent("signup"))
+
+	scoped := Principal{Tenants: []string{"acme"}, EventNames: []string{"signup"}}
+	require.Equal(t, "acme", scoped.DefaultTenant())
+	require.False(t, scoped.AllowsTenant("default"))
+	require.False(t, scoped.AllowsEvent("purchase"))
+	require.False(t, scoped.AllowsEvent(""))
+
+	everything := Principal{Tenants: []string{AllTenants}}
+	require.Equal(t, "default", everything.DefaultTenant())
+	require.True(t, everything.AllowsTenant("globex"))
+}
+
+func TestLoadJWKS(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Unix(1700000000, 0)
+	token := issuer.sign(t, "ES256", "ec-1", validClaims(now))
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, issuer.jwks(t), 0o600))
+	fromFile, err := LoadJWKS(context.Background(), path, time.Hour)
+	require.NoError(t, err)
+	_, err = fromFile.Key(context.Background(), "ec-1")
+	require.NoError(t, err)
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write(issuer.jwks(t))
+	}))
+	defer server.Close()
+
+	fromURL, err := LoadJWKS(context.Background(), server.URL, time.Hour)
+	require.NoError(t, err)
+	verifier := NewTokenVerifier(fromURL, TokenConfig{TenantsClaim: "tenants"})
+	verifier.now = func() time.Time { return now }
+	_, err = verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, 1, fetches)
+
+	_, err = fromURL.Key(context.Background(), "rotated")
+	require.Error(t, err)
+	require.Equal(t, 1, fetches, "unknown keys are not refetched more than once a minute")
+
+	fromURL.fetched = fromURL.fetched.Add(-2 * time.Minute)
+	_, err = fromURL.Key(context.Background(), "rotated")
+	require.Error(t, err)
+	require.Equal(t, 2, fetches)
+
+	_, err = NewJWKS([]byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`))
+	require.Error(t, err, "a key set without signing keys is rejected")
+}
+
+func TestRequireTokenOrScope(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Now()
+	verifier := newTestVerifier(t, issuer, now)
+
+	store := NewKeyStore("")
+	_, readToken, err := store.Create("dashboard", []Scope{ScopeRead}, "")
+	require.NoError(t, err)
+
+	restricted := validClaims(now)
+	restricted["event_names"] = []string{"signup"}
+	restrictedToken := issuer.sign(t, "RS256", "rsa-1", restricted)
+
+	newApp := func(keys *KeyStore) *fiber.App {
+		app := fiber.New()
+		app.Get("/metrics", RequireTokenOrScope(verifier, keys, ScopeRead), func(c *fiber.Ctx) error {
+			if principal, ok := PrincipalFromContext(c); ok {
+				return c.SendString(principal.Subject)
+			}
+			return c.SendString("key")
+		})
+		return app
+	}
+
+	tests := []struct {
+		name   string
+		keys   *KeyStore
+		token  string
+		query  string
+		status int
+	}{
+		{"token", store, issuer.sign(t, "RS256", "rsa-1", validClaims(now)), "event_name=purchase", http.StatusOK},
+		{"invalid token", store, issuer.sign(t, "HS256", "hmac", validClaims(now)), "event_name=signup", http.StatusUnauthorized},
+		{"allowed event", store, restrictedToken, "event_name=signup", http.StatusOK},
+		{"forbidden event", store, restrictedToken, "event_name=purchase", http.StatusForbidden},
+		{"api key", store, readToken, "event_name=signup", http.StatusOK},
+		{"api key without key auth", nil, readToken, "event_name=signup", http.StatusUnauthorized},
+		{"nothing", nil, "", "event_name=signup", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics?"+tt.query, nil)
+			if tt.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			}
+			resp, err := newApp(tt.keys).Test(req, -1)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/schema"
+	"event-metrics-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+type AdminController interface {
+	ListPartitions(c *fiber.Ctx) error
+	DropPartitions(c *fiber.Ctx) error
+	OptimizePartitions(c *fiber.Ctx) error
+	ListSchemas(c *fiber.Ctx) error
+	PutSchema(c *fiber.Ctx) error
+	DeleteSchema(c *fiber.Ctx) error
+}
+
+// adminController exposes HTTP handlers for operational endpoints.
+type adminController struct {
+	adminService service.AdminService
+}
+
+// NewAdminController builds an AdminController.
+func NewAdminController(svc service.AdminService) AdminController {
+	return &adminController{adminService: svc}
+}
+
+// ListPartitions returns events table partitions with row counts and size.
+func (h *adminController) ListPartitions(c *fiber.Ctx) error {
+	partitions, err := h.adminService.ListPartitions(c.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrNotSupported) {
+			return fiber.NewError(fiber.StatusNotImplemented, "partitions are not supported by the storage backend")
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list partitions")
+	}
+
+	return c.JSON(fiber.Map{"partitions": partitions})
+}
+
+// DropPartitions drops the daily partitions between from and to (YYYY-MM-DD, inclusive).
+func (h *adminController) DropPartitions(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return err
+	}
+
+	dropped, svcErr := h.adminService.DropPartitions(c.Context(), from, to)
+	if svcErr != nil {
+		return partitionActionError(svcErr, "failed to drop partitions")
+	}
+
+	return c.JSON(fiber.Map{"dropped": dropped})
+}
+
+// OptimizePartitions forces a final merge of the daily partitions between
+// from and to (YYYY-MM-DD, inclusive), collapsing duplicate events.
+func (h *adminController) OptimizePartitions(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return err
+	}
+
+	optimized, svcErr := h.adminService.OptimizePartitions(c.Context(), from, to)
+	if svcErr != nil {
+		return partitionActionError(svcErr, "failed to optimize partitions")
+	}
+
+	return c.JSON(fiber.Map{"optimized": optimized})
+}
+
+func partitionActionError(err error, fallback string) error {
+	if _, ok := err.(*service.ValidationError); ok {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if errors.Is(err, service.ErrNotSupported) {
+		return fiber.NewError(fiber.StatusNotImplemented, "partitions are not supported by the storage backend")
+	}
+
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
+
+// ListSchemas returns registered event schemas and violation counts.
+func (h *adminController) ListSchemas(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"schemas":    h.adminService.ListSchemas(),
+		"violations": h.adminService.SchemaViolations(),
+	})
+}
+
+// PutSchema registers or replaces the schema for the event name in the path.
+func (h *adminController) PutSchema(c *fiber.Ctx) error {
+	var eventSchema schema.EventSchema
+	if err := c.BodyParser(&eventSchema); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json payload")
+	}
+
+	eventName := c.Params("event_name")
+	if eventSchema.EventName != "" && eventSchema.EventName != eventName {
+		return fiber.NewError(fiber.StatusBadRequest, "event_name in body does not match path")
+	}
+	eventSchema.EventName = eventName
+
+	if err := h.adminService.PutSchema(eventSchema); err != nil {
+		if _, ok := err.(*service.ValidationError); ok {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to save schema")
+	}
+
+	return c.JSON(eventSchema)
+}
+
+// DeleteSchema removes the schema for the event name in the path.
+func (h *adminController) DeleteSchema(c *fiber.Ctx) error {
+	if err := h.adminService.DeleteSchema(c.Params("event_name")); err != nil {
+		if errors.Is(err, service.ErrSchemaNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to delete schema")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func parseDateRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+func parseDateQuery(c *fiber.Ctx, key string) (time.Time, error) {
+	raw := utils.Trim(c.Query(key), ' ')
+	if raw == "" {
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, key+" is required")
+	}
+
+	day, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid "+key+" date, expected YYYY-MM-DD")
+	}
+	return day, nil
+}
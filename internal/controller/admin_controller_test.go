@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/schema"
+	"event-metrics-service/internal/service"
+	mockservice "event-metrics-service/internal/testdata/mockservice"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminControllerTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	service *mockservice.AdminService
+}
+
+func TestAdminControllerSuite(t *testing.T) {
+	suite.Run(t, new(AdminControllerTestSuite))
+}
+
+func (s *AdminControllerTestSuite) SetupTest() {
+	s.service = &mockservice.AdminService{}
+	ctrl := NewAdminController(s.service)
+	s.app = fiber.New()
+	s.app.Get("/admin/partitions", ctrl.ListPartitions)
+	s.app.Delete("/admin/partitions", ctrl.DropPartitions)
+	s.app.Post("/admin/partitions/optimize", ctrl.OptimizePartitions)
+	s.app.Put("/admin/schemas/:event_name", ctrl.PutSchema)
+	s.app.Delete("/admin/schemas/:event_name", ctrl.DeleteSchema)
+}
+
+func (s *AdminControllerTestSuite) TestListPartitions_Success() {
+	s.service.On("ListPartitions", mock.Anything).Return([]model.Partition{{ID: "20250101", Rows: 5}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/partitions", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestDropPartitions_Success() {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	s.service.On("DropPartitions", mock.Anything, from, to).Return([]model.Partition{{ID: "20250101"}}, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/partitions?from=2025-01-01&to=2025-01-31", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestDropPartitions_InvalidDate() {
+	req := httptest.NewRequest(http.MethodDelete, "/admin/partitions?from=20250101&to=2025-01-31", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestDropPartitions_ValidationError() {
+	s.service.On("DropPartitions", mock.Anything, mock.Anything, mock.Anything).
+		Return([]model.Partition(nil), &service.ValidationError{Message: "from must be before to"})
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/partitions?from=2025-02-01&to=2025-01-31", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestPutSchema_UsesPathEventName() {
+	expected := schema.EventSchema{
+		EventName: "signup",
+		Metadata:  map[string]schema.MetadataField{"plan": {Type: schema.TypeString, Required: true}},
+	}
+	s.service.On("PutSchema", expected).Return(nil)
+
+	body := `{"metadata": {"plan": {"type": "string", "required": true}}}`
+	req := httptest.NewRequest(http.MethodPut, "/admin/schemas/signup", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestPutSchema_EventNameMismatch() {
+	req := httptest.NewRequest(http.MethodPut, "/admin/schemas/signup", strings.NewReader(`{"event_name": "login"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestDeleteSchema_NotFound() {
+	s.service.On("DeleteSchema", "signup").Return(service.ErrSchemaNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/schemas/signup", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *AdminControllerTestSuite) TestOptimizePartitions_NotSupported() {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.service.On("OptimizePartitions", mock.Anything, from, from).Return([]model.Partition(nil), service.ErrNotSupported)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/partitions/optimize?from=2025-01-01&to=2025-01-01", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusNotImplemented, resp.StatusCode)
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+type AuditController interface {
+	SearchAudit(c *fiber.Ctx) error
+}
+
+// auditController exposes the access audit log.
+type auditController struct {
+	auditService service.AuditService
+}
+
+// NewAuditController builds an AuditController.
+func NewAuditController(svc service.AuditService) AuditController {
+	return &auditController{auditService: svc}
+}
+
+// SearchAudit returns audit records, newest first, filtered by tenant_id,
+// principal, action and a from/to window in unix seconds.
+func (h *auditController) SearchAudit(c *fiber.Ctx) error {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		return err
+	}
+
+	limit := 0
+	if raw := utils.Trim(c.Query("limit"), ' '); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid limit")
+		}
+	}
+
+	records, err := h.auditService.SearchAudit(c.Context(), model.AuditQuery{
+		TenantID:  utils.Trim(c.Query("tenant_id"), ' '),
+		Principal: utils.Trim(c.Query("principal"), ' '),
+		Action:    utils.Trim(c.Query("action"), ' '),
+		From:      from,
+		To:        to,
+		Limit:     limit,
+	})
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, validationErr.Message)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to search audit log")
+	}
+
+	return c.JSON(fiber.Map{"records": records})
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+	mockservice "event-metrics-service/internal/testdata/mockservice"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditApp(svc *mockservice.AuditService) *fiber.App {
+	app := fiber.New()
+	app.Get("/admin/audit", NewAuditController(svc).SearchAudit)
+	return app
+}
+
+func TestSearchAudit(t *testing.T) {
+	svc := &mockservice.AuditService{}
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	query := model.AuditQuery{
+		TenantID:  "acme",
+		Principal: "key:k1",
+		Action:    "GET /metrics",
+		From:      time.Unix(1740787200, 0).UTC(),
+		Limit:     10,
+	}
+	svc.On("SearchAudit", mock.Anything, query).
+		Return([]model.AuditRecord{{At: at, Principal: "key:k1", TenantID: "acme", Action: "GET /metrics", Status: 200, RowsRead: 42}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit?tenant_id=acme&principal=key:k1&action=GET%20/metrics&from=1740787200&limit=10", nil)
+	resp, err := newAuditApp(svc).Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Records []model.AuditRecord `json:"records"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Records, 1)
+	require.Equal(t, uint64(42), body.Records[0].RowsRead)
+	svc.AssertExpectations(t)
+}
+
+func TestSearchAudit_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		err    error
+		status int
+	}{
+		{"invalid limit", "limit=ten", nil, http.StatusBadRequest},
+		{"invalid from", "from=yesterday", nil, http.StatusBadRequest},
+		{"validation", "limit=5000", &service.ValidationError{Message: "limit must be between 1 and 1000"}, http.StatusBadRequest},
+		{"storage", "", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockservice.AuditService{}
+			if tt.err != nil {
+				svc.On("SearchAudit", mock.Anything, mock.Anything).Return([]model.AuditRecord(nil), tt.err).Once()
+			}
+
+			resp, err := newAuditApp(svc).Test(httptest.NewRequest(http.MethodGet, "/admin/audit?"+tt.query, nil), -1)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+			svc.AssertExpectations(t)
+		})
+	}
+}
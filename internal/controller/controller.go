@@ -1,11 +1,12 @@
 package controller
 
 import (
-	"strconv"
-	"time"
+	"errors"
+	"strings"
 
 	"event-metrics-service/internal/model"
 	"event-metrics-service/internal/service"
+	"event-metrics-service/internal/tenant"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
@@ -14,6 +15,9 @@ import (
 type EventController interface {
 	CreateEvent(c *fiber.Ctx) error
 	GetMetrics(c *fiber.Ctx) error
+	GetUserEvents(c *fiber.Ctx) error
+	GetUserSummary(c *fiber.Ctx) error
+	ExportEvents(c *fiber.Ctx) error
 }
 
 // EventHandler exposes HTTP handlers for ingestion endpoints.
@@ -32,9 +36,22 @@ func (h *eventController) CreateEvent(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid json payload")
 	}
+	req.TenantID = tenant.FromContext(c)
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+	req.ClientIP = c.IP()
 
 	event, err := h.eventService.BuildEvent(req)
+	if errors.Is(err, service.ErrEventDropped) {
+		return c.SendStatus(fiber.StatusAccepted)
+	}
 	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) && len(validationErr.Details) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   validationErr.Message,
+				"details": validationErr.Details,
+			})
+		}
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
@@ -43,23 +60,40 @@ func (h *eventController) CreateEvent(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusAccepted)
 }
 
-// GetMetrics returns aggregated metrics for events.
+// GetMetrics returns aggregated metrics for events as JSON, or as CSV or
+// OpenMetrics when asked for via the format parameter or Accept header.
 func (h *eventController) GetMetrics(c *fiber.Ctx) error {
+	format, err := negotiateMetricsFormat(c)
+	if err != nil {
+		return err
+	}
+
 	filter, err := buildMetricsFilter(c)
 	if err != nil {
 		return err
 	}
 
-	resp, svcErr := h.eventService.GetMetrics(c.Context(), filter)
+	ctx, stop := requestContext(c)
+	defer stop()
+
+	resp, svcErr := h.eventService.GetMetrics(ctx, filter)
 	if svcErr != nil {
 		if _, ok := svcErr.(*service.ValidationError); ok {
 			return fiber.NewError(fiber.StatusBadRequest, svcErr.Error())
 		}
 
+		if errors.Is(svcErr, service.ErrQueryTimeout) {
+			return fiber.NewError(fiber.StatusGatewayTimeout, "metrics query timed out; narrow the time window or add filters")
+		}
+
+		if errors.Is(svcErr, service.ErrQueryLimitExceeded) {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, "metrics query exceeded resource limits; narrow the time window or add filters")
+		}
+
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch metrics")
 	}
 
-	return c.JSON(resp)
+	return renderMetrics(c, format, resp)
 }
 
 func buildMetricsFilter(c *fiber.Ctx) (model.MetricsFilter, error) {
@@ -70,22 +104,9 @@ func buildMetricsFilter(c *fiber.Ctx) (model.MetricsFilter, error) {
 
 	groupBy := utils.Trim(c.Query("group_by", "channel"), ' ')
 
-	var from, to time.Time
-
-	if raw := utils.Trim(c.Query("from"), ' '); raw != "" {
-		sec, parseErr := strconv.ParseInt(raw, 10, 64)
-		if parseErr != nil {
-			return model.MetricsFilter{}, fiber.NewError(fiber.StatusBadRequest, "invalid from timestamp")
-		}
-		from = time.Unix(sec, 0).UTC()
-	}
-
-	if raw := utils.Trim(c.Query("to"), ' '); raw != "" {
-		sec, parseErr := strconv.ParseInt(raw, 10, 64)
-		if parseErr != nil {
-			return model.MetricsFilter{}, fiber.NewError(fiber.StatusBadRequest, "invalid to timestamp")
-		}
-		to = time.Unix(sec, 0).UTC()
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		return model.MetricsFilter{}, err
 	}
 
 	var channel *string
@@ -93,11 +114,26 @@ func buildMetricsFilter(c *fiber.Ctx) (model.MetricsFilter, error) {
 		channel = &raw
 	}
 
+	var metadata map[string]string
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		name, ok := strings.CutPrefix(string(key), model.MetadataGroupPrefix)
+		if !ok || name == "" {
+			return
+		}
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata[name] = string(value)
+	})
+
 	return model.MetricsFilter{
-		EventName: eventName,
-		GroupBy:   groupBy,
-		From:      from,
-		To:        to,
-		Channel:   channel,
+		TenantID:    tenant.FromContext(c),
+		EventName:   eventName,
+		GroupBy:     groupBy,
+		From:        from,
+		To:          to,
+		Channel:     channel,
+		Metadata:    metadata,
+		Consistency: utils.Trim(c.Query("consistency"), ' '),
 	}, nil
 }
@@ -3,12 +3,16 @@ package controller
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+	"event-metrics-service/internal/tenant"
 
 	mockservice "event-metrics-service/internal/testdata/mockservice"
 
@@ -32,8 +36,12 @@ func (s *ControllerTestSuite) SetupTest() {
 	s.service = &mockservice.Service{}
 	ctrl := NewEventController(s.service)
 	s.app = fiber.New()
+	s.app.Use(tenant.Middleware(nil))
 	s.app.Post("/events", ctrl.CreateEvent)
 	s.app.Get("/metrics", ctrl.GetMetrics)
+	s.app.Get("/users/:user_id/events", ctrl.GetUserEvents)
+	s.app.Get("/users/:user_id/summary", ctrl.GetUserSummary)
+	s.app.Get("/events/export", ctrl.ExportEvents)
 }
 
 func (s *ControllerTestSuite) TestCreateEvent_Success() {
@@ -42,7 +50,9 @@ func (s *ControllerTestSuite) TestCreateEvent_Success() {
 		EventName: "signup",
 		Channel:   "web",
 		UserID:    "u1",
-		Timestamp: now.Unix(),
+		Timestamp: model.RawTimestamp(strconv.FormatInt(now.Unix(), 10)),
+		TenantID:  model.DefaultTenant,
+		ClientIP:  "0.0.0.0",
 	}
 	ev := model.Event{
 		EventName: "signup",
@@ -58,6 +68,51 @@ func (s *ControllerTestSuite) TestCreateEvent_Success() {
 	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
 }
 
+func (s *ControllerTestSuite) TestCreateEvent_TenantFromHeader() {
+	reqBody := model.EventRequest{EventName: "signup", Channel: "web", UserID: "u1", Timestamp: "100"}
+	expected := reqBody
+	expected.TenantID = "acme"
+	expected.ClientIP = "0.0.0.0"
+	ev := model.Event{TenantID: "acme", EventName: "signup", Channel: "web", UserID: "u1", Timestamp: time.Unix(100, 0).UTC()}
+	s.service.On("BuildEvent", expected).Return(ev, nil).Once()
+	s.service.On("ProcessEvent", mock.Anything, ev).Return(nil).Once()
+
+	payload, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(tenant.HeaderTenantID, "acme")
+	resp, err := s.app.Test(req, -1)
+
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+	s.service.AssertExpectations(s.T())
+}
+
+func (s *ControllerTestSuite) TestCreateEvent_RequestSource() {
+	reqBody := model.EventRequest{EventName: "signup", Channel: "web", UserID: "u1", Timestamp: "100"}
+	expected := reqBody
+	expected.TenantID = model.DefaultTenant
+	expected.UserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X)"
+	expected.ClientIP = "203.0.113.7"
+	ev := model.Event{EventName: "signup", Channel: "web", UserID: "u1", Timestamp: time.Unix(100, 0).UTC()}
+	s.service.On("BuildEvent", expected).Return(ev, nil).Once()
+	s.service.On("ProcessEvent", mock.Anything, ev).Return(nil).Once()
+
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Post("/events", NewEventController(s.service).CreateEvent)
+
+	payload, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(fiber.HeaderUserAgent, expected.UserAgent)
+	req.Header.Set(fiber.HeaderXForwardedFor, expected.ClientIP)
+	resp, err := app.Test(req, -1)
+
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+	s.service.AssertExpectations(s.T())
+}
+
 func (s *ControllerTestSuite) TestCreateEvent_InvalidJSON() {
 	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewBufferString("{"))
 	resp, _ := s.app.Test(req, -1)
@@ -70,7 +125,9 @@ func (s *ControllerTestSuite) TestCreateEvent_BuildError() {
 		EventName: "",
 		Channel:   "web",
 		UserID:    "u1",
-		Timestamp: now.Unix(),
+		Timestamp: model.RawTimestamp(strconv.FormatInt(now.Unix(), 10)),
+		TenantID:  model.DefaultTenant,
+		ClientIP:  "0.0.0.0",
 	}
 	s.service.On("BuildEvent", reqBody).Return(model.Event{}, fiber.ErrBadRequest)
 
@@ -79,9 +136,38 @@ func (s *ControllerTestSuite) TestCreateEvent_BuildError() {
 	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
 }
 
+func (s *ControllerTestSuite) TestCreateEvent_DroppedByRule() {
+	reqBody := model.EventRequest{EventName: "heartbeat", Channel: "web", UserID: "u1", Timestamp: "100", TenantID: model.DefaultTenant, ClientIP: "0.0.0.0"}
+	s.service.On("BuildEvent", reqBody).Return(model.Event{}, service.ErrEventDropped).Once()
+
+	resp := s.performRequest(reqBody)
+
+	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+	s.service.AssertNotCalled(s.T(), "ProcessEvent", mock.Anything, mock.Anything)
+}
+
+func (s *ControllerTestSuite) TestCreateEvent_SchemaViolationDetails() {
+	reqBody := model.EventRequest{EventName: "purchase", Channel: "web", UserID: "u1", Timestamp: "100", TenantID: model.DefaultTenant, ClientIP: "0.0.0.0"}
+	s.service.On("BuildEvent", reqBody).Return(model.Event{}, &service.ValidationError{
+		Message: "event does not match schema",
+		Details: []service.FieldError{{Field: "metadata.price", Message: "is required"}},
+	})
+
+	resp := s.performRequest(reqBody)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+
+	var body struct {
+		Error   string               `json:"error"`
+		Details []service.FieldError `json:"details"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(s.T(), "event does not match schema", body.Error)
+	require.Equal(s.T(), []service.FieldError{{Field: "metadata.price", Message: "is required"}}, body.Details)
+}
+
 func (s *ControllerTestSuite) TestGetMetrics_Success() {
 	filterMatcher := mock.MatchedBy(func(f model.MetricsFilter) bool {
-		return f.EventName == "signup" && f.GroupBy == "channel"
+		return f.TenantID == model.DefaultTenant && f.EventName == "signup" && f.GroupBy == "channel"
 	})
 	expected := model.MetricsResponse{
 		Meta: model.MetricsMeta{
@@ -105,6 +191,19 @@ func (s *ControllerTestSuite) TestGetMetrics_Success() {
 	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
 }
 
+func (s *ControllerTestSuite) TestGetMetrics_MetadataFilters() {
+	filterMatcher := mock.MatchedBy(func(f model.MetricsFilter) bool {
+		return f.GroupBy == "metadata.currency" &&
+			len(f.Metadata) == 1 && f.Metadata["referrer"] == "google"
+	})
+	s.service.On("GetMetrics", mock.Anything, filterMatcher).Return(model.MetricsResponse{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?event_name=purchase&group_by=metadata.currency&metadata.referrer=google", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
 func (s *ControllerTestSuite) TestGetMetrics_MissingEventName() {
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	resp, err := s.app.Test(req, -1)
@@ -119,6 +218,50 @@ func (s *ControllerTestSuite) TestGetMetrics_InvalidFrom() {
 	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
 }
 
+func (s *ControllerTestSuite) TestGetMetrics_TimestampFormats() {
+	from := time.UnixMilli(1723475612123).UTC()
+	to := time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC)
+	s.service.On("GetMetrics", mock.Anything, mock.MatchedBy(func(f model.MetricsFilter) bool {
+		return f.From.Equal(from) && f.To.Equal(to)
+	})).Return(model.MetricsResponse{}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?event_name=signup&from=1723475612123&to=2024-08-13T00:00:00Z", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	for _, query := range []string{
+		"from=20000000000",
+		"from=1723475612&timestamp_unit=us",
+		"to=2024-08-13T00:00:00Z&timestamp_unit=ms",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics?event_name=signup&"+query, nil)
+		resp, err := s.app.Test(req, -1)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_Timeout() {
+	s.service.On("GetMetrics", mock.Anything, mock.Anything).
+		Return(model.MetricsResponse{}, fmt.Errorf("query metrics: %w", service.ErrQueryTimeout))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?event_name=signup", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_LimitExceeded() {
+	s.service.On("GetMetrics", mock.Anything, mock.Anything).
+		Return(model.MetricsResponse{}, fmt.Errorf("query metrics: %w", service.ErrQueryLimitExceeded))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics?event_name=signup", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
 func (s *ControllerTestSuite) performRequest(body any) *http.Response {
 	payload, _ := json.Marshal(body)
 	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(payload))
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"event-metrics-service/internal/audit"
+	"event-metrics-service/internal/export"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportEvents streams raw events matching the /metrics filters as CSV,
+// NDJSON or Parquet, selected with the format query parameter.
+func (h *eventController) ExportEvents(c *fiber.Ctx) error {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	filter, err := buildMetricsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate up front: once streaming starts the status line is gone.
+	filter, err = h.eventService.ValidateExport(filter)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, validationErr.Message)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to start export")
+	}
+
+	audit.RecordFilter(c.UserContext(), filter)
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, filter.EventName, format.Extension()))
+
+	// The stream outlives the handler, so it runs under its own context
+	// derived from the request's. The service bounds it with EXPORT_TIMEOUT;
+	// server shutdown and a failed write cancel it early.
+	parent := c.UserContext()
+	shutdown := c.Context().Done()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(parent)
+		defer cancel()
+		go func() {
+			select {
+			case <-shutdown:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if err := h.streamExport(ctx, cancel, filter, format, w); err != nil {
+			log.Printf("[ERROR] export %s: %v", filter.EventName, err)
+		}
+	})
+	return nil
+}
+
+// streamExport writes the export to w. A failed write means the client went
+// away, so it cancels the query instead of letting it read to the end.
+func (h *eventController) streamExport(ctx context.Context, cancel context.CancelFunc, filter model.MetricsFilter, format export.Format, w *bufio.Writer) error {
+	ew, err := export.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	write := func(e model.Event) error {
+		if err := ew.Write(e); err != nil {
+			cancel()
+			return err
+		}
+		return nil
+	}
+	if err := h.eventService.ExportEvents(ctx, filter, write); err != nil {
+		return err
+	}
+
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
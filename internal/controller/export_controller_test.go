@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"event-metrics-service/internal/export"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ControllerTestSuite) TestExportEvents_StreamsCSV() {
+	filter := model.MetricsFilter{EventName: "purchase", GroupBy: "channel", From: time.Unix(0, 0).UTC(), To: time.Unix(100, 0).UTC()}
+	s.service.On("ValidateExport", mock.MatchedBy(func(f model.MetricsFilter) bool {
+		return f.EventName == "purchase" && f.Metadata["currency"] == "TRY"
+	})).Return(filter, nil).Once()
+	s.service.On("ExportEvents", mock.Anything, filter, mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(2).(func(model.Event) error)
+		_ = fn(model.Event{EventName: "purchase", Channel: "web", UserID: "u1", Timestamp: time.Unix(10, 0).UTC(), ReceivedAt: time.Unix(12, 0).UTC()})
+	}).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/events/export?event_name=purchase&format=csv&metadata.currency=TRY", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	require.Equal(s.T(), "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(s.T(), `attachment; filename="purchase.csv"`, resp.Header.Get("Content-Disposition"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "event_name,channel,campaign_id,user_id,timestamp,received_at,tags,metadata\n"+
+		"purchase,web,,u1,1970-01-01T00:00:10.000Z,1970-01-01T00:00:12.000Z,[],{}\n", string(body))
+}
+
+func (s *ControllerTestSuite) TestExportEvents_InvalidFormat() {
+	req := httptest.NewRequest(http.MethodGet, "/events/export?event_name=purchase&format=xlsx", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *ControllerTestSuite) TestExportEvents_ValidationError() {
+	s.service.On("ValidateExport", mock.Anything).
+		Return(model.MetricsFilter{}, &service.ValidationError{Message: "time window exceeds maximum of 24h0m0s for exports"}).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/events/export?event_name=purchase", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+	s.service.AssertNotCalled(s.T(), "ExportEvents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func (s *ControllerTestSuite) TestStreamExport_CancelsOnWriteFailure() {
+	filter := model.MetricsFilter{EventName: "purchase", From: time.Unix(0, 0).UTC(), To: time.Unix(100, 0).UTC()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var writeErr error
+	s.service.On("ExportEvents", ctx, filter, mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(2).(func(model.Event) error)
+		for i := 0; i < 10 && writeErr == nil; i++ {
+			writeErr = fn(model.Event{EventName: "purchase", UserID: "u1"})
+		}
+		// The query must be cancelled before the repository sees the error.
+		require.ErrorIs(s.T(), ctx.Err(), context.Canceled)
+	}).Return(context.Canceled).Once()
+
+	ctrl := &eventController{eventService: s.service}
+	err := ctrl.streamExport(ctx, cancel, filter, export.FormatNDJSON, bufio.NewWriterSize(failingWriter{}, 16))
+	require.ErrorIs(s.T(), err, context.Canceled)
+	require.EqualError(s.T(), writeErr, "broken pipe")
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+type KeyController interface {
+	ListKeys(c *fiber.Ctx) error
+	CreateKey(c *fiber.Ctx) error
+	RevokeKey(c *fiber.Ctx) error
+	RotateKey(c *fiber.Ctx) error
+	SetKeyLimits(c *fiber.Ctx) error
+}
+
+// keyController exposes HTTP handlers for API key management.
+type keyController struct {
+	keyService service.APIKeyService
+}
+
+// NewKeyController builds a KeyController.
+func NewKeyController(svc service.APIKeyService) KeyController {
+	return &keyController{keyService: svc}
+}
+
+type createKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	Tenant string   `json:"tenant"`
+}
+
+// ListKeys returns all API keys without their secrets.
+func (h *keyController) ListKeys(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"keys": h.keyService.ListKeys()})
+}
+
+// CreateKey issues a key. The token in the response is shown only once.
+func (h *keyController) CreateKey(c *fiber.Ctx) error {
+	var req createKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json payload")
+	}
+
+	key, err := h.keyService.CreateKey(req.Name, req.Scopes, req.Tenant)
+	if err != nil {
+		return keyActionError(err, "failed to create api key")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+// RevokeKey disables the key in the path immediately.
+func (h *keyController) RevokeKey(c *fiber.Ctx) error {
+	key, err := h.keyService.RevokeKey(c.Params("id"))
+	if err != nil {
+		return keyActionError(err, "failed to revoke api key")
+	}
+
+	return c.JSON(key)
+}
+
+// RotateKey issues a replacement for the key in the path. The old key stays
+// valid for the optional grace duration (e.g. grace=24h).
+func (h *keyController) RotateKey(c *fiber.Ctx) error {
+	var grace time.Duration
+	if raw := utils.Trim(c.Query("grace"), ' '); raw != "" {
+		var err error
+		if grace, err = time.ParseDuration(raw); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid grace duration")
+		}
+	}
+
+	key, err := h.keyService.RotateKey(c.Params("id"), grace)
+	if err != nil {
+		return keyActionError(err, "failed to rotate api key")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+// SetKeyLimits replaces the rate limits and daily event quota of the key in
+// the path. An empty body restores the defaults.
+func (h *keyController) SetKeyLimits(c *fiber.Ctx) error {
+	var limits model.ClientLimits
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&limits); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid json payload")
+		}
+	}
+
+	key, err := h.keyService.SetKeyLimits(c.Params("id"), limits)
+	if err != nil {
+		return keyActionError(err, "failed to set api key limits")
+	}
+
+	return c.JSON(key)
+}
+
+func keyActionError(err error, fallback string) error {
+	if _, ok := err.(*service.ValidationError); ok {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if errors.Is(err, service.ErrAPIKeyInactive) {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	return fiber.NewError(fiber.StatusInternalServerError, fallback)
+}
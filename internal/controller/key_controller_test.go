@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+	mockservice "event-metrics-service/internal/testdata/mockservice"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type KeyControllerTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	service *mockservice.APIKeyService
+}
+
+func TestKeyControllerSuite(t *testing.T) {
+	suite.Run(t, new(KeyControllerTestSuite))
+}
+
+func (s *KeyControllerTestSuite) SetupTest() {
+	s.service = &mockservice.APIKeyService{}
+	ctrl := NewKeyController(s.service)
+	s.app = fiber.New()
+	s.app.Get("/admin/keys", ctrl.ListKeys)
+	s.app.Post("/admin/keys", ctrl.CreateKey)
+	s.app.Delete("/admin/keys/:id", ctrl.RevokeKey)
+	s.app.Post("/admin/keys/:id/rotate", ctrl.RotateKey)
+	s.app.Put("/admin/keys/:id/limits", ctrl.SetKeyLimits)
+}
+
+func (s *KeyControllerTestSuite) TearDownTest() {
+	s.service.AssertExpectations(s.T())
+}
+
+func (s *KeyControllerTestSuite) TestCreateKey_ReturnsToken() {
+	s.service.On("CreateKey", "web-sdk", []string{"write"}, "acme").Return(service.CreatedAPIKey{
+		APIKey: auth.APIKey{ID: "abc", Name: "web-sdk", Scopes: []auth.Scope{auth.ScopeWrite}, Tenant: "acme"},
+		Token:  "emk_abc_secret",
+	}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{"name":"web-sdk","scopes":["write"],"tenant":"acme"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, resp.StatusCode)
+
+	var body map[string]any
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(s.T(), "abc", body["id"])
+	require.Equal(s.T(), "acme", body["tenant"])
+	require.Equal(s.T(), "emk_abc_secret", body["token"])
+}
+
+func (s *KeyControllerTestSuite) TestCreateKey_ValidationError() {
+	s.service.On("CreateKey", "", []string(nil), "").
+		Return(service.CreatedAPIKey{}, &service.ValidationError{Message: "name is required"}).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *KeyControllerTestSuite) TestRevokeKey_NotFound() {
+	s.service.On("RevokeKey", "missing").Return(auth.APIKey{}, service.ErrAPIKeyNotFound).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/keys/missing", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *KeyControllerTestSuite) TestRotateKey_Inactive() {
+	s.service.On("RotateKey", "abc", time.Duration(0)).Return(service.CreatedAPIKey{}, service.ErrAPIKeyInactive).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/abc/rotate", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, resp.StatusCode)
+}
+
+func (s *KeyControllerTestSuite) TestRotateKey_WithGrace() {
+	s.service.On("RotateKey", "abc", 24*time.Hour).
+		Return(service.CreatedAPIKey{APIKey: auth.APIKey{ID: "def"}, Token: "emk_def_secret"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/abc/rotate?grace=24h", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, resp.StatusCode)
+}
+
+func (s *KeyControllerTestSuite) TestRotateKey_InvalidGrace() {
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/abc/rotate?grace=soon", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *KeyControllerTestSuite) TestSetKeyLimits() {
+	limits := model.ClientLimits{IngestRate: 50, IngestBurst: 100, DailyEvents: 1000000}
+	s.service.On("SetKeyLimits", "abc", limits).Return(auth.APIKey{ID: "abc", Limits: &limits}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/keys/abc/limits", strings.NewReader(`{"ingest_rate":50,"ingest_burst":100,"daily_events":1000000}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *KeyControllerTestSuite) TestSetKeyLimits_ValidationError() {
+	s.service.On("SetKeyLimits", "abc", model.ClientLimits{QueryRate: -1}).
+		Return(auth.APIKey{}, &service.ValidationError{Message: "limits must not be negative"}).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/keys/abc/limits", strings.NewReader(`{"query_rate":-1}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
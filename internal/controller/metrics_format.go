@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// metricsFormat is a /metrics response rendering.
+type metricsFormat string
+
+const (
+	metricsFormatJSON        metricsFormat = "json"
+	metricsFormatCSV         metricsFormat = "csv"
+	metricsFormatOpenMetrics metricsFormat = "openmetrics"
+)
+
+const (
+	mimeCSV = "text/csv"
+	// The version parameter lets scrapers that ask for a specific
+	// OpenMetrics version match the offer.
+	mimeOpenMetrics = "application/openmetrics-text;version=1.0.0"
+
+	contentTypeCSV         = "text/csv; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// negotiateMetricsFormat picks the rendering from the format query parameter
+// or, failing that, the Accept header. JSON wins ties and absent headers.
+func negotiateMetricsFormat(c *fiber.Ctx) (metricsFormat, error) {
+	switch raw := strings.ToLower(utils.Trim(c.Query("format"), ' ')); raw {
+	case "":
+	case "json":
+		return metricsFormatJSON, nil
+	case "csv":
+		return metricsFormatCSV, nil
+	case "openmetrics", "prometheus":
+		return metricsFormatOpenMetrics, nil
+	default:
+		return "", fiber.NewError(fiber.StatusBadRequest, "format must be json, csv or openmetrics")
+	}
+
+	c.Vary(fiber.HeaderAccept)
+	switch c.Accepts(fiber.MIMEApplicationJSON, mimeCSV, mimeOpenMetrics) {
+	case mimeCSV:
+		return metricsFormatCSV, nil
+	case mimeOpenMetrics:
+		return metricsFormatOpenMetrics, nil
+	default:
+		return metricsFormatJSON, nil
+	}
+}
+
+// renderMetrics writes resp in the negotiated format.
+func renderMetrics(c *fiber.Ctx, format metricsFormat, resp model.MetricsResponse) error {
+	switch format {
+	case metricsFormatCSV:
+		body, err := metricsCSV(resp)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to render metrics")
+		}
+		c.Set(fiber.HeaderContentType, contentTypeCSV)
+		return c.Send(body)
+	case metricsFormatOpenMetrics:
+		c.Set(fiber.HeaderContentType, contentTypeOpenMetrics)
+		return c.Send(metricsOpenMetrics(resp))
+	default:
+		return c.JSON(resp)
+	}
+}
+
+// metricsCSV renders the groups table, one row per group, in the same column
+// order as the JSON group objects.
+func metricsCSV(resp model.MetricsResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{{"key", "total_count", "unique_user_count"}}
+	for _, g := range resp.Data.Groups {
+		rows = append(rows, []string{
+			g.Key,
+			strconv.FormatUint(g.TotalCount, 10),
+			strconv.FormatUint(g.UniqueUserCount, 10),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// metricsOpenMetrics renders the totals, and each group, as gauges: the values
+// describe the requested window rather than a monotonically growing counter.
+func metricsOpenMetrics(resp model.MetricsResponse) []byte {
+	var b strings.Builder
+
+	eventLabel := fmt.Sprintf(`event_name="%s"`, escapeLabelValue(resp.Meta.EventName))
+
+	b.WriteString("# HELP event_metrics_events Events in the requested window.\n")
+	b.WriteString("# TYPE event_metrics_events gauge\n")
+	fmt.Fprintf(&b, "event_metrics_events{%s} %d\n", eventLabel, resp.Data.TotalEventCount)
+
+	b.WriteString("# HELP event_metrics_unique_users Distinct users in the requested window.\n")
+	b.WriteString("# TYPE event_metrics_unique_users gauge\n")
+	fmt.Fprintf(&b, "event_metrics_unique_users{%s} %d\n", eventLabel, resp.Data.UniqueEventCount)
+
+	if len(resp.Data.Groups) > 0 {
+		groupLabel := fmt.Sprintf(`group_by="%s"`, escapeLabelValue(resp.Meta.GroupBy))
+
+		b.WriteString("# HELP event_metrics_group_events Events per group in the requested window.\n")
+		b.WriteString("# TYPE event_metrics_group_events gauge\n")
+		for _, g := range resp.Data.Groups {
+			fmt.Fprintf(&b, "event_metrics_group_events{%s,%s,key=\"%s\"} %d\n", eventLabel, groupLabel, escapeLabelValue(g.Key), g.TotalCount)
+		}
+
+		b.WriteString("# HELP event_metrics_group_unique_users Distinct users per group in the requested window.\n")
+		b.WriteString("# TYPE event_metrics_group_unique_users gauge\n")
+		for _, g := range resp.Data.Groups {
+			fmt.Fprintf(&b, "event_metrics_group_unique_users{%s,%s,key=\"%s\"} %d\n", eventLabel, groupLabel, escapeLabelValue(g.Key), g.UniqueUserCount)
+		}
+	}
+
+	b.WriteString("# EOF\n")
+	return []byte(b.String())
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleMetricsResponse() model.MetricsResponse {
+	return model.MetricsResponse{
+		Meta: model.MetricsMeta{EventName: "purchase", GroupBy: "metadata.note"},
+		Data: model.MetricsData{
+			TotalEventCount:  10,
+			UniqueEventCount: 3,
+			Groups: []model.MetricsGroup{
+				{Key: "plain", TotalCount: 8, UniqueUserCount: 2},
+				{Key: `say "hi", ok`, TotalCount: 2, UniqueUserCount: 1},
+			},
+		},
+	}
+}
+
+func (s *ControllerTestSuite) getMetrics(url, accept string) (*http.Response, string) {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(s.T(), err)
+	return resp, string(body)
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_CSVViaAccept() {
+	s.service.On("GetMetrics", mock.Anything, mock.Anything).Return(sampleMetricsResponse(), nil)
+
+	resp, body := s.getMetrics("/metrics?event_name=purchase", "text/csv")
+
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	require.Equal(s.T(), "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(s.T(), "key,total_count,unique_user_count\n"+
+		"plain,8,2\n"+
+		`"say ""hi"", ok",2,1`+"\n", body)
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_OpenMetricsViaFormat() {
+	s.service.On("GetMetrics", mock.Anything, mock.Anything).Return(sampleMetricsResponse(), nil)
+
+	resp, body := s.getMetrics("/metrics?event_name=purchase&format=openmetrics", "application/json")
+
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	require.Equal(s.T(), "application/openmetrics-text; version=1.0.0; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(s.T(), `# HELP event_metrics_events Events in the requested window.
+# TYPE event_metrics_events gauge
+event_metrics_events{event_name="purchase"} 10
+# HELP event_metrics_unique_users Distinct users in the requested window.
+# TYPE event_metrics_unique_users gauge
+event_metrics_unique_users{event_name="purchase"} 3
+# HELP event_metrics_group_events Events per group in the requested window.
+# TYPE event_metrics_group_events gauge
+event_metrics_group_events{event_name="purchase",group_by="metadata.note",key="plain"} 8
+event_metrics_group_events{event_name="purchase",group_by="metadata.note",key="say \"hi\", ok"} 2
+# HELP event_metrics_group_unique_users Distinct users per group in the requested window.
+# TYPE event_metrics_group_unique_users gauge
+event_metrics_group_unique_users{event_name="purchase",group_by="metadata.note",key="plain"} 2
+event_metrics_group_unique_users{event_name="purchase",group_by="metadata.note",key="say \"hi\", ok"} 1
+# EOF
+`, body)
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_PrometheusScraperAccept() {
+	s.service.On("GetMetrics", mock.Anything, mock.Anything).Return(sampleMetricsResponse(), nil)
+
+	resp, _ := s.getMetrics("/metrics?event_name=purchase",
+		"application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	require.Contains(s.T(), resp.Header.Get("Content-Type"), "application/openmetrics-text")
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_JSONIsDefault() {
+	s.service.On("GetMetrics", mock.Anything, mock.Anything).Return(sampleMetricsResponse(), nil)
+
+	for _, accept := range []string{"", "*/*", "text/html"} {
+		resp, _ := s.getMetrics("/metrics?event_name=purchase", accept)
+		require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+		require.Equal(s.T(), "application/json", resp.Header.Get("Content-Type"), "accept %q", accept)
+	}
+}
+
+func (s *ControllerTestSuite) TestGetMetrics_UnknownFormat() {
+	resp, _ := s.getMetrics("/metrics?event_name=purchase&format=xml", "")
+
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+	s.service.AssertNotCalled(s.T(), "GetMetrics", mock.Anything, mock.Anything)
+}
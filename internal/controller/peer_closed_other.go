@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package controller
+
+import "net"
+
+// peerClosed is not supported on this platform; requests are only cancelled
+// by their timeout or server shutdown.
+func peerClosed(conn net.Conn) bool {
+	return false
+}
@@ -0,0 +1,31 @@
+//go:build linux || darwin
+
+package controller
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerClosed reports whether the remote end has closed the connection. It
+// peeks without consuming data so a pipelined request stays in the buffer.
+func peerClosed(conn net.Conn) bool {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	closed := false
+	buf := make([]byte, 1)
+	_ = raw.Read(func(fd uintptr) bool {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		closed = n == 0 && err == nil
+		return true
+	})
+	return closed
+}
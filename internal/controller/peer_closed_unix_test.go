@@ -0,0 +1,37 @@
+//go:build linux || darwin
+
+package controller
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerClosed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+
+	server, err := ln.Accept()
+	require.NoError(t, err)
+	defer server.Close()
+
+	_, err = client.Write([]byte("x"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return !peerClosed(server) }, time.Second, 10*time.Millisecond)
+
+	// Pending data must not be consumed by the check.
+	require.NoError(t, client.Close())
+	buf := make([]byte, 1)
+	n, err := server.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.Eventually(t, func() bool { return peerClosed(server) }, time.Second, 10*time.Millisecond)
+}
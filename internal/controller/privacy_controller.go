@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"errors"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PrivacyController interface {
+	RequestErasure(c *fiber.Ctx) error
+	RequestAccess(c *fiber.Ctx) error
+	ListRequests(c *fiber.Ctx) error
+	GetRequest(c *fiber.Ctx) error
+}
+
+// privacyController exposes HTTP handlers for GDPR data-subject requests.
+type privacyController struct {
+	privacyService service.PrivacyService
+}
+
+// NewPrivacyController builds a PrivacyController.
+func NewPrivacyController(svc service.PrivacyService) PrivacyController {
+	return &privacyController{privacyService: svc}
+}
+
+// RequestErasure deletes all events of a user. It answers 202 while the
+// storage backend is still applying the delete.
+func (h *privacyController) RequestErasure(c *fiber.Ctx) error {
+	var input model.PrivacyRequestInput
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json payload")
+	}
+
+	req, err := h.privacyService.RequestErasure(c.Context(), input)
+	if err != nil {
+		if _, ok := err.(*service.ValidationError); ok {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		if errors.Is(err, service.ErrNotSupported) {
+			return fiber.NewError(fiber.StatusNotImplemented, "erasure is not supported by the storage backend")
+		}
+
+		if req.ID == "" {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to record erasure request")
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(req)
+	}
+
+	if req.Status != model.PrivacyStatusCompleted {
+		return c.Status(fiber.StatusAccepted).JSON(req)
+	}
+	return c.JSON(req)
+}
+
+// RequestAccess returns all stored events of a user as JSON.
+func (h *privacyController) RequestAccess(c *fiber.Ctx) error {
+	var input model.PrivacyRequestInput
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json payload")
+	}
+
+	ctx, stop := requestContext(c)
+	defer stop()
+
+	export, err := h.privacyService.RequestAccess(ctx, input)
+	if err != nil {
+		if _, ok := err.(*service.ValidationError); ok {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to export user data")
+	}
+
+	return c.JSON(export)
+}
+
+// ListRequests returns all privacy requests, newest first.
+func (h *privacyController) ListRequests(c *fiber.Ctx) error {
+	requests, err := h.privacyService.ListRequests(c.Context())
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to list privacy requests")
+	}
+
+	return c.JSON(fiber.Map{"requests": requests})
+}
+
+// GetRequest returns the status of one privacy request.
+func (h *privacyController) GetRequest(c *fiber.Ctx) error {
+	req, err := h.privacyService.GetRequest(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrPrivacyRequestNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load privacy request")
+	}
+
+	return c.JSON(req)
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+	mockservice "event-metrics-service/internal/testdata/mockservice"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type PrivacyControllerTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	service *mockservice.PrivacyService
+}
+
+func TestPrivacyControllerSuite(t *testing.T) {
+	suite.Run(t, new(PrivacyControllerTestSuite))
+}
+
+func (s *PrivacyControllerTestSuite) SetupTest() {
+	s.service = &mockservice.PrivacyService{}
+	ctrl := NewPrivacyController(s.service)
+	s.app = fiber.New()
+	s.app.Post("/admin/privacy/erasure", ctrl.RequestErasure)
+	s.app.Post("/admin/privacy/access", ctrl.RequestAccess)
+	s.app.Get("/admin/privacy/requests", ctrl.ListRequests)
+	s.app.Get("/admin/privacy/requests/:id", ctrl.GetRequest)
+}
+
+func (s *PrivacyControllerTestSuite) TearDownTest() {
+	s.service.AssertExpectations(s.T())
+}
+
+func (s *PrivacyControllerTestSuite) post(path, body string) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	return resp
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestErasure_Running() {
+	input := model.PrivacyRequestInput{UserID: "u1", RequestedBy: "dpo"}
+	s.service.On("RequestErasure", mock.Anything, input).
+		Return(model.PrivacyRequest{ID: "r1", Status: model.PrivacyStatusRunning}, nil).Once()
+
+	resp := s.post("/admin/privacy/erasure", `{"user_id":"u1","requested_by":"dpo"}`)
+	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+
+	var body model.PrivacyRequest
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(s.T(), "r1", body.ID)
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestErasure_Completed() {
+	s.service.On("RequestErasure", mock.Anything, mock.Anything).
+		Return(model.PrivacyRequest{ID: "r1", Status: model.PrivacyStatusCompleted}, nil).Once()
+
+	resp := s.post("/admin/privacy/erasure", `{"user_id":"u1"}`)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestErasure_ValidationError() {
+	s.service.On("RequestErasure", mock.Anything, mock.Anything).
+		Return(model.PrivacyRequest{}, &service.ValidationError{Message: "user_id is required"}).Once()
+
+	resp := s.post("/admin/privacy/erasure", `{}`)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestErasure_FailedIsReturned() {
+	s.service.On("RequestErasure", mock.Anything, mock.Anything).
+		Return(model.PrivacyRequest{ID: "r1", Status: model.PrivacyStatusFailed}, errors.New("exec error")).Once()
+
+	resp := s.post("/admin/privacy/erasure", `{"user_id":"u1"}`)
+	require.Equal(s.T(), http.StatusInternalServerError, resp.StatusCode)
+
+	var body model.PrivacyRequest
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(s.T(), model.PrivacyStatusFailed, body.Status)
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestErasure_NotSupported() {
+	s.service.On("RequestErasure", mock.Anything, mock.Anything).
+		Return(model.PrivacyRequest{ID: "r1"}, service.ErrNotSupported).Once()
+
+	resp := s.post("/admin/privacy/erasure", `{"user_id":"u1"}`)
+	require.Equal(s.T(), http.StatusNotImplemented, resp.StatusCode)
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestAccess_Success() {
+	s.service.On("RequestAccess", mock.Anything, model.PrivacyRequestInput{UserID: "u1"}).
+		Return(model.UserDataExport{
+			Request: model.PrivacyRequest{ID: "r1", Status: model.PrivacyStatusCompleted},
+			Events:  []model.UserEvent{{EventName: "click"}},
+		}, nil).Once()
+
+	resp := s.post("/admin/privacy/access", `{"user_id":"u1"}`)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var body model.UserDataExport
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(s.T(), body.Events, 1)
+}
+
+func (s *PrivacyControllerTestSuite) TestRequestAccess_InvalidJSON() {
+	resp := s.post("/admin/privacy/access", `{`)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *PrivacyControllerTestSuite) TestListRequests_Success() {
+	s.service.On("ListRequests", mock.Anything).Return([]model.PrivacyRequest{{ID: "r1"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/privacy/requests", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *PrivacyControllerTestSuite) TestGetRequest_NotFound() {
+	s.service.On("GetRequest", mock.Anything, "missing").
+		Return(model.PrivacyRequest{}, service.ErrPrivacyRequestNotFound).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/privacy/requests/missing", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// disconnectPollInterval controls how often an in-flight request checks
+// whether the client has gone away.
+const disconnectPollInterval = 250 * time.Millisecond
+
+// requestContext returns a context that is cancelled when the client
+// disconnects or the server shuts down. fasthttp does not surface client
+// disconnects on its own, so the connection is polled while the handler runs.
+// The returned stop func must be called before the handler returns.
+func requestContext(c *fiber.Ctx) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(c.UserContext())
+
+	conn := c.Context().Conn()
+	shutdown := c.Context().Done()
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-shutdown:
+				cancel()
+				return
+			case <-ticker.C:
+				if peerClosed(conn) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	// Wait for the watcher so it never touches the connection after fasthttp
+	// has handed it to the next request.
+	return ctx, func() {
+		cancel()
+		<-exited
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"event-metrics-service/internal/rules"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RulesController interface {
+	GetRuleStats(c *fiber.Ctx) error
+}
+
+// rulesController exposes ingest rule hit counters.
+type rulesController struct {
+	engine *rules.Engine
+}
+
+// NewRulesController builds a RulesController. A nil engine reports no
+// rules.
+func NewRulesController(engine *rules.Engine) RulesController {
+	return &rulesController{engine: engine}
+}
+
+// GetRuleStats returns the active rules version and how many events each
+// rule matched and dropped since start.
+func (h *rulesController) GetRuleStats(c *fiber.Ctx) error {
+	if h.engine == nil {
+		return c.JSON(fiber.Map{"version": 0, "rules": []rules.Stats{}})
+	}
+	return c.JSON(fiber.Map{"version": h.engine.Version(), "rules": h.engine.Stats()})
+}
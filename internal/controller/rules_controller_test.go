@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/rules"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRuleStats(t *testing.T) {
+	engine, err := rules.New(rules.Config{Version: 3, Rules: []rules.Rule{{
+		Name:    "drop-test",
+		Match:   rules.Match{Channels: []string{"test"}},
+		Actions: []rules.Action{{Type: rules.ActionDrop}},
+	}}})
+	require.NoError(t, err)
+	engine.Apply(model.Event{EventName: "click", Channel: "test"})
+	engine.Apply(model.Event{EventName: "click", Channel: "web"})
+
+	type response struct {
+		Version int           `json:"version"`
+		Rules   []rules.Stats `json:"rules"`
+	}
+
+	for _, tt := range []struct {
+		name   string
+		engine *rules.Engine
+		want   response
+	}{
+		{"configured", engine, response{Version: 3, Rules: []rules.Stats{{Name: "drop-test", Hits: 1, Dropped: 1}}}},
+		{"disabled", nil, response{Version: 0, Rules: []rules.Stats{}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/admin/rules", NewRulesController(tt.engine).GetRuleStats)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/admin/rules", nil), -1)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var got response
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
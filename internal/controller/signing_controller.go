@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"event-metrics-service/internal/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SigningController interface {
+	GetSigningStats(c *fiber.Ctx) error
+}
+
+// signingController exposes request signature verification counters.
+type signingController struct {
+	verifier *auth.SignatureVerifier
+}
+
+// NewSigningController builds a SigningController. A nil verifier reports
+// empty counters.
+func NewSigningController(verifier *auth.SignatureVerifier) SigningController {
+	return &signingController{verifier: verifier}
+}
+
+// GetSigningStats returns how many signed requests were verified and how
+// many were rejected per reason since start.
+func (h *signingController) GetSigningStats(c *fiber.Ctx) error {
+	if h.verifier == nil {
+		return c.JSON(auth.SignatureStats{Failures: map[string]uint64{}})
+	}
+	return c.JSON(h.verifier.Stats())
+}
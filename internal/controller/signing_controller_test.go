@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSigningStats(t *testing.T) {
+	verifier := auth.NewSignatureVerifier(map[string][]byte{"partner": []byte("0123456789abcdef")}, time.Minute, true)
+	require.Error(t, verifier.Verify("partner", "", "", nil))
+
+	for _, tt := range []struct {
+		name     string
+		verifier *auth.SignatureVerifier
+		want     auth.SignatureStats
+	}{
+		{"configured", verifier, auth.SignatureStats{Failures: map[string]uint64{auth.ReasonMissingSignature: 1}}},
+		{"disabled", nil, auth.SignatureStats{Failures: map[string]uint64{}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/admin/signing", NewSigningController(tt.verifier).GetSigningStats)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/admin/signing", nil), -1)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var stats auth.SignatureStats
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+			require.Equal(t, tt.want, stats)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"event-metrics-service/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UsageController interface {
+	GetUsage(c *fiber.Ctx) error
+}
+
+// usageController exposes the rate limit and quota counters.
+type usageController struct {
+	limits *ratelimit.Enforcer
+}
+
+// NewUsageController builds a UsageController.
+func NewUsageController(limits *ratelimit.Enforcer) UsageController {
+	return &usageController{limits: limits}
+}
+
+// GetUsage returns today's accepted events, quota rejections and throttled
+// requests per API key, client IP and tenant.
+func (h *usageController) GetUsage(c *fiber.Ctx) error {
+	return c.JSON(h.limits.Usage())
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-metrics-service/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUsage(t *testing.T) {
+	limits := ratelimit.NewEnforcer(ratelimit.Policy{DailyEvents: 10}, nil)
+
+	app := fiber.New()
+	app.Post("/events", limits.Middleware(ratelimit.Ingest), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusAccepted)
+	})
+	app.Get("/admin/usage", NewUsageController(limits).GetUsage)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/events", nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/admin/usage", nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var usage ratelimit.Usage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&usage))
+	require.Equal(t, []ratelimit.ClientUsage{
+		{Key: "ip:0.0.0.0", Events: 1, Quota: 10},
+		{Key: "tenant:default", Events: 1},
+	}, usage.Clients)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+	"event-metrics-service/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// GetUserEvents returns a page of one user's raw events, newest first.
+func (h *eventController) GetUserEvents(c *fiber.Ctx) error {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		return err
+	}
+
+	limit := 0
+	if raw := utils.Trim(c.Query("limit"), ' '); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid limit")
+		}
+	}
+
+	filter := model.UserEventsFilter{
+		TenantID:  tenant.FromContext(c),
+		UserID:    c.Params("user_id"),
+		From:      from,
+		To:        to,
+		EventName: utils.Trim(c.Query("event_name"), ' '),
+		Limit:     limit,
+	}
+
+	ctx, stop := requestContext(c)
+	defer stop()
+
+	resp, err := h.eventService.GetUserEvents(ctx, filter, utils.Trim(c.Query("cursor"), ' '))
+	if err != nil {
+		return userQueryError(err, "failed to fetch user events")
+	}
+	return c.JSON(resp)
+}
+
+// GetUserSummary returns first/last seen times and per-event counts for a user.
+func (h *eventController) GetUserSummary(c *fiber.Ctx) error {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := requestContext(c)
+	defer stop()
+
+	summary, err := h.eventService.GetUserSummary(ctx, tenant.FromContext(c), c.Params("user_id"), from, to)
+	if err != nil {
+		return userQueryError(err, "failed to fetch user summary")
+	}
+	return c.JSON(summary)
+}
+
+// parseTimeRange reads the optional from/to query parameters as unix
+// seconds, unix milliseconds or RFC 3339. timestamp_unit fixes the unit of
+// numeric values.
+func parseTimeRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	var from, to time.Time
+	unit := utils.Trim(c.Query("timestamp_unit"), ' ')
+
+	if raw := utils.Trim(c.Query("from"), ' '); raw != "" {
+		ts, err := model.ParseTimestamp(raw, unit)
+		if err != nil {
+			return time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid from: "+err.Error())
+		}
+		from = ts
+	}
+
+	if raw := utils.Trim(c.Query("to"), ' '); raw != "" {
+		ts, err := model.ParseTimestamp(raw, unit)
+		if err != nil {
+			return time.Time{}, time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid to: "+err.Error())
+		}
+		to = ts
+	}
+
+	return from, to, nil
+}
+
+func userQueryError(err error, fallback string) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return fiber.NewError(fiber.StatusBadRequest, validationErr.Message)
+	case errors.Is(err, service.ErrQueryTimeout):
+		return fiber.NewError(fiber.StatusGatewayTimeout, "query timed out; narrow the time window")
+	case errors.Is(err, service.ErrQueryLimitExceeded):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "query exceeded resource limits; narrow the time window")
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+	"event-metrics-service/internal/tenant"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ControllerTestSuite) TestGetUserEvents_PassesQuery() {
+	expected := model.UserEventsFilter{
+		TenantID:  "acme",
+		UserID:    "u1",
+		From:      time.Unix(100, 0).UTC(),
+		To:        time.Unix(200, 0).UTC(),
+		EventName: "purchase",
+		Limit:     50,
+	}
+	s.service.On("GetUserEvents", mock.Anything, expected, "abc").Return(model.UserEventsResponse{UserID: "u1"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/u1/events?from=100&to=200&event_name=purchase&limit=50&cursor=abc", nil)
+	req.Header.Set(tenant.HeaderTenantID, "acme")
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	s.service.AssertExpectations(s.T())
+}
+
+func (s *ControllerTestSuite) TestGetUserEvents_InvalidLimit() {
+	req := httptest.NewRequest(http.MethodGet, "/users/u1/events?limit=many", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *ControllerTestSuite) TestGetUserEvents_ValidationError() {
+	s.service.On("GetUserEvents", mock.Anything, mock.Anything, "bad").
+		Return(model.UserEventsResponse{}, &service.ValidationError{Message: "invalid cursor"}).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/u1/events?cursor=bad", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *ControllerTestSuite) TestGetUserSummary_Timeout() {
+	s.service.On("GetUserSummary", mock.Anything, model.DefaultTenant, "u1", time.Time{}, time.Time{}).
+		Return(model.UserSummary{}, fmt.Errorf("query user event counts: %w", service.ErrQueryTimeout)).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/u1/summary", nil)
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusGatewayTimeout, resp.StatusCode)
+}
@@ -2,35 +2,355 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"event-metrics-service/internal/model"
 )
 
-// RunMigrations ensures required tables exist. This keeps the service
-// self-contained without an external migration step.
-func RunMigrations(ctx context.Context, conn clickhouse.Conn) error {
-	err := conn.Exec(ctx, `
-CREATE TABLE IF NOT EXISTS events
+//go:embed migrations/*.up.sql
+var migrationFiles embed.FS
+
+// Migration is a single versioned schema change loaded from
+// migrations/NNNN_name.up.sql, or a code migration with Run set.
+type Migration struct {
+	Version  uint32
+	Name     string
+	SQL      string
+	Checksum string
+
+	// Run applies a code migration. Code migrations have no checksum; they
+	// must be safe to rerun after a partial failure.
+	Run func(ctx context.Context, conn clickhouse.Conn) error
+}
+
+// codeMigrations are schema changes that cannot be written as idempotent SQL
+// statements.
+var codeMigrations = []Migration{
+	{Version: 3, Name: "add_tenant_id", Run: addTenantID},
+}
+
+const createSchemaMigrationsQuery = `
+CREATE TABLE IF NOT EXISTS schema_migrations
 (
-	event_name      String,
-	channel         String,
-	campaign_id     Nullable(String),
-	user_id         String,
-	ts              DateTime64(3, 'UTC'),
-    tags            Array(String),
-    metadata        String DEFAULT '{}',
-	ingested_at     DateTime DEFAULT now()
+	version     UInt32,
+	name        String,
+	checksum    String,
+	applied_at  DateTime DEFAULT now()
+)
+ENGINE = MergeTree
+ORDER BY version
+`
+
+const (
+	selectAppliedMigrationsQuery = `SELECT version, checksum FROM schema_migrations ORDER BY version`
+	insertAppliedMigrationQuery  = `INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`
 )
-ENGINE = ReplacingMergeTree
-PARTITION BY toYYYYMMDD(ts)
-ORDER BY (event_name, ts, user_id, channel, campaign_id)
-SETTINGS
-    allow_nullable_key = 1,
-    index_granularity = 8192;
-`)
+
+// unknownTableCode is the ClickHouse UNKNOWN_TABLE error code.
+const unknownTableCode = 60
+
+// SchemaOptions holds configuration-driven schema settings that are applied
+// after the versioned migrations.
+type SchemaOptions struct {
+	Retention       RetentionPolicy
+	PromotedColumns []model.PromotedColumn
+}
+
+// RunMigrations applies pending schema migrations and then the configured
+// schema options. The server calls it on startup when AUTO_MIGRATE is enabled;
+// otherwise the `migrate` subcommand runs it as a separate step.
+func RunMigrations(ctx context.Context, conn clickhouse.Conn, opts SchemaOptions) error {
+	migrator, err := NewMigrator(conn)
 	if err != nil {
+		return err
+	}
+
+	if _, err := migrator.Up(ctx, opts, false); err != nil {
 		return fmt.Errorf("apply migrations: %w", err)
 	}
 	return nil
 }
+
+// applySchemaOptions brings retention and promoted metadata columns in line
+// with configuration. It is safe to run on every start, but must run under
+// the migration lock like the migrations themselves.
+func applySchemaOptions(ctx context.Context, conn clickhouse.Conn, opts SchemaOptions) error {
+	if err := ApplyRetention(ctx, conn, opts.Retention); err != nil {
+		return fmt.Errorf("apply retention: %w", err)
+	}
+
+	if err := EnsurePromotedColumns(ctx, conn, opts.PromotedColumns); err != nil {
+		return fmt.Errorf("apply promoted columns: %w", err)
+	}
+	return nil
+}
+
+// Migrator applies embedded migrations in version order and records them in
+// schema_migrations.
+type Migrator struct {
+	conn       clickhouse.Conn
+	migrations []Migration
+	lock       *migrationLock
+}
+
+// NewMigrator loads the embedded migrations.
+func NewMigrator(conn clickhouse.Conn) (*Migrator, error) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		conn:       conn,
+		migrations: migrations,
+		lock:       newMigrationLock(conn),
+	}, nil
+}
+
+// Up applies every pending migration followed by opts, all under the
+// migration lock, and returns the applied migrations. With dryRun set it only
+// reports what would be applied and changes nothing.
+func (m *Migrator) Up(ctx context.Context, opts SchemaOptions, dryRun bool) ([]Migration, error) {
+	if dryRun {
+		return m.Pending(ctx)
+	}
+
+	if err := m.conn.Exec(ctx, createSchemaMigrationsQuery); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	if err := m.lock.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := m.lock.Release(context.WithoutCancel(ctx)); err != nil {
+			log.Printf("[WARN] release migration lock: %v", err)
+		}
+	}()
+
+	held, stop := m.lock.Hold(ctx)
+	defer stop()
+
+	pending, err := m.applyPending(held)
+	if err == nil {
+		err = applySchemaOptions(held, m.conn, opts)
+	}
+	if err != nil {
+		if held.Err() != nil && ctx.Err() == nil {
+			// The lease was lost, not the caller's context.
+			return nil, fmt.Errorf("%w: %w", context.Cause(held), err)
+		}
+		return nil, err
+	}
+	return pending, nil
+}
+
+// applyPending applies the migrations not applied yet. It must run under the
+// migration lock.
+func (m *Migrator) applyPending(ctx context.Context) ([]Migration, error) {
+	// Re-read under the lock: another instance may have just finished.
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range pending {
+		if migration.Run != nil {
+			if err := migration.Run(ctx, m.conn); err != nil {
+				return nil, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		for _, stmt := range splitStatements(migration.SQL) {
+			if err := m.conn.Exec(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		if err := m.conn.Exec(ctx, insertAppliedMigrationQuery, migration.Version, migration.Name, migration.Checksum); err != nil {
+			return nil, fmt.Errorf("record migration %04d: %w", migration.Version, err)
+		}
+		log.Printf("[INFO] applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	return pending, nil
+}
+
+// Pending returns migrations that have not been applied yet. It fails if an
+// applied migration's file was edited afterwards.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		checksum, ok := applied[migration.Version]
+		if !ok {
+			pending = append(pending, migration)
+			continue
+		}
+		// Code migrations are not checksummed; their SQL predecessors were.
+		if migration.Run == nil && checksum != migration.Checksum {
+			return nil, fmt.Errorf("migration %04d_%s was modified after being applied", migration.Version, migration.Name)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[uint32]string, error) {
+	rows, err := m.conn.Query(ctx, selectAppliedMigrationsQuery)
+	if err != nil {
+		var exception *clickhouse.Exception
+		if errors.As(err, &exception) && exception.Code == unknownTableCode {
+			return map[uint32]string{}, nil
+		}
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[uint32]string{}
+	for rows.Next() {
+		var version uint32
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		applied[version] = checksum
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// embeddedMigrations returns the embedded SQL migrations and the code
+// migrations, sorted by version.
+func embeddedMigrations() ([]Migration, error) {
+	migrations, err := LoadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, code := range codeMigrations {
+		for _, m := range migrations {
+			if m.Version == code.Version {
+				return nil, fmt.Errorf("duplicate migration version %d: %s and code migration %s", code.Version, m.Name, code.Name)
+			}
+		}
+		migrations = append(migrations, code)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LoadMigrations reads NNNN_name.up.sql files from fsys, sorted by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+
+	seen := map[uint32]string{}
+	migrations := make([]Migration, 0, len(paths))
+	for _, p := range paths {
+		base := strings.TrimSuffix(path.Base(p), ".up.sql")
+		rawVersion, name, ok := strings.Cut(base, "_")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid migration file name: %s", p)
+		}
+
+		version, err := strconv.ParseUint(rawVersion, 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", p)
+		}
+
+		if other, dup := seen[uint32(version)]; dup {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, p)
+		}
+		seen[uint32(version)] = p
+
+		body, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", p, err)
+		}
+
+		sum := sha256.Sum256(body)
+		migrations = append(migrations, Migration{
+			Version:  uint32(version),
+			Name:     name,
+			SQL:      string(body),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// splitStatements splits a migration into individual statements because the
+// native protocol executes one statement per call. Semicolons inside quoted
+// strings and -- comments are ignored.
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+	inComment := false
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(sql)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inComment:
+			if r == '\n' {
+				inComment = false
+			}
+			continue
+		case quote != 0:
+			current.WriteRune(r)
+			if r == '\\' && i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			inComment = true
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';':
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return statements
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+const (
+	migrationLockLease = 5 * time.Minute
+	migrationLockRetry = 2 * time.Second
+)
+
+const createMigrationLockQuery = `
+CREATE TABLE IF NOT EXISTS schema_migrations_lock
+(
+	owner        String,
+	acquired_at  DateTime64(3, 'UTC'),
+	expires_at   DateTime64(3, 'UTC')
+)
+ENGINE = MergeTree
+ORDER BY acquired_at
+TTL toDateTime(expires_at) + INTERVAL 1 DAY
+`
+
+const (
+	insertMigrationLockQuery = `
+	INSERT INTO schema_migrations_lock (owner, acquired_at, expires_at)
+	SELECT ?, now64(3), now64(3) + toIntervalSecond(?)
+`
+	selectMigrationLockHolderQuery = `
+	SELECT owner FROM schema_migrations_lock
+	WHERE expires_at > now64(3)
+	ORDER BY acquired_at, owner
+	LIMIT 1
+`
+	// renewMigrationLockQuery extends a live lease. The new row keeps the
+	// original acquired_at so the holder stays the oldest row; an expired
+	// lease selects nothing and is not revived.
+	renewMigrationLockQuery = `
+	INSERT INTO schema_migrations_lock (owner, acquired_at, expires_at)
+	SELECT owner, min(acquired_at), now64(3) + toIntervalSecond(?)
+	FROM schema_migrations_lock
+	WHERE owner = ? AND expires_at > now64(3)
+	GROUP BY owner
+`
+	deleteMigrationLockQuery = `DELETE FROM schema_migrations_lock WHERE owner = ?`
+)
+
+// migrationLock serialises migrations across app instances. ClickHouse has no
+// advisory locks, so each contender inserts a lease row and the oldest
+// unexpired row wins; losers withdraw and retry. Leases expire on their own so
+// a crashed instance cannot block migrations forever.
+type migrationLock struct {
+	conn  clickhouse.Conn
+	owner string
+	lease time.Duration
+	retry time.Duration
+}
+
+func newMigrationLock(conn clickhouse.Conn) *migrationLock {
+	return &migrationLock{
+		conn:  conn,
+		owner: lockOwner(),
+		lease: migrationLockLease,
+		retry: migrationLockRetry,
+	}
+}
+
+// Acquire blocks until the lock is held, the context ends, or one full lease
+// has passed without success.
+func (l *migrationLock) Acquire(ctx context.Context) error {
+	if err := l.conn.Exec(ctx, createMigrationLockQuery); err != nil {
+		return fmt.Errorf("create schema_migrations_lock: %w", err)
+	}
+
+	deadline := time.Now().Add(l.lease)
+	for {
+		if err := l.conn.Exec(ctx, insertMigrationLockQuery, l.owner, int64(l.lease.Seconds())); err != nil {
+			return fmt.Errorf("insert migration lock: %w", err)
+		}
+
+		holder, err := l.holder(ctx)
+		if err != nil {
+			return err
+		}
+		if holder == l.owner {
+			return nil
+		}
+
+		if err := l.Release(ctx); err != nil {
+			return err
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for migration lock held by %s", holder)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// Hold renews the lease every third of its length while work runs under the
+// lock. The returned context is cancelled when the lease is lost to another
+// instance or cannot be renewed before it expires, so the work stops instead
+// of overlapping with the next holder; context.Cause reports why. Call stop
+// when the work is done.
+func (l *migrationLock) Hold(ctx context.Context) (held context.Context, stop func()) {
+	held, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		interval := l.lease / 3
+		expires := time.Now().Add(l.lease)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-held.Done():
+				return
+			case <-ticker.C:
+			}
+
+			renewedAt := time.Now()
+			err := l.renew(held)
+			switch {
+			case err == nil:
+				expires = renewedAt.Add(l.lease)
+			case errors.Is(err, errMigrationLockLost):
+				cancel(err)
+				return
+			case time.Until(expires) <= interval:
+				cancel(fmt.Errorf("migration lock lease expires before it can be renewed: %w", err))
+				return
+			default:
+				log.Printf("[WARN] renew migration lock, retrying: %v", err)
+			}
+		}
+	}()
+
+	return held, func() {
+		cancel(nil)
+		<-done
+	}
+}
+
+// errMigrationLockLost reports that another instance holds the lock.
+var errMigrationLockLost = errors.New("migration lock lost")
+
+func (l *migrationLock) renew(ctx context.Context) error {
+	if err := l.conn.Exec(ctx, renewMigrationLockQuery, int64(l.lease.Seconds()), l.owner); err != nil {
+		return fmt.Errorf("renew migration lock: %w", err)
+	}
+
+	holder, err := l.holder(ctx)
+	if err != nil {
+		return err
+	}
+	if holder != l.owner {
+		return fmt.Errorf("%w to %q", errMigrationLockLost, holder)
+	}
+	return nil
+}
+
+// holder returns the owner of the oldest live lease, or "" when there is
+// none.
+func (l *migrationLock) holder(ctx context.Context) (string, error) {
+	var holder string
+	err := l.conn.QueryRow(ctx, selectMigrationLockHolderQuery).Scan(&holder)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("read migration lock: %w", err)
+	}
+	return holder, nil
+}
+
+// Release withdraws this instance's lease rows.
+func (l *migrationLock) Release(ctx context.Context) error {
+	if err := l.conn.Exec(ctx, deleteMigrationLockQuery, l.owner); err != nil {
+		return fmt.Errorf("release migration lock: %w", err)
+	}
+	return nil
+}
+
+func lockOwner() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
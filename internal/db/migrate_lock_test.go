@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testLock(conn *mockclickhouseconnection.Connection, lease time.Duration) *migrationLock {
+	return &migrationLock{conn: conn, owner: "me", lease: lease, retry: time.Millisecond}
+}
+
+// holderRow answers the lock holder query with owner.
+func holderRow(conn *mockclickhouseconnection.Connection, owner string) {
+	row := &mockclickhouserows.Row{}
+	row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = owner
+	}).Return(nil)
+	conn.On("QueryRow", mock.Anything, selectMigrationLockHolderQuery, []any(nil)).Return(row)
+}
+
+func TestMigrationLockHold_RenewsLease(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	conn.On("Exec", mock.Anything, renewMigrationLockQuery, mock.Anything, "me").Return(nil)
+	holderRow(conn, "me")
+
+	held, stop := testLock(conn, 30*time.Millisecond).Hold(context.Background())
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, held.Err(), "a renewed lease outlives its original length")
+	stop()
+	require.Error(t, held.Err())
+	conn.AssertCalled(t, "Exec", mock.Anything, renewMigrationLockQuery, int64(0), "me")
+}
+
+func TestMigrationLockHold_CancelsWhenLost(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	conn.On("Exec", mock.Anything, renewMigrationLockQuery, mock.Anything, "me").Return(nil)
+	holderRow(conn, "other")
+
+	held, stop := testLock(conn, 30*time.Millisecond).Hold(context.Background())
+	defer stop()
+
+	<-held.Done()
+	require.ErrorIs(t, context.Cause(held), errMigrationLockLost)
+}
+
+func TestMigrationLockHold_CancelsBeforeExpiry(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	conn.On("Exec", mock.Anything, renewMigrationLockQuery, mock.Anything, "me").Return(errors.New("connection refused"))
+
+	start := time.Now()
+	held, stop := testLock(conn, 60*time.Millisecond).Hold(context.Background())
+	defer stop()
+
+	<-held.Done()
+	require.ErrorContains(t, context.Cause(held), "expires before it can be renewed")
+	require.Less(t, time.Since(start), 60*time.Millisecond, "work must stop while the lease is still valid")
+}
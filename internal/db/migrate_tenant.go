@@ -0,0 +1,265 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// tenantCopyRounds caps how often partitions that changed during the copy are
+// copied again before the swap. Rows still missing afterwards are caught up
+// from events_pre_tenant once the new table is live.
+const tenantCopyRounds = 3
+
+const (
+	countTablesQuery = `
+	SELECT count() FROM system.tables
+	WHERE database = currentDatabase() AND name = ?
+`
+	countColumnsQuery = `
+	SELECT count() FROM system.columns
+	WHERE database = currentDatabase() AND table = ? AND name = ?
+`
+	selectColumnsQuery = `
+	SELECT name FROM system.columns
+	WHERE database = currentDatabase() AND table = ?
+	ORDER BY position
+`
+
+	createTenantedEventsQuery = `
+	CREATE TABLE IF NOT EXISTS events_tenanted
+	ENGINE = ReplacingMergeTree
+	PARTITION BY toYYYYMMDD(ts)
+	ORDER BY (tenant_id, event_name, ts, user_id, channel, campaign_id)
+	SETTINGS
+		allow_nullable_key = 1,
+		index_granularity = 8192
+	AS SELECT toLowCardinality('default') AS tenant_id, * FROM events WHERE 0
+`
+	alterTenantedEventsQuery = `
+	ALTER TABLE events_tenanted
+		MODIFY COLUMN tenant_id LowCardinality(String) DEFAULT 'default',
+		MODIFY COLUMN metadata String DEFAULT '{}',
+		MODIFY COLUMN ingested_at DateTime DEFAULT now()
+`
+	addPrivacyAuditTenantQuery = `ALTER TABLE privacy_audit ADD COLUMN IF NOT EXISTS tenant_id LowCardinality(String) DEFAULT 'default' AFTER request_type`
+
+	// tenantEventKey is the sorting key of events without tenant_id. NULL
+	// campaigns compare as empty strings so NOT IN matches them.
+	tenantEventKey = "(event_name, ts, user_id, channel, ifNull(campaign_id, ''))"
+)
+
+// addTenantID moves events into a table whose sorting key starts with
+// tenant_id, assigning existing rows to the default tenant. ClickHouse cannot
+// change a sorting key prefix in place, so the table is rebuilt:
+//
+//  1. events_tenanted is created empty with the new key.
+//  2. events is copied into it partition by partition. Partitions whose row
+//     count changed during the copy are copied again.
+//  3. The tables are swapped; the old one stays as events_pre_tenant until it
+//     is dropped by hand.
+//  4. Rows written to the old table after its last copy are caught up.
+//
+// Every step checks the current state first, so a failed run can be retried,
+// and ingestion may continue while it runs.
+func addTenantID(ctx context.Context, conn clickhouse.Conn) error {
+	hasEvents, err := tableExists(ctx, conn, "events")
+	if err != nil {
+		return err
+	}
+	hasTenanted, err := tableExists(ctx, conn, "events_tenanted")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !hasEvents && hasTenanted:
+		// A previous run stopped between the two renames.
+		if err := conn.Exec(ctx, "RENAME TABLE events_tenanted TO events"); err != nil {
+			return fmt.Errorf("rename events_tenanted: %w", err)
+		}
+	case hasEvents:
+		migrated, err := columnExists(ctx, conn, "events", "tenant_id")
+		if err != nil {
+			return err
+		}
+		if !migrated {
+			if err := copyTenantedEvents(ctx, conn); err != nil {
+				return err
+			}
+			if err := conn.Exec(ctx, "RENAME TABLE events TO events_pre_tenant, events_tenanted TO events"); err != nil {
+				return fmt.Errorf("swap events tables: %w", err)
+			}
+			log.Printf("[INFO] events rebuilt with tenant_id; the old table is kept as events_pre_tenant")
+		}
+	}
+
+	hasPrevious, err := tableExists(ctx, conn, "events_pre_tenant")
+	if err != nil {
+		return err
+	}
+	if hasPrevious {
+		if err := catchUpTenantedEvents(ctx, conn); err != nil {
+			return err
+		}
+	}
+
+	if err := conn.Exec(ctx, addPrivacyAuditTenantQuery); err != nil {
+		return fmt.Errorf("add privacy_audit tenant_id: %w", err)
+	}
+	return nil
+}
+
+// copyTenantedEvents fills events_tenanted from events. Each partition is
+// dropped before it is copied, so partitions left over from a failed run are
+// replaced rather than duplicated.
+func copyTenantedEvents(ctx context.Context, conn clickhouse.Conn) error {
+	if err := conn.Exec(ctx, createTenantedEventsQuery); err != nil {
+		return fmt.Errorf("create events_tenanted: %w", err)
+	}
+	if err := conn.Exec(ctx, alterTenantedEventsQuery); err != nil {
+		return fmt.Errorf("set events_tenanted defaults: %w", err)
+	}
+
+	columns, err := tableColumns(ctx, conn, "events")
+	if err != nil {
+		return err
+	}
+	insert := fmt.Sprintf("INSERT INTO events_tenanted (tenant_id, %[1]s) SELECT 'default', %[1]s FROM events WHERE _partition_id = ?", columns)
+
+	for round := 1; round <= tenantCopyRounds; round++ {
+		source, err := partitionCounts(ctx, conn, "events")
+		if err != nil {
+			return err
+		}
+		target, err := partitionCounts(ctx, conn, "events_tenanted")
+		if err != nil {
+			return err
+		}
+
+		changed := 0
+		for partition := range target {
+			if _, ok := source[partition]; !ok {
+				if err := dropPartition(ctx, conn, "events_tenanted", partition); err != nil {
+					return err
+				}
+				changed++
+			}
+		}
+		for partition, rows := range source {
+			if target[partition] == rows {
+				continue
+			}
+			if err := dropPartition(ctx, conn, "events_tenanted", partition); err != nil {
+				return err
+			}
+			if err := conn.Exec(ctx, insert, partition); err != nil {
+				return fmt.Errorf("copy events partition %s: %w", partition, err)
+			}
+			changed++
+		}
+
+		log.Printf("[INFO] tenant_id migration: copy round %d updated %d partition(s)", round, changed)
+		if changed == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// catchUpTenantedEvents copies rows of events_pre_tenant that are missing
+// from events, such as events ingested into the old table after its last
+// copy. Rows are compared by sorting key, so rerunning it adds nothing.
+func catchUpTenantedEvents(ctx context.Context, conn clickhouse.Conn) error {
+	columns, err := tableColumns(ctx, conn, "events_pre_tenant")
+	if err != nil {
+		return err
+	}
+	partitions, err := partitionCounts(ctx, conn, "events_pre_tenant")
+	if err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf(
+		"INSERT INTO events (tenant_id, %[1]s) SELECT 'default', %[1]s FROM events_pre_tenant "+
+			"WHERE _partition_id = ? AND %[2]s NOT IN (SELECT %[2]s FROM events WHERE tenant_id = 'default' AND _partition_id = ?)",
+		columns, tenantEventKey)
+	for partition := range partitions {
+		if err := conn.Exec(ctx, insert, partition, partition); err != nil {
+			return fmt.Errorf("catch up events partition %s: %w", partition, err)
+		}
+	}
+	return nil
+}
+
+func tableExists(ctx context.Context, conn clickhouse.Conn, table string) (bool, error) {
+	var n uint64
+	if err := conn.QueryRow(ctx, countTablesQuery, table).Scan(&n); err != nil {
+		return false, fmt.Errorf("look up table %s: %w", table, err)
+	}
+	return n > 0, nil
+}
+
+func columnExists(ctx context.Context, conn clickhouse.Conn, table, column string) (bool, error) {
+	var n uint64
+	if err := conn.QueryRow(ctx, countColumnsQuery, table, column).Scan(&n); err != nil {
+		return false, fmt.Errorf("look up column %s.%s: %w", table, column, err)
+	}
+	return n > 0, nil
+}
+
+// tableColumns returns the quoted column names of table as a select list.
+func tableColumns(ctx context.Context, conn clickhouse.Conn, table string) (string, error) {
+	rows, err := conn.Query(ctx, selectColumnsQuery, table)
+	if err != nil {
+		return "", fmt.Errorf("read %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", fmt.Errorf("scan %s column: %w", table, err)
+		}
+		columns = append(columns, "`"+name+"`")
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("read %s columns: %w", table, err)
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// partitionCounts returns the row count of every partition of table.
+func partitionCounts(ctx context.Context, conn clickhouse.Conn, table string) (map[string]uint64, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT _partition_id, count() FROM %s GROUP BY _partition_id", table))
+	if err != nil {
+		return nil, fmt.Errorf("count %s partitions: %w", table, err)
+	}
+	defer rows.Close()
+
+	counts := map[string]uint64{}
+	for rows.Next() {
+		var partition string
+		var n uint64
+		if err := rows.Scan(&partition, &n); err != nil {
+			return nil, fmt.Errorf("scan %s partition: %w", table, err)
+		}
+		counts[partition] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count %s partitions: %w", table, err)
+	}
+	return counts, nil
+}
+
+func dropPartition(ctx context.Context, conn clickhouse.Conn, table, partition string) error {
+	// Partition IDs are digits (toYYYYMMDD), so they are safe to inline.
+	if err := conn.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DROP PARTITION ID '%s'", table, partition)); err != nil {
+		return fmt.Errorf("drop %s partition %s: %w", table, partition, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// countRow answers a count() lookup with n.
+func countRow(n uint64) *mockclickhouserows.Row {
+	row := &mockclickhouserows.Row{}
+	row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*uint64) = n
+	}).Return(nil).Once()
+	return row
+}
+
+func expectTable(conn *mockclickhouseconnection.Connection, table string, exists bool) {
+	n := uint64(0)
+	if exists {
+		n = 1
+	}
+	conn.On("QueryRow", mock.Anything, countTablesQuery, []any{table}).Return(countRow(n)).Once()
+}
+
+func expectColumns(conn *mockclickhouseconnection.Connection, table string, names ...string) {
+	rows := &mockclickhouserows.Rows{}
+	for _, name := range names {
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = name
+		}).Return(nil).Once()
+	}
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+	conn.On("Query", mock.Anything, selectColumnsQuery, []any{table}).Return(rows, nil).Once()
+}
+
+func expectPartitions(conn *mockclickhouseconnection.Connection, table string, counts map[string]uint64) {
+	rows := &mockclickhouserows.Rows{}
+	for partition, n := range counts {
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = partition
+			*args.Get(1).(*uint64) = n
+		}).Return(nil).Once()
+	}
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+	conn.On("Query", mock.Anything, "SELECT _partition_id, count() FROM "+table+" GROUP BY _partition_id", []any(nil)).Return(rows, nil).Once()
+}
+
+func TestAddTenantID_CopiesChangedPartitionsAndCatchesUp(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	expectTable(conn, "events", true)
+	expectTable(conn, "events_tenanted", true)
+	conn.On("QueryRow", mock.Anything, countColumnsQuery, []any{"events", "tenant_id"}).Return(countRow(0)).Once()
+
+	conn.On("Exec", mock.Anything, createTenantedEventsQuery).Return(nil).Once()
+	conn.On("Exec", mock.Anything, alterTenantedEventsQuery).Return(nil).Once()
+	expectColumns(conn, "events", "event_name", "ts")
+
+	// A failed run left a stale copy of 20250101 and a partition that no
+	// longer exists; 20250102 is already complete.
+	expectPartitions(conn, "events", map[string]uint64{"20250101": 5, "20250102": 3})
+	expectPartitions(conn, "events_tenanted", map[string]uint64{"20250101": 2, "20250102": 3, "20241231": 1})
+	conn.On("Exec", mock.Anything, "ALTER TABLE events_tenanted DROP PARTITION ID '20241231'").Return(nil).Once()
+	conn.On("Exec", mock.Anything, "ALTER TABLE events_tenanted DROP PARTITION ID '20250101'").Return(nil).Once()
+	conn.On("Exec", mock.Anything,
+		"INSERT INTO events_tenanted (tenant_id, `event_name`, `ts`) SELECT 'default', `event_name`, `ts` FROM events WHERE _partition_id = ?",
+		"20250101").Return(nil).Once()
+
+	// The second round finds nothing left to copy.
+	expectPartitions(conn, "events", map[string]uint64{"20250101": 5, "20250102": 3})
+	expectPartitions(conn, "events_tenanted", map[string]uint64{"20250101": 5, "20250102": 3})
+
+	conn.On("Exec", mock.Anything, "RENAME TABLE events TO events_pre_tenant, events_tenanted TO events").Return(nil).Once()
+
+	// Rows that reached the old table after its copy are caught up.
+	expectTable(conn, "events_pre_tenant", true)
+	expectColumns(conn, "events_pre_tenant", "event_name", "ts")
+	expectPartitions(conn, "events_pre_tenant", map[string]uint64{"20250102": 4})
+	conn.On("Exec", mock.Anything,
+		"INSERT INTO events (tenant_id, `event_name`, `ts`) SELECT 'default', `event_name`, `ts` FROM events_pre_tenant "+
+			"WHERE _partition_id = ? AND "+tenantEventKey+" NOT IN (SELECT "+tenantEventKey+" FROM events WHERE tenant_id = 'default' AND _partition_id = ?)",
+		"20250102", "20250102").Return(nil).Once()
+
+	conn.On("Exec", mock.Anything, addPrivacyAuditTenantQuery).Return(nil).Once()
+
+	require.NoError(t, addTenantID(context.Background(), conn))
+	conn.AssertExpectations(t)
+}
+
+func TestAddTenantID_ResumesAfterPartialRename(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	expectTable(conn, "events", false)
+	expectTable(conn, "events_tenanted", true)
+	conn.On("Exec", mock.Anything, "RENAME TABLE events_tenanted TO events").Return(nil).Once()
+
+	expectTable(conn, "events_pre_tenant", true)
+	expectColumns(conn, "events_pre_tenant", "event_name")
+	expectPartitions(conn, "events_pre_tenant", map[string]uint64{})
+	conn.On("Exec", mock.Anything, addPrivacyAuditTenantQuery).Return(nil).Once()
+
+	require.NoError(t, addTenantID(context.Background(), conn))
+	conn.AssertExpectations(t)
+}
+
+func TestAddTenantID_AlreadySwapped(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	expectTable(conn, "events", true)
+	expectTable(conn, "events_tenanted", false)
+	conn.On("QueryRow", mock.Anything, countColumnsQuery, []any{"events", "tenant_id"}).Return(countRow(1)).Once()
+	expectTable(conn, "events_pre_tenant", false)
+	conn.On("Exec", mock.Anything, addPrivacyAuditTenantQuery).Return(nil).Once()
+
+	require.NoError(t, addTenantID(context.Background(), conn))
+	conn.AssertExpectations(t)
+	conn.AssertNotCalled(t, "Exec", mock.Anything, createTenantedEventsQuery)
+}
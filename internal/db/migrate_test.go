@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		require.Equal(t, uint32(i+1), m.Version, "migration versions must be contiguous")
+		if m.Run == nil {
+			require.NotEmpty(t, splitStatements(m.SQL))
+		}
+	}
+}
+
+func TestLoadMigrations_SortsAndValidates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_second.up.sql": {Data: []byte("SELECT 2")},
+		"migrations/0001_first.up.sql":  {Data: []byte("SELECT 1")},
+	}
+	migrations, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	require.Equal(t, "first", migrations[0].Name)
+	require.Equal(t, "second", migrations[1].Name)
+	require.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+
+	_, err = LoadMigrations(fstest.MapFS{
+		"migrations/0001_a.up.sql": {Data: []byte("SELECT 1")},
+		"migrations/1_b.up.sql":    {Data: []byte("SELECT 1")},
+	})
+	require.ErrorContains(t, err, "duplicate migration version")
+
+	_, err = LoadMigrations(fstest.MapFS{"migrations/first.up.sql": {Data: []byte("SELECT 1")}})
+	require.ErrorContains(t, err, "invalid migration")
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := `
+-- create things; really
+CREATE TABLE a (s String DEFAULT ';');
+ALTER TABLE a ADD COLUMN b String DEFAULT 'it\'s;';
+
+`
+	require.Equal(t, []string{
+		"CREATE TABLE a (s String DEFAULT ';')",
+		`ALTER TABLE a ADD COLUMN b String DEFAULT 'it\'s;'`,
+	}, splitStatements(sql))
+}
+
+func TestMigratorDryRun_UnknownTableMeansAllPending(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	conn.On("Query", mock.Anything, selectAppliedMigrationsQuery, []any(nil)).
+		Return(&mockclickhouserows.Rows{}, &clickhouse.Exception{Code: unknownTableCode}).Once()
+
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+
+	pending, err := migrator.Up(context.Background(), SchemaOptions{}, true)
+	require.NoError(t, err)
+	require.Equal(t, versions(migrator.migrations), versions(pending))
+	conn.AssertExpectations(t)
+}
+
+// versions lists migration versions; code migrations hold funcs, which do
+// not compare equal.
+func versions(migrations []Migration) []uint32 {
+	out := make([]uint32, len(migrations))
+	for i, m := range migrations {
+		out[i] = m.Version
+	}
+	return out
+}
+
+func TestMigratorPending_DetectsModifiedMigration(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	rows := &mockclickhouserows.Rows{}
+	conn.On("Query", mock.Anything, selectAppliedMigrationsQuery, []any(nil)).Return(rows, nil).Once()
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*uint32) = 1
+		*args.Get(1).(*string) = "stale-checksum"
+	}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+
+	_, err = migrator.Pending(context.Background())
+	require.ErrorContains(t, err, "was modified after being applied")
+}
+
+func TestMigratorUp_AppliesSchemaOptionsUnderLock(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+	migrator.lock.owner = "me"
+
+	conn.On("Exec", mock.Anything, createSchemaMigrationsQuery).Return(nil).Once()
+	conn.On("Exec", mock.Anything, createMigrationLockQuery).Return(nil).Once()
+	conn.On("Exec", mock.Anything, insertMigrationLockQuery, "me", int64(300)).Return(nil).Once()
+	holderRow(conn, "me")
+
+	// Every migration is already applied.
+	rows := &mockclickhouserows.Rows{}
+	for _, m := range migrator.migrations {
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*uint32) = m.Version
+			*args.Get(1).(*string) = m.Checksum
+		}).Return(nil).Once()
+	}
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+	conn.On("Query", mock.Anything, selectAppliedMigrationsQuery, []any(nil)).Return(rows, nil).Once()
+
+	table := &mockclickhouserows.Row{}
+	table.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "ReplacingMergeTree PARTITION BY toYYYYMMDD(ts) ORDER BY (tenant_id, event_name, ts)"
+	}).Return(nil).Once()
+	conn.On("QueryRow", mock.Anything, selectEventsTableQuery, []any(nil)).Return(table).Once()
+
+	var calls []string
+	conn.On("Exec", mock.Anything, "ALTER TABLE events MODIFY TTL toDateTime(ts) + toIntervalDay(30)").
+		Run(func(mock.Arguments) { calls = append(calls, "ttl") }).Return(nil).Once()
+	conn.On("Exec", mock.Anything, mock.MatchedBy(func(q string) bool { return strings.HasPrefix(q, "ALTER TABLE events MODIFY COMMENT") })).
+		Run(func(mock.Arguments) { calls = append(calls, "comment") }).Return(nil).Once()
+	conn.On("Exec", mock.Anything, deleteMigrationLockQuery, "me").
+		Run(func(mock.Arguments) { calls = append(calls, "release") }).Return(nil).Once()
+
+	applied, err := migrator.Up(context.Background(), SchemaOptions{Retention: RetentionPolicy{RetentionDays: 30}}, false)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	require.Equal(t, []string{"ttl", "comment", "release"}, calls, "retention must be applied before the lock is released")
+	conn.AssertExpectations(t)
+}
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS events
+(
+	event_name      String,
+	channel         String,
+	campaign_id     Nullable(String),
+	user_id         String,
+	ts              DateTime64(3, 'UTC'),
+    tags            Array(String),
+    metadata        String DEFAULT '{}',
+	ingested_at     DateTime DEFAULT now()
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMMDD(ts)
+ORDER BY (event_name, ts, user_id, channel, campaign_id)
+SETTINGS
+    allow_nullable_key = 1,
+    index_granularity = 8192;
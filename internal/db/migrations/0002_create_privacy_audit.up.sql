@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS privacy_audit
+(
+	request_id      String,
+	request_type    LowCardinality(String),
+	user_id         String,
+	status          LowCardinality(String),
+	actor           String,
+	detail          String,
+	at              DateTime64(3, 'UTC')
+)
+ENGINE = MergeTree
+ORDER BY (request_id, at);
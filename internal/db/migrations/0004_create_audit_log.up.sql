@@ -0,0 +1,18 @@
+-- Access audit log: one row per metrics query or admin request. Rows are
+-- kept until dropped by hand; add a TTL here if they must expire.
+CREATE TABLE IF NOT EXISTS audit_log
+(
+	at              DateTime64(3, 'UTC'),
+	principal       String,
+	tenant_id       LowCardinality(String),
+	action          LowCardinality(String),
+	path            String,
+	client_ip       String,
+	filter          String,
+	duration_ms     UInt64,
+	rows_read       UInt64,
+	status          UInt16
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(at)
+ORDER BY (tenant_id, at);
@@ -0,0 +1,22 @@
+-- received_at is when the API accepted an event; ts stays the client-reported
+-- event time. Rows written before this column existed fall back to their
+-- insert time.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS received_at DateTime64(3, 'UTC') DEFAULT ingested_at AFTER metadata;
+
+-- Events older than PAST_TOLERANCE when LATE_EVENT_POLICY=route. Partitioned
+-- by arrival month so late events never create partitions for old days.
+CREATE TABLE IF NOT EXISTS late_events
+(
+	tenant_id       LowCardinality(String) DEFAULT 'default',
+	event_name      String,
+	channel         String,
+	campaign_id     Nullable(String),
+	user_id         String,
+	ts              DateTime64(3, 'UTC'),
+	tags            Array(String),
+	metadata        String DEFAULT '{}',
+	received_at     DateTime64(3, 'UTC')
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(received_at)
+ORDER BY (tenant_id, received_at, event_name);
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"event-metrics-service/internal/config"
+)
+
+//go:embed postgres/schema.sql
+var postgresSchema string
+
+// NewPostgresPool opens a PostgreSQL connection pool and waits for the server
+// to accept connections.
+func NewPostgresPool(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.PostgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("parse postgres dsn: %w", err)
+	}
+	poolCfg.MaxConns = int32(cfg.DBMaxConns)
+	poolCfg.MinConns = int32(cfg.DBMinConns)
+	poolCfg.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolCfg.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+
+	if err := waitForPostgres(ctx, pool, cfg.HealthPingRetries, cfg.HealthPingDelay); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// EnsurePostgresSchema creates the events table and its indexes. Every
+// statement is idempotent, so it is safe to run on each start.
+func EnsurePostgresSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	for _, stmt := range splitStatements(postgresSchema) {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("apply postgres schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func waitForPostgres(ctx context.Context, pool *pgxpool.Pool, attempts int, delay time.Duration) error {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	if delay <= 0 {
+		delay = 1500 * time.Millisecond
+	}
+	for i := 1; ; i++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if i == attempts {
+			return fmt.Errorf("ping postgres: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
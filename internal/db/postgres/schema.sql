@@ -0,0 +1,77 @@
+CREATE TABLE IF NOT EXISTS events
+(
+	event_name      TEXT        NOT NULL,
+	channel         TEXT        NOT NULL,
+	campaign_id     TEXT,
+	user_id         TEXT        NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	tags            TEXT[]      NOT NULL DEFAULT '{}',
+	metadata        JSONB       NOT NULL DEFAULT '{}',
+	ingested_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+	-- Hash of the ClickHouse sorting key, so duplicates collapse the same way
+	-- ReplacingMergeTree collapses them.
+	idempotency_key TEXT        NOT NULL,
+	CONSTRAINT events_idempotency_key_key UNIQUE (idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS events_event_name_ts_idx ON events (event_name, ts);
+
+-- Append-only trail of GDPR erasure and access requests; one row per status
+-- change.
+CREATE TABLE IF NOT EXISTS privacy_audit
+(
+	request_id      TEXT        NOT NULL,
+	request_type    TEXT        NOT NULL,
+	user_id         TEXT        NOT NULL,
+	status          TEXT        NOT NULL,
+	actor           TEXT        NOT NULL DEFAULT '',
+	detail          TEXT        NOT NULL DEFAULT '',
+	at              TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS privacy_audit_request_id_at_idx ON privacy_audit (request_id, at);
+CREATE INDEX IF NOT EXISTS events_user_id_idx ON events (user_id);
+
+-- Multi-tenancy: rows written before tenants existed belong to the default
+-- tenant. The idempotency key only covers the tenant for non-default tenants,
+-- so existing keys stay valid.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS events_tenant_id_event_name_ts_idx ON events (tenant_id, event_name, ts);
+ALTER TABLE privacy_audit ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+-- Access audit log: one row per metrics query or admin request.
+CREATE TABLE IF NOT EXISTS audit_log
+(
+	at              TIMESTAMPTZ NOT NULL,
+	principal       TEXT        NOT NULL DEFAULT '',
+	tenant_id       TEXT        NOT NULL DEFAULT '',
+	action          TEXT        NOT NULL,
+	path            TEXT        NOT NULL,
+	client_ip       TEXT        NOT NULL DEFAULT '',
+	filter          TEXT        NOT NULL DEFAULT '',
+	duration_ms     BIGINT      NOT NULL,
+	rows_read       BIGINT      NOT NULL DEFAULT 0,
+	status          INTEGER     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_at_idx ON audit_log (at);
+
+-- received_at is when the API accepted an event. Rows written before the
+-- column existed have none and are read with their ingested_at instead.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS received_at TIMESTAMPTZ;
+
+-- Events older than PAST_TOLERANCE when LATE_EVENT_POLICY=route.
+CREATE TABLE IF NOT EXISTS late_events
+(
+	tenant_id       TEXT        NOT NULL DEFAULT 'default',
+	event_name      TEXT        NOT NULL,
+	channel         TEXT        NOT NULL,
+	campaign_id     TEXT,
+	user_id         TEXT        NOT NULL,
+	ts              TIMESTAMPTZ NOT NULL,
+	tags            TEXT[]      NOT NULL DEFAULT '{}',
+	metadata        JSONB       NOT NULL DEFAULT '{}',
+	received_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS late_events_tenant_id_user_id_idx ON late_events (tenant_id, user_id);
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"event-metrics-service/internal/model"
+)
+
+// promotedColumnTypes maps supported declared types to their nullable column
+// definition. Keys missing from an event are stored as NULL.
+var promotedColumnTypes = map[string]string{
+	"String":                 "Nullable(String)",
+	"LowCardinality(String)": "LowCardinality(Nullable(String))",
+	"Float32":                "Nullable(Float32)",
+	"Float64":                "Nullable(Float64)",
+	"Int32":                  "Nullable(Int32)",
+	"Int64":                  "Nullable(Int64)",
+	"UInt32":                 "Nullable(UInt32)",
+	"UInt64":                 "Nullable(UInt64)",
+	"Bool":                   "Nullable(Bool)",
+}
+
+// ParsePromotedColumns parses a comma-separated "key:Type" list such as
+// "price:Float64,currency:LowCardinality(String)".
+func ParsePromotedColumns(raw string) ([]model.PromotedColumn, error) {
+	var columns []model.PromotedColumn
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, typ, ok := strings.Cut(part, ":")
+		key, typ = strings.TrimSpace(key), strings.TrimSpace(typ)
+		if !ok || key == "" || typ == "" {
+			return nil, fmt.Errorf("invalid promoted metadata entry %q, expected key:Type", part)
+		}
+		if !model.MetadataKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid promoted metadata key %q", key)
+		}
+		if _, ok := promotedColumnTypes[typ]; !ok {
+			return nil, fmt.Errorf("unsupported type %q for promoted metadata key %q", typ, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate promoted metadata key %q", key)
+		}
+		seen[key] = true
+
+		columns = append(columns, model.PromotedColumn{Key: key, Type: typ})
+	}
+	return columns, nil
+}
+
+const selectPromotedColumnsQuery = `
+	SELECT name, default_expression FROM system.columns
+	WHERE database = currentDatabase() AND table = 'events' AND startsWith(name, 'meta_')
+`
+
+// EnsurePromotedColumns adds a typed column to events for every promoted key.
+// The column defaults to the value extracted from the metadata JSON and is
+// materialized once, so rows written before the key was promoted are not
+// NULL in filters and group-bys. Columns that already have a default are left
+// untouched; removing a key from configuration does not drop its column.
+func EnsurePromotedColumns(ctx context.Context, conn clickhouse.Conn, columns []model.PromotedColumn) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	defaults, err := promotedColumnDefaults(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, col := range columns {
+		columnType, ok := promotedColumnTypes[col.Type]
+		if !ok {
+			return fmt.Errorf("unsupported type %q for promoted metadata key %q", col.Type, col.Key)
+		}
+
+		var query string
+		def, exists := defaults[col.Column()]
+		switch {
+		case !exists:
+			query = fmt.Sprintf("ALTER TABLE events ADD COLUMN IF NOT EXISTS %s %s DEFAULT %s",
+				col.Column(), columnType, promotedDefault(col, columnType))
+		case def == "":
+			// Promoted before columns had a default: rows older than the
+			// column hold NULL.
+			query = fmt.Sprintf("ALTER TABLE events MODIFY COLUMN %s DEFAULT %s", col.Column(), promotedDefault(col, columnType))
+		default:
+			continue
+		}
+
+		if err := conn.Exec(ctx, query); err != nil {
+			return fmt.Errorf("add promoted column %s: %w", col.Column(), err)
+		}
+		if err := conn.Exec(ctx, fmt.Sprintf("ALTER TABLE events MATERIALIZE COLUMN %s", col.Column())); err != nil {
+			return fmt.Errorf("materialize promoted column %s: %w", col.Column(), err)
+		}
+	}
+	return nil
+}
+
+// promotedColumnDefaults returns the default expression of every meta_
+// column of events, keyed by column name.
+func promotedColumnDefaults(ctx context.Context, conn clickhouse.Conn) (map[string]string, error) {
+	rows, err := conn.Query(ctx, selectPromotedColumnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("read promoted columns: %w", err)
+	}
+	defer rows.Close()
+
+	defaults := map[string]string{}
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			return nil, fmt.Errorf("scan promoted column: %w", err)
+		}
+		defaults[name] = def
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read promoted columns: %w", err)
+	}
+	return defaults, nil
+}
+
+// promotedDefault extracts col from the metadata JSON the way ingest does:
+// missing keys and JSON null are NULL, string columns keep non-string values
+// as raw JSON, and other types are NULL when the value does not convert. Keys
+// match model.MetadataKeyPattern, so they are safe to quote inline.
+func promotedDefault(col model.PromotedColumn, columnType string) string {
+	key := "'" + col.Key + "'"
+	switch col.Type {
+	case "String", "LowCardinality(String)":
+		return fmt.Sprintf("multiIf(JSONType(metadata, %[1]s) = 'Null', NULL, "+
+			"JSONType(metadata, %[1]s) = 'String', JSONExtractString(metadata, %[1]s), JSONExtractRaw(metadata, %[1]s))", key)
+	default:
+		return fmt.Sprintf("JSONExtract(metadata, %s, '%s')", key, columnType)
+	}
+}
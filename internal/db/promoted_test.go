@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePromotedColumns(t *testing.T) {
+	columns, err := ParsePromotedColumns(" price:Float64, currency:LowCardinality(String) ,")
+	require.NoError(t, err)
+	require.Equal(t, []model.PromotedColumn{
+		{Key: "price", Type: "Float64"},
+		{Key: "currency", Type: "LowCardinality(String)"},
+	}, columns)
+
+	columns, err = ParsePromotedColumns("")
+	require.NoError(t, err)
+	require.Empty(t, columns)
+
+	for _, raw := range []string{"price", "Price:Float64", "price:Decimal", "price:Float64,price:Int64", "a-b:String"} {
+		_, err := ParsePromotedColumns(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func promotedColumnRows(columns map[string]string) *mockclickhouserows.Rows {
+	rows := &mockclickhouserows.Rows{}
+	for name, def := range columns {
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = name
+			*args.Get(1).(*string) = def
+		}).Return(nil).Once()
+	}
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+	return rows
+}
+
+func TestEnsurePromotedColumns(t *testing.T) {
+	priceDefault := "JSONExtract(metadata, 'price', 'Nullable(Float64)')"
+	currencyDefault := "multiIf(JSONType(metadata, 'currency') = 'Null', NULL, JSONType(metadata, 'currency') = 'String', " +
+		"JSONExtractString(metadata, 'currency'), JSONExtractRaw(metadata, 'currency'))"
+
+	conn := &mockclickhouseconnection.Connection{}
+	// meta_plan already has its default, meta_currency was promoted without one.
+	conn.On("Query", mock.Anything, selectPromotedColumnsQuery, []any(nil)).
+		Return(promotedColumnRows(map[string]string{"meta_plan": "JSONExtractString(metadata, 'plan')", "meta_currency": ""}), nil).Once()
+
+	// Rows written before promotion get the value from their JSON, both
+	// through the default and once materialized.
+	conn.On("Exec", mock.Anything, "ALTER TABLE events ADD COLUMN IF NOT EXISTS meta_price Nullable(Float64) DEFAULT "+priceDefault).Return(nil).Once()
+	conn.On("Exec", mock.Anything, "ALTER TABLE events MATERIALIZE COLUMN meta_price").Return(nil).Once()
+	conn.On("Exec", mock.Anything, "ALTER TABLE events MODIFY COLUMN meta_currency DEFAULT "+currencyDefault).Return(nil).Once()
+	conn.On("Exec", mock.Anything, "ALTER TABLE events MATERIALIZE COLUMN meta_currency").Return(nil).Once()
+
+	err := EnsurePromotedColumns(context.Background(), conn, []model.PromotedColumn{
+		{Key: "price", Type: "Float64"},
+		{Key: "currency", Type: "LowCardinality(String)"},
+		{Key: "plan", Type: "String"},
+	})
+	require.NoError(t, err)
+	conn.AssertExpectations(t)
+}
+
+func TestEnsurePromotedColumns_NoneConfigured(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	require.NoError(t, EnsurePromotedColumns(context.Background(), conn, nil))
+	conn.AssertNotCalled(t, "Query", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"event-metrics-service/internal/model"
+)
+
+// RetentionPolicy controls how long raw events are kept and when they move
+// to a cold volume. Zero days disables the corresponding rule.
+type RetentionPolicy struct {
+	RetentionDays int
+	ColdVolume    string
+	ColdAfterDays int
+	StoragePolicy string
+
+	// TenantRetentionDays overrides RetentionDays for individual tenants.
+	TenantRetentionDays map[string]int
+}
+
+// ttlExpression renders the policy as a TTL clause, in the form ClickHouse
+// usually prints it in system.tables.
+func (p RetentionPolicy) ttlExpression() string {
+	var rules []string
+	if p.ColdVolume != "" && p.ColdAfterDays > 0 {
+		rules = append(rules, fmt.Sprintf("toDateTime(ts) + toIntervalDay(%d) TO VOLUME '%s'", p.ColdAfterDays, p.ColdVolume))
+	}
+
+	tenants := make([]string, 0, len(p.TenantRetentionDays))
+	for tenantID := range p.TenantRetentionDays {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+
+	quoted := make([]string, len(tenants))
+	for i, tenantID := range tenants {
+		quoted[i] = "'" + tenantID + "'"
+		rules = append(rules, fmt.Sprintf("toDateTime(ts) + toIntervalDay(%d) WHERE tenant_id = %s", p.TenantRetentionDays[tenantID], quoted[i]))
+	}
+
+	if p.RetentionDays > 0 {
+		rule := fmt.Sprintf("toDateTime(ts) + toIntervalDay(%d)", p.RetentionDays)
+		if len(quoted) > 0 {
+			rule += " WHERE tenant_id NOT IN (" + strings.Join(quoted, ", ") + ")"
+		}
+		rules = append(rules, rule)
+	}
+	return strings.Join(rules, ", ")
+}
+
+// Validate rejects policies ClickHouse would accept but that make no sense,
+// such as moving data after it has already been deleted.
+func (p RetentionPolicy) Validate() error {
+	if p.RetentionDays < 0 || p.ColdAfterDays < 0 {
+		return fmt.Errorf("retention days must not be negative")
+	}
+	if p.ColdAfterDays > 0 && p.ColdVolume == "" {
+		return fmt.Errorf("cold volume is required when cold-after days is set")
+	}
+	if strings.ContainsAny(p.ColdVolume, "'\\") {
+		return fmt.Errorf("invalid cold volume name: %s", p.ColdVolume)
+	}
+	if strings.ContainsAny(p.StoragePolicy, "'\\") {
+		return fmt.Errorf("invalid storage policy name: %s", p.StoragePolicy)
+	}
+	if p.RetentionDays > 0 && p.ColdAfterDays >= p.RetentionDays {
+		return fmt.Errorf("cold-after days must be less than retention days")
+	}
+	for tenantID, days := range p.TenantRetentionDays {
+		if !model.TenantIDPattern.MatchString(tenantID) {
+			return fmt.Errorf("invalid tenant id: %s", tenantID)
+		}
+		if days <= 0 {
+			return fmt.Errorf("tenant %s: retention days must be positive", tenantID)
+		}
+		if p.ColdAfterDays > 0 && p.ColdAfterDays >= days {
+			return fmt.Errorf("tenant %s: cold-after days must be less than retention days", tenantID)
+		}
+	}
+	return nil
+}
+
+// selectEventsTableQuery reads the events table definition together with its
+// comment, which records the applied TTL (see ttlStamp).
+const selectEventsTableQuery = "SELECT engine_full, comment FROM system.tables WHERE database = currentDatabase() AND name = 'events'"
+
+// ApplyRetention brings the events table storage policy and TTL in line with
+// the policy. It is a no-op when the table already matches, so restarts do not
+// trigger needless TTL materialisation.
+//
+// ClickHouse may print a TTL differently from how it was written, so the
+// applied TTL is recorded as a hash in the table comment and compared by that.
+// Tables without the hash are compared by their printed TTL once.
+func ApplyRetention(ctx context.Context, conn clickhouse.Conn, policy RetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	var engineFull, comment string
+	row := conn.QueryRow(ctx, selectEventsTableQuery)
+	if err := row.Scan(&engineFull, &comment); err != nil {
+		return fmt.Errorf("read events table definition: %w", err)
+	}
+
+	if policy.StoragePolicy != "" && !strings.Contains(engineFull, fmt.Sprintf("storage_policy = '%s'", policy.StoragePolicy)) {
+		query := fmt.Sprintf("ALTER TABLE events MODIFY SETTING storage_policy = '%s'", policy.StoragePolicy)
+		if err := conn.Exec(ctx, query); err != nil {
+			return fmt.Errorf("modify events storage policy: %w", err)
+		}
+	}
+
+	desired := policy.ttlExpression()
+	stamp := ttlStamp(desired)
+	if comment == stamp {
+		return nil
+	}
+
+	if currentTTL(engineFull) != desired {
+		if err := modifyTTL(ctx, conn, desired); err != nil {
+			return err
+		}
+	}
+
+	if err := conn.Exec(ctx, fmt.Sprintf("ALTER TABLE events MODIFY COMMENT '%s'", stamp)); err != nil {
+		return fmt.Errorf("record events ttl: %w", err)
+	}
+	return nil
+}
+
+func modifyTTL(ctx context.Context, conn clickhouse.Conn, desired string) error {
+	if desired == "" {
+		if err := conn.Exec(ctx, "ALTER TABLE events REMOVE TTL"); err != nil {
+			return fmt.Errorf("remove events ttl: %w", err)
+		}
+		return nil
+	}
+
+	if err := conn.Exec(ctx, "ALTER TABLE events MODIFY TTL "+desired); err != nil {
+		return fmt.Errorf("modify events ttl: %w", err)
+	}
+	return nil
+}
+
+// ttlStamp identifies a TTL clause in the events table comment.
+func ttlStamp(ttl string) string {
+	sum := sha256.Sum256([]byte(ttl))
+	return "ttl:" + hex.EncodeToString(sum[:])
+}
+
+// currentTTL extracts the TTL clause from a system.tables engine_full value.
+func currentTTL(engineFull string) string {
+	start := strings.Index(engineFull, " TTL ")
+	if start < 0 {
+		return ""
+	}
+	ttl := engineFull[start+len(" TTL "):]
+	if end := strings.Index(ttl, " SETTINGS "); end >= 0 {
+		ttl = ttl[:end]
+	}
+	return strings.TrimSpace(ttl)
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPolicy_TTLExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetentionPolicy
+		expected string
+	}{
+		{name: "disabled", policy: RetentionPolicy{}, expected: ""},
+		{name: "delete only", policy: RetentionPolicy{RetentionDays: 90}, expected: "toDateTime(ts) + toIntervalDay(90)"},
+		{
+			name:     "move and delete",
+			policy:   RetentionPolicy{RetentionDays: 90, ColdVolume: "cold", ColdAfterDays: 30},
+			expected: "toDateTime(ts) + toIntervalDay(30) TO VOLUME 'cold', toDateTime(ts) + toIntervalDay(90)",
+		},
+		{
+			name:   "tenant overrides",
+			policy: RetentionPolicy{RetentionDays: 90, TenantRetentionDays: map[string]int{"beta": 7, "acme": 365}},
+			expected: "toDateTime(ts) + toIntervalDay(365) WHERE tenant_id = 'acme', " +
+				"toDateTime(ts) + toIntervalDay(7) WHERE tenant_id = 'beta', " +
+				"toDateTime(ts) + toIntervalDay(90) WHERE tenant_id NOT IN ('acme', 'beta')",
+		},
+		{
+			name:     "tenant override without global retention",
+			policy:   RetentionPolicy{TenantRetentionDays: map[string]int{"acme": 30}},
+			expected: "toDateTime(ts) + toIntervalDay(30) WHERE tenant_id = 'acme'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.policy.ttlExpression())
+		})
+	}
+}
+
+func TestRetentionPolicy_Validate(t *testing.T) {
+	require.NoError(t, RetentionPolicy{RetentionDays: 30}.Validate())
+	require.Error(t, RetentionPolicy{ColdAfterDays: 10}.Validate())
+	require.Error(t, RetentionPolicy{RetentionDays: 10, ColdVolume: "cold", ColdAfterDays: 10}.Validate())
+	require.Error(t, RetentionPolicy{ColdVolume: "x'y", ColdAfterDays: 1}.Validate())
+	require.Error(t, RetentionPolicy{StoragePolicy: "x'y"}.Validate())
+	require.Error(t, RetentionPolicy{TenantRetentionDays: map[string]int{"x'y": 1}}.Validate())
+	require.Error(t, RetentionPolicy{TenantRetentionDays: map[string]int{"acme": 0}}.Validate())
+	require.Error(t, RetentionPolicy{ColdVolume: "cold", ColdAfterDays: 30, TenantRetentionDays: map[string]int{"acme": 7}}.Validate())
+}
+
+func TestCurrentTTL(t *testing.T) {
+	engineFull := "ReplacingMergeTree PARTITION BY toYYYYMMDD(ts) ORDER BY (event_name, ts) " +
+		"TTL toDateTime(ts) + toIntervalDay(90) SETTINGS allow_nullable_key = 1, index_granularity = 8192"
+	require.Equal(t, "toDateTime(ts) + toIntervalDay(90)", currentTTL(engineFull))
+
+	require.Equal(t, "", currentTTL("ReplacingMergeTree ORDER BY ts SETTINGS index_granularity = 8192"))
+}
+
+func TestApplyRetention(t *testing.T) {
+	ninetyDays := RetentionPolicy{RetentionDays: 90}
+	ninetyDaysTTL := "toDateTime(ts) + toIntervalDay(90)"
+
+	tests := []struct {
+		name       string
+		policy     RetentionPolicy
+		engineFull string
+		comment    string
+		execs      []string
+	}{
+		{
+			// ClickHouse printed the TTL differently; the stamp still matches.
+			name:       "unchanged policy, different formatting",
+			policy:     ninetyDays,
+			engineFull: "ReplacingMergeTree ORDER BY ts TTL toDateTime(ts) + toIntervalDay(90)  SETTINGS index_granularity = 8192",
+			comment:    ttlStamp(ninetyDaysTTL),
+		},
+		{
+			name:       "table from before the stamp",
+			policy:     ninetyDays,
+			engineFull: "ReplacingMergeTree ORDER BY ts TTL " + ninetyDaysTTL + " SETTINGS index_granularity = 8192",
+			execs:      []string{"ALTER TABLE events MODIFY COMMENT '" + ttlStamp(ninetyDaysTTL) + "'"},
+		},
+		{
+			name:       "changed policy",
+			policy:     RetentionPolicy{RetentionDays: 30},
+			engineFull: "ReplacingMergeTree ORDER BY ts TTL " + ninetyDaysTTL + " SETTINGS index_granularity = 8192",
+			comment:    ttlStamp(ninetyDaysTTL),
+			execs: []string{
+				"ALTER TABLE events MODIFY TTL toDateTime(ts) + toIntervalDay(30)",
+				"ALTER TABLE events MODIFY COMMENT '" + ttlStamp("toDateTime(ts) + toIntervalDay(30)") + "'",
+			},
+		},
+		{
+			name:       "retention disabled",
+			policy:     RetentionPolicy{},
+			engineFull: "ReplacingMergeTree ORDER BY ts TTL " + ninetyDaysTTL + " SETTINGS index_granularity = 8192",
+			comment:    ttlStamp(ninetyDaysTTL),
+			execs: []string{
+				"ALTER TABLE events REMOVE TTL",
+				"ALTER TABLE events MODIFY COMMENT '" + ttlStamp("") + "'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &mockclickhouseconnection.Connection{}
+			row := &mockclickhouserows.Row{}
+			row.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				*args.Get(0).(*string) = tt.engineFull
+				*args.Get(1).(*string) = tt.comment
+			}).Return(nil).Once()
+			conn.On("QueryRow", mock.Anything, selectEventsTableQuery, []any(nil)).Return(row).Once()
+
+			var execs []string
+			conn.On("Exec", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				execs = append(execs, args.String(1))
+			}).Return(nil)
+
+			require.NoError(t, ApplyRetention(context.Background(), conn, tt.policy))
+			require.Equal(t, tt.execs, execs)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Package enrich adds server-derived fields to events at ingest.
+package enrich
+
+import (
+	"maps"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+// Metadata keys written by the enrichers. They overwrite client-sent values
+// of the same name, since the server's view is the trusted one.
+const (
+	KeyDevice       = "device"
+	KeyOS           = "os"
+	KeyBrowser      = "browser"
+	KeyCountry      = "country"
+	KeyReceivedAt   = "received_at"
+	KeyClockSkewMs  = "clock_skew_ms"
+	KeyCampaignName = "campaign_name"
+)
+
+// Source describes the request an event arrived with.
+type Source struct {
+	UserAgent  string
+	ClientIP   string
+	ReceivedAt time.Time
+}
+
+// Enricher adds fields to an event's metadata. Enrich is only called with
+// a non-nil metadata map that the enricher may modify.
+type Enricher interface {
+	Enrich(event *model.Event, src Source)
+}
+
+// Chain runs enrichers in order.
+type Chain []Enricher
+
+// Apply returns event with every enricher applied. The event's metadata is
+// copied first, so the caller's map is never modified.
+func (c Chain) Apply(event model.Event, src Source) model.Event {
+	if len(c) == 0 {
+		return event
+	}
+
+	metadata := make(map[string]any, len(event.Metadata)+len(c))
+	maps.Copy(metadata, event.Metadata)
+	event.Metadata = metadata
+
+	for _, enricher := range c {
+		enricher.Enrich(&event, src)
+	}
+	return event
+}
+
+// ReceiveTime records when the server received the event and how far the
+// client's clock was behind (positive) or ahead (negative) of it. Event
+// timestamps have second resolution, so the skew is accurate to a second.
+type ReceiveTime struct{}
+
+// Enrich implements Enricher.
+func (ReceiveTime) Enrich(event *model.Event, src Source) {
+	event.Metadata[KeyReceivedAt] = src.ReceivedAt.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	event.Metadata[KeyClockSkewMs] = src.ReceivedAt.Sub(event.Timestamp).Milliseconds()
+}
@@ -0,0 +1,50 @@
+package enrich
+
+import (
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+type staticEnricher map[string]any
+
+func (e staticEnricher) Enrich(event *model.Event, src Source) {
+	for key, value := range e {
+		event.Metadata[key] = value
+	}
+}
+
+func TestChainApply(t *testing.T) {
+	metadata := map[string]any{"plan": "pro", "tier": "client"}
+	event := model.Event{EventName: "signup", Metadata: metadata}
+
+	chain := Chain{staticEnricher{"tier": "server"}, staticEnricher{"region": "eu"}}
+	got := chain.Apply(event, Source{})
+
+	require.Equal(t, map[string]any{"plan": "pro", "tier": "server", "region": "eu"}, got.Metadata)
+	require.Equal(t, map[string]any{"plan": "pro", "tier": "client"}, metadata, "the caller's map must not change")
+}
+
+func TestChainApply_NilMetadata(t *testing.T) {
+	got := Chain{staticEnricher{"region": "eu"}}.Apply(model.Event{}, Source{})
+	require.Equal(t, map[string]any{"region": "eu"}, got.Metadata)
+
+	got = Chain(nil).Apply(model.Event{}, Source{})
+	require.Nil(t, got.Metadata)
+}
+
+func TestReceiveTime(t *testing.T) {
+	sent := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := model.Event{Timestamp: sent, Metadata: map[string]any{}}
+
+	ReceiveTime{}.Enrich(&event, Source{ReceivedAt: sent.Add(2500 * time.Millisecond)})
+
+	require.Equal(t, "2025-03-01T12:00:02.500Z", event.Metadata[KeyReceivedAt])
+	require.Equal(t, int64(2500), event.Metadata[KeyClockSkewMs])
+
+	ReceiveTime{}.Enrich(&event, Source{ReceivedAt: sent.Add(-time.Minute)})
+	require.Equal(t, int64(-60000), event.Metadata[KeyClockSkewMs], "clients ahead of the server have negative skew")
+}
@@ -0,0 +1,181 @@
+package enrich
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"event-metrics-service/internal/model"
+)
+
+// GeoIP maps client IPs to ISO country codes using a local database.
+type GeoIP struct {
+	ranges []ipRange
+}
+
+type ipRange struct {
+	first   netip.Addr
+	last    netip.Addr
+	country string
+}
+
+// LoadGeoIP reads a GeoIP database CSV. Two layouts are accepted:
+//
+//   - a header naming a "network" column of CIDR prefixes and a
+//     "country_iso_code" column, as in MaxMind GeoLite2 CSV exports joined
+//     with their locations;
+//   - no header and rows of "first_ip,last_ip,country_code", as in the DB-IP
+//     country lite database.
+func LoadGeoIP(path string) (*GeoIP, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database: %w", err)
+	}
+	defer f.Close()
+
+	g, err := ParseGeoIP(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse geoip database %s: %w", path, err)
+	}
+	return g, nil
+}
+
+// ParseGeoIP reads a GeoIP database CSV in either layout of LoadGeoIP.
+func ParseGeoIP(r io.Reader) (*GeoIP, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return &GeoIP{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	networkCol, countryCol := slices.Index(header, "network"), slices.Index(header, "country_iso_code")
+	parse := parseRangeRow
+	if networkCol >= 0 {
+		if countryCol < 0 {
+			return nil, errors.New("header has a network column but no country_iso_code column")
+		}
+		parse = func(row []string) (ipRange, error) {
+			return parseNetworkRow(row, networkCol, countryCol)
+		}
+	}
+
+	var ranges []ipRange
+	add := func(row []string) error {
+		rng, err := parse(row)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		// Networks without a country (anonymous proxies, satellite) are
+		// skipped rather than mapped to an empty code.
+		if rng.country != "" {
+			ranges = append(ranges, rng)
+		}
+		return nil
+	}
+
+	if networkCol < 0 {
+		if err := add(header); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := add(row); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(ranges, func(a, b ipRange) int { return a.first.Compare(b.first) })
+	return &GeoIP{ranges: ranges}, nil
+}
+
+func parseNetworkRow(row []string, networkCol, countryCol int) (ipRange, error) {
+	if networkCol >= len(row) || countryCol >= len(row) {
+		return ipRange{}, errors.New("missing columns")
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(row[networkCol]))
+	if err != nil {
+		return ipRange{}, err
+	}
+	prefix = prefix.Masked()
+	return ipRange{first: prefix.Addr(), last: lastAddr(prefix), country: strings.ToUpper(strings.TrimSpace(row[countryCol]))}, nil
+}
+
+func parseRangeRow(row []string) (ipRange, error) {
+	if len(row) < 3 {
+		return ipRange{}, errors.New("expected first_ip,last_ip,country_code")
+	}
+	first, err := netip.ParseAddr(strings.TrimSpace(row[0]))
+	if err != nil {
+		return ipRange{}, err
+	}
+	last, err := netip.ParseAddr(strings.TrimSpace(row[1]))
+	if err != nil {
+		return ipRange{}, err
+	}
+	if first.Is4() != last.Is4() || last.Less(first) {
+		return ipRange{}, fmt.Errorf("invalid range %s-%s", first, last)
+	}
+	return ipRange{first: first, last: last, country: strings.ToUpper(strings.TrimSpace(row[2]))}, nil
+}
+
+// lastAddr returns the highest address of a masked prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Addr()
+	bytes := addr.As16()
+	hostBits := addr.BitLen() - prefix.Bits()
+	for i := 15; i >= 0 && hostBits > 0; i-- {
+		n := min(hostBits, 8)
+		bytes[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	last := netip.AddrFrom16(bytes)
+	if addr.Is4() {
+		return last.Unmap()
+	}
+	return last
+}
+
+// Country returns the ISO country code of ip, or "" when it is unknown.
+func (g *GeoIP) Country(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// The last range starting at or before addr is the only candidate.
+	i, found := slices.BinarySearchFunc(g.ranges, addr, func(r ipRange, a netip.Addr) int { return r.first.Compare(a) })
+	if !found {
+		i--
+	}
+	if i < 0 || g.ranges[i].last.Less(addr) {
+		return ""
+	}
+	return g.ranges[i].country
+}
+
+// Enrich implements Enricher.
+func (g *GeoIP) Enrich(event *model.Event, src Source) {
+	if country := g.Country(src.ClientIP); country != "" {
+		event.Metadata[KeyCountry] = country
+	}
+}
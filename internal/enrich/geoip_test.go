@@ -0,0 +1,76 @@
+package enrich
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoIP_Networks(t *testing.T) {
+	geoIP, err := ParseGeoIP(strings.NewReader(`network,geoname_id,country_iso_code
+1.0.0.0/24,2077456,au
+81.2.69.128/26,2635167,GB
+81.2.69.192/28,,
+2001:db8::/32,2921044,DE
+`))
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"1.0.0.0":            "AU",
+		"1.0.0.255":          "AU",
+		"1.0.1.0":            "",
+		"81.2.69.130":        "GB",
+		"81.2.69.191":        "GB",
+		"81.2.69.193":        "",
+		"::ffff:81.2.69.142": "GB",
+		"2001:db8::1":        "DE",
+		"2001:db9::1":        "",
+		"0.0.0.0":            "",
+		"not-an-ip":          "",
+	}
+	for ip, want := range tests {
+		require.Equal(t, want, geoIP.Country(ip), ip)
+	}
+}
+
+func TestGeoIP_Ranges(t *testing.T) {
+	geoIP, err := ParseGeoIP(strings.NewReader(`1.0.0.0,1.0.0.255,AU
+1.0.1.0,1.0.3.255,CN
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, "AU", geoIP.Country("1.0.0.7"))
+	require.Equal(t, "CN", geoIP.Country("1.0.2.9"))
+	require.Equal(t, "", geoIP.Country("1.0.4.0"))
+}
+
+func TestGeoIP_Invalid(t *testing.T) {
+	_, err := ParseGeoIP(strings.NewReader("network,country\n1.0.0.0/24,AU\n"))
+	require.ErrorContains(t, err, "country_iso_code")
+
+	_, err = ParseGeoIP(strings.NewReader("1.0.0.0,1.0.0.255,AU\n1.0.1.0,bogus,CN\n"))
+	require.ErrorContains(t, err, "line 2")
+
+	_, err = ParseGeoIP(strings.NewReader("1.0.0.255,1.0.0.0,AU\n"))
+	require.ErrorContains(t, err, "invalid range")
+}
+
+func TestLoadGeoIP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geoip.csv")
+	require.NoError(t, os.WriteFile(path, []byte("1.0.0.0,1.0.0.255,AU\n"), 0o600))
+
+	geoIP, err := LoadGeoIP(path)
+	require.NoError(t, err)
+
+	event := model.Event{Metadata: map[string]any{}}
+	geoIP.Enrich(&event, Source{ClientIP: "1.0.0.1"})
+	require.Equal(t, map[string]any{KeyCountry: "AU"}, event.Metadata)
+
+	_, err = LoadGeoIP(filepath.Join(t.TempDir(), "missing.csv"))
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package enrich
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"event-metrics-service/internal/model"
+)
+
+// Campaigns joins campaign names onto events by campaign_id.
+type Campaigns struct {
+	names map[string]string
+}
+
+// LoadCampaigns reads a "campaign_id,campaign_name" CSV. A first row of
+// exactly those column names is treated as a header.
+func LoadCampaigns(path string) (*Campaigns, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open campaigns file: %w", err)
+	}
+	defer f.Close()
+
+	c, err := ParseCampaigns(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse campaigns file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseCampaigns reads a campaigns CSV as described by LoadCampaigns.
+func ParseCampaigns(r io.Reader) (*Campaigns, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	names := map[string]string{}
+	for first := true; ; first = false {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		id, name := strings.TrimSpace(row[0]), strings.TrimSpace(row[1])
+		if first && id == "campaign_id" && name == "campaign_name" {
+			continue
+		}
+		if id == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: campaign_id is empty", line)
+		}
+		names[id] = name
+	}
+	return &Campaigns{names: names}, nil
+}
+
+// Name returns the name of a campaign and whether it is known.
+func (c *Campaigns) Name(campaignID string) (string, bool) {
+	name, ok := c.names[campaignID]
+	return name, ok
+}
+
+// Enrich implements Enricher.
+func (c *Campaigns) Enrich(event *model.Event, src Source) {
+	if event.CampaignID == "" {
+		return
+	}
+	if name, ok := c.Name(event.CampaignID); ok {
+		event.Metadata[KeyCampaignName] = name
+	}
+}
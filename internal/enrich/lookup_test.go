@@ -0,0 +1,38 @@
+package enrich
+
+import (
+	"strings"
+	"testing"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaigns(t *testing.T) {
+	campaigns, err := ParseCampaigns(strings.NewReader(`campaign_id,campaign_name
+cmp_spring, Spring Sale
+cmp_bf,"Black Friday, 2025"
+`))
+	require.NoError(t, err)
+
+	name, ok := campaigns.Name("cmp_bf")
+	require.True(t, ok)
+	require.Equal(t, "Black Friday, 2025", name)
+
+	event := model.Event{CampaignID: "cmp_spring", Metadata: map[string]any{}}
+	campaigns.Enrich(&event, Source{})
+	require.Equal(t, map[string]any{KeyCampaignName: "Spring Sale"}, event.Metadata)
+
+	event = model.Event{CampaignID: "cmp_unknown", Metadata: map[string]any{}}
+	campaigns.Enrich(&event, Source{})
+	require.Empty(t, event.Metadata)
+}
+
+func TestCampaigns_Invalid(t *testing.T) {
+	_, err := ParseCampaigns(strings.NewReader("cmp_spring,Spring Sale,extra\n"))
+	require.Error(t, err)
+
+	_, err = ParseCampaigns(strings.NewReader("cmp_spring,Spring Sale\n,Nameless\n"))
+	require.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,105 @@
+package enrich
+
+import (
+	"strings"
+
+	"event-metrics-service/internal/model"
+)
+
+// Device types reported by UserAgent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// UserAgent parses the User-Agent header into device type, operating system
+// and browser. Parts it does not recognize are left out.
+type UserAgent struct{}
+
+// Enrich implements Enricher.
+func (UserAgent) Enrich(event *model.Event, src Source) {
+	if src.UserAgent == "" {
+		return
+	}
+
+	device, os, browser := ParseUserAgent(src.UserAgent)
+	event.Metadata[KeyDevice] = device
+	if os != "" {
+		event.Metadata[KeyOS] = os
+	}
+	if browser != "" {
+		event.Metadata[KeyBrowser] = browser
+	}
+}
+
+var botMarkers = []string{"bot", "crawler", "spider", "curl/", "wget/", "python-requests", "go-http-client", "headless"}
+
+// match pairs a User-Agent substring with the name it identifies. Lists are
+// ordered: many agents mention the tokens of others ("like Mac OS X",
+// "Chrome/... Safari/...").
+type match struct {
+	token string
+	name  string
+}
+
+var osMatches = []match{
+	{"Windows NT", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"iPod", "iOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+var browserMatches = []match{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Opera", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+// ParseUserAgent returns the device type, operating system and browser of a
+// User-Agent string.
+func ParseUserAgent(ua string) (device, os, browser string) {
+	os = firstMatch(ua, osMatches)
+	browser = firstMatch(ua, browserMatches)
+
+	lower := strings.ToLower(ua)
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			return DeviceBot, os, browser
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(lower, "tablet") ||
+		(os == "Android" && !strings.Contains(ua, "Mobile")):
+		device = DeviceTablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"):
+		device = DeviceMobile
+	default:
+		device = DeviceDesktop
+	}
+	return device, os, browser
+}
+
+func firstMatch(ua string, matches []match) string {
+	for _, m := range matches {
+		if strings.Contains(ua, m.token) {
+			return m.name
+		}
+	}
+	return ""
+}
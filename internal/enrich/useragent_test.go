@@ -0,0 +1,76 @@
+package enrich
+
+import (
+	"testing"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua      string
+		device  string
+		os      string
+		browser string
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			DeviceDesktop, "Windows", "Chrome",
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			DeviceDesktop, "Windows", "Edge",
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+			DeviceDesktop, "macOS", "Safari",
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			DeviceMobile, "iOS", "Safari",
+		},
+		{
+			"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			DeviceTablet, "iOS", "Chrome",
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36",
+			DeviceMobile, "Android", "Chrome",
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Safari/537.36",
+			DeviceTablet, "Android", "Samsung Internet",
+		},
+		{
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			DeviceDesktop, "Linux", "Firefox",
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			DeviceBot, "", "",
+		},
+		{"curl/8.5.0", DeviceBot, "", ""},
+		{"MyApp/3.1", DeviceDesktop, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ua, func(t *testing.T) {
+			device, os, browser := ParseUserAgent(tt.ua)
+			require.Equal(t, tt.device, device)
+			require.Equal(t, tt.os, os)
+			require.Equal(t, tt.browser, browser)
+		})
+	}
+}
+
+func TestUserAgentEnrich(t *testing.T) {
+	event := model.Event{Metadata: map[string]any{}}
+	UserAgent{}.Enrich(&event, Source{UserAgent: "curl/8.5.0"})
+	require.Equal(t, map[string]any{KeyDevice: DeviceBot}, event.Metadata)
+
+	event = model.Event{Metadata: map[string]any{}}
+	UserAgent{}.Enrich(&event, Source{})
+	require.Empty(t, event.Metadata)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"event-metrics-service/internal/model"
+)
+
+// DirWriter splits an export into numbered files of at most ChunkRows events
+// each, e.g. events-00001.parquet. Every chunk is a complete file that can be
+// read on its own.
+type DirWriter struct {
+	dir       string
+	prefix    string
+	format    Format
+	chunkRows int
+
+	file  *os.File
+	buf   *bufio.Writer
+	w     Writer
+	rows  int
+	files []string
+}
+
+// NewDirWriter creates dir if needed and returns a writer that starts a new
+// file every chunkRows events. chunkRows <= 0 writes a single file.
+func NewDirWriter(dir, prefix string, format Format, chunkRows int) (*DirWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create export dir: %w", err)
+	}
+	return &DirWriter{dir: dir, prefix: prefix, format: format, chunkRows: chunkRows}, nil
+}
+
+func (d *DirWriter) Write(event model.Event) error {
+	if d.w != nil && d.chunkRows > 0 && d.rows >= d.chunkRows {
+		if err := d.closeChunk(); err != nil {
+			return err
+		}
+	}
+
+	if d.w == nil {
+		if err := d.openChunk(); err != nil {
+			return err
+		}
+	}
+
+	d.rows++
+	return d.w.Write(event)
+}
+
+// Close finishes the current chunk. An export without events still produces
+// one (empty) file so callers can tell it ran.
+func (d *DirWriter) Close() error {
+	if d.w == nil && len(d.files) == 0 {
+		if err := d.openChunk(); err != nil {
+			return err
+		}
+	}
+	if d.w == nil {
+		return nil
+	}
+	return d.closeChunk()
+}
+
+// Files lists the chunk files written so far, in order.
+func (d *DirWriter) Files() []string {
+	return d.files
+}
+
+func (d *DirWriter) openChunk() error {
+	name := filepath.Join(d.dir, fmt.Sprintf("%s-%05d.%s", d.prefix, len(d.files)+1, d.format.Extension()))
+
+	file, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("create export chunk: %w", err)
+	}
+
+	buf := bufio.NewWriter(file)
+	w, err := NewWriter(d.format, buf)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	d.file, d.buf, d.w, d.rows = file, buf, w, 0
+	d.files = append(d.files, name)
+	return nil
+}
+
+func (d *DirWriter) closeChunk() error {
+	defer func() { d.file, d.buf, d.w = nil, nil, nil }()
+
+	if err := d.w.Close(); err != nil {
+		d.file.Close()
+		return fmt.Errorf("finish export chunk: %w", err)
+	}
+	if err := d.buf.Flush(); err != nil {
+		d.file.Close()
+		return fmt.Errorf("write export chunk: %w", err)
+	}
+	return d.file.Close()
+}
@@ -0,0 +1,45 @@
+// Package export renders raw events as CSV, NDJSON or Parquet.
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat validates a format name. An empty name selects NDJSON.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case "":
+		return FormatNDJSON, nil
+	case FormatCSV, FormatNDJSON, FormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (expected csv, ndjson or parquet)", name)
+	}
+}
+
+// ContentType is the HTTP media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Extension is the file name extension of the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
@@ -0,0 +1,210 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Writer encodes events one at a time. Close flushes buffered output and, for
+// Parquet, writes the footer; it does not close the underlying io.Writer.
+type Writer interface {
+	Write(event model.Event) error
+	Close() error
+}
+
+// NewWriter returns a Writer encoding events in format to w.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// timestampLayout keeps the millisecond precision events are stored with.
+const timestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+var csvHeader = []string{"event_name", "channel", "campaign_id", "user_id", "timestamp", "received_at", "tags", "metadata"}
+
+// csvWriter writes one row per event. tags and metadata are nested values, so
+// they are written as JSON inside their cells.
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(event model.Event) error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	tags, err := marshalTags(event.Tags)
+	if err != nil {
+		return err
+	}
+	metadata, err := marshalMetadata(event.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return c.w.Write([]string{
+		event.EventName,
+		event.Channel,
+		event.CampaignID,
+		event.UserID,
+		event.Timestamp.UTC().Format(timestampLayout),
+		event.ReceivedAt.UTC().Format(timestampLayout),
+		tags,
+		metadata,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	if !c.headerWritten {
+		// An empty export still describes its columns.
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonRecord is the JSON shape of an exported event.
+type ndjsonRecord struct {
+	EventName  string         `json:"event_name"`
+	Channel    string         `json:"channel"`
+	CampaignID string         `json:"campaign_id,omitempty"`
+	UserID     string         `json:"user_id"`
+	Timestamp  string         `json:"timestamp"`
+	ReceivedAt string         `json:"received_at"`
+	Tags       []string       `json:"tags"`
+	Metadata   map[string]any `json:"metadata"`
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(event model.Event) error {
+	tags := event.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	return n.enc.Encode(ndjsonRecord{
+		EventName:  event.EventName,
+		Channel:    event.Channel,
+		CampaignID: event.CampaignID,
+		UserID:     event.UserID,
+		Timestamp:  event.Timestamp.UTC().Format(timestampLayout),
+		ReceivedAt: event.ReceivedAt.UTC().Format(timestampLayout),
+		Tags:       tags,
+		Metadata:   metadata,
+	})
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// parquetRow is the Parquet schema of an exported event. Metadata has no
+// fixed shape, so it is kept as a JSON column.
+type parquetRow struct {
+	EventName  string    `parquet:"event_name,dict"`
+	Channel    string    `parquet:"channel,dict"`
+	CampaignID string    `parquet:"campaign_id,optional"`
+	UserID     string    `parquet:"user_id"`
+	Timestamp  time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	ReceivedAt time.Time `parquet:"received_at,timestamp(millisecond)"`
+	Tags       []string  `parquet:"tags,list"`
+	Metadata   string    `parquet:"metadata,json"`
+}
+
+// parquetRowGroupSize bounds how many rows are buffered in memory before a
+// row group is written out.
+const parquetRowGroupSize = 100_000
+
+type parquetWriter struct {
+	w   *parquet.GenericWriter[parquetRow]
+	row []parquetRow
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		w: parquet.NewGenericWriter[parquetRow](w,
+			parquet.Compression(&parquet.Zstd),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		),
+		row: make([]parquetRow, 1),
+	}
+}
+
+func (p *parquetWriter) Write(event model.Event) error {
+	metadata, err := marshalMetadata(event.Metadata)
+	if err != nil {
+		return err
+	}
+
+	p.row[0] = parquetRow{
+		EventName:  event.EventName,
+		Channel:    event.Channel,
+		CampaignID: event.CampaignID,
+		UserID:     event.UserID,
+		Timestamp:  event.Timestamp.UTC(),
+		ReceivedAt: event.ReceivedAt.UTC(),
+		Tags:       event.Tags,
+		Metadata:   metadata,
+	}
+	_, err = p.w.Write(p.row)
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
+
+func marshalTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("marshal tags: %w", err)
+	}
+	return string(b), nil
+}
+
+func marshalMetadata(metadata map[string]any) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("marshal metadata: %w", err)
+	}
+	return string(b), nil
+}
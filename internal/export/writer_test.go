@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+var ts = time.Date(2025, 3, 10, 12, 0, 0, 250*int(time.Millisecond), time.UTC)
+
+func sampleEvents() []model.Event {
+	return []model.Event{
+		{
+			EventName:  "purchase",
+			Channel:    "web",
+			CampaignID: "cmp_1",
+			UserID:     "u1",
+			Timestamp:  ts,
+			ReceivedAt: ts.Add(2 * time.Minute),
+			Tags:       []string{"sale"},
+			Metadata:   map[string]any{"price": 9.5, "note": "a,b"},
+		},
+		{
+			EventName:  "purchase",
+			Channel:    "mobile_app",
+			UserID:     "u2",
+			Timestamp:  ts.Add(time.Second),
+			ReceivedAt: ts.Add(time.Second),
+		},
+	}
+}
+
+func writeAll(t *testing.T, format Format, events []model.Event) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	require.NoError(t, err)
+	for _, e := range events {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	require.NoError(t, err)
+	require.Equal(t, FormatNDJSON, f)
+
+	f, err = ParseFormat(" Parquet ")
+	require.NoError(t, err)
+	require.Equal(t, FormatParquet, f)
+
+	_, err = ParseFormat("xlsx")
+	require.ErrorContains(t, err, "unsupported export format")
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV, sampleEvents()))).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		csvHeader,
+		{"purchase", "web", "cmp_1", "u1", "2025-03-10T12:00:00.250Z", "2025-03-10T12:02:00.250Z", `["sale"]`, `{"note":"a,b","price":9.5}`},
+		{"purchase", "mobile_app", "", "u2", "2025-03-10T12:00:01.250Z", "2025-03-10T12:00:01.250Z", `[]`, `{}`},
+	}, records)
+}
+
+func TestCSVWriter_EmptyHasHeader(t *testing.T) {
+	require.Equal(t, strings.Join(csvHeader, ",")+"\n", string(writeAll(t, FormatCSV, nil)))
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeAll(t, FormatNDJSON, sampleEvents()))), "\n")
+	require.Len(t, lines, 2)
+
+	require.JSONEq(t, `{"event_name":"purchase","channel":"web","campaign_id":"cmp_1","user_id":"u1",
+		"timestamp":"2025-03-10T12:00:00.250Z","received_at":"2025-03-10T12:02:00.250Z","tags":["sale"],"metadata":{"price":9.5,"note":"a,b"}}`, lines[0])
+	require.JSONEq(t, `{"event_name":"purchase","channel":"mobile_app","user_id":"u2",
+		"timestamp":"2025-03-10T12:00:01.250Z","received_at":"2025-03-10T12:00:01.250Z","tags":[],"metadata":{}}`, lines[1])
+}
+
+func TestParquetWriter_RoundTrip(t *testing.T) {
+	data := writeAll(t, FormatParquet, sampleEvents())
+
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	require.Equal(t, "cmp_1", rows[0].CampaignID)
+	require.Equal(t, []string{"sale"}, rows[0].Tags)
+	require.True(t, ts.Equal(rows[0].Timestamp))
+	require.True(t, ts.Add(2*time.Minute).Equal(rows[0].ReceivedAt))
+	var metadata map[string]any
+	require.NoError(t, json.Unmarshal([]byte(rows[0].Metadata), &metadata))
+	require.Equal(t, map[string]any{"price": 9.5, "note": "a,b"}, metadata)
+
+	require.Equal(t, "", rows[1].CampaignID)
+	require.Equal(t, "{}", rows[1].Metadata)
+}
+
+func TestDirWriter_Chunks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	w, err := NewDirWriter(dir, "events", FormatNDJSON, 2)
+	require.NoError(t, err)
+
+	events := append(sampleEvents(), sampleEvents()...)
+	events = append(events, sampleEvents()[0])
+	for _, e := range events {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Close())
+
+	require.Equal(t, []string{
+		filepath.Join(dir, "events-00001.ndjson"),
+		filepath.Join(dir, "events-00002.ndjson"),
+		filepath.Join(dir, "events-00003.ndjson"),
+	}, w.Files())
+
+	lineCounts := make([]int, len(w.Files()))
+	for i, name := range w.Files() {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		lineCounts[i] = strings.Count(string(data), "\n")
+	}
+	require.Equal(t, []int{2, 2, 1}, lineCounts)
+}
+
+func TestDirWriter_ParquetChunksAreReadable(t *testing.T) {
+	w, err := NewDirWriter(t.TempDir(), "events", FormatParquet, 1)
+	require.NoError(t, err)
+	for _, e := range sampleEvents() {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Close())
+	require.Len(t, w.Files(), 2)
+
+	for _, name := range w.Files() {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		rows, err := parquet.Read[parquetRow](bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+	}
+}
+
+func TestDirWriter_EmptyExportWritesOneFile(t *testing.T) {
+	w, err := NewDirWriter(t.TempDir(), "events", FormatCSV, 10)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Len(t, w.Files(), 1)
+}
@@ -4,9 +4,13 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 
+	"event-metrics-service/internal/audit"
+	"event-metrics-service/internal/auth"
 	"event-metrics-service/internal/config"
 	"event-metrics-service/internal/controller"
+	"event-metrics-service/internal/ratelimit"
 	routes "event-metrics-service/internal/routes"
+	"event-metrics-service/internal/tenant"
 )
 
 // Server wraps the Fiber application setup.
@@ -14,17 +18,75 @@ type Server struct {
 	app *fiber.App
 }
 
-// NewServer configures routes and middleware.
-func NewServer(appCfg *config.Config, eventController controller.EventController) *Server {
+// Controllers groups the handlers mounted by the server.
+type Controllers struct {
+	Event controller.EventController
+	Admin routes.AdminControllers
+}
+
+// Security holds the state behind the request guards.
+type Security struct {
+	Keys    *auth.KeyStore
+	Tenants *tenant.Registry
+	Limits  *ratelimit.Enforcer
+	// Signatures verifies signed ingest requests; nil disables signing.
+	Signatures *auth.SignatureVerifier
+	// Tokens verifies identity tokens on query routes; nil disables them.
+	Tokens *auth.TokenVerifier
+	// Audit records metrics queries and admin requests; nil disables the
+	// audit log.
+	Audit audit.Recorder
+}
+
+// NewServer configures routes and middleware. When authentication is
+// enabled, every route except /health requires an API key with the
+// matching scope. Query routes also accept identity tokens when configured,
+// and require them even without API key authentication. Ingest and query
+// routes are scoped to a tenant and rate limited, and ingest requests may
+// be signed. Metrics queries and admin requests are audited when a recorder
+// is configured.
+func NewServer(appCfg *config.Config, controllers Controllers, security Security) *Server {
 	fiberCfg := fiber.Config{
 		DisableStartupMessage: true,
 		Prefork:               appCfg.FiberPrefork,
+		// Behind a load balancer the client IP comes from a header it sets.
+		ProxyHeader: appCfg.ProxyHeader,
 	}
 	app := fiber.New(fiberCfg)
 	// app.Use(logger.New())
 	app.Use(recover.New())
 
-	routes.Register(app, eventController)
+	var guards routes.Guards
+	if appCfg.AuthEnabled {
+		guards = routes.Guards{
+			Ingest: auth.RequireScope(security.Keys, auth.ScopeWrite),
+			Query:  auth.RequireScope(security.Keys, auth.ScopeRead),
+			Admin:  auth.RequireScope(security.Keys, auth.ScopeAdmin),
+		}
+	}
+
+	if security.Tokens != nil {
+		var keys *auth.KeyStore
+		if appCfg.AuthEnabled {
+			keys = security.Keys
+		}
+		guards.Query = auth.RequireTokenOrScope(security.Tokens, keys, auth.ScopeRead)
+	}
+
+	guards.Tenant = tenant.Middleware(security.Tenants)
+	guards.IngestLimit = security.Limits.Middleware(ratelimit.Ingest)
+	guards.QueryLimit = security.Limits.Middleware(ratelimit.Query)
+	if security.Signatures != nil {
+		guards.Signature = security.Signatures.Middleware()
+	}
+	if security.Audit != nil {
+		guards.Audit = audit.Middleware(security.Audit)
+	}
+
+	routes.Register(app, controllers.Event, guards)
+	if appCfg.AdminEnabled {
+		routes.RegisterAdmin(app, controllers.Admin, guards)
+	}
 
 	return &Server{app: app}
 }
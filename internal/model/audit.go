@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// AuditRecord is one row of the access audit log: a metrics query or an
+// admin action and who performed it.
+type AuditRecord struct {
+	At time.Time `json:"at"`
+	// Principal is "key:<id>" for API keys, "sub:<subject>" for identity
+	// tokens and empty when the request was not authenticated.
+	Principal string `json:"principal"`
+	TenantID  string `json:"tenant_id"`
+	// Action is the method and route pattern, e.g. "DELETE /admin/keys/:id".
+	Action   string `json:"action"`
+	Path     string `json:"path"`
+	ClientIP string `json:"client_ip"`
+	// Filter is the normalized MetricsFilter of metrics queries as JSON.
+	Filter     string `json:"filter,omitempty"`
+	DurationMs uint64 `json:"duration_ms"`
+	RowsRead   uint64 `json:"rows_read"`
+	Status     int    `json:"status"`
+}
+
+// AuditQuery selects audit records. Empty fields match everything.
+type AuditQuery struct {
+	TenantID  string
+	Principal string
+	Action    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
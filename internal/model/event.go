@@ -10,14 +10,27 @@ type EventRequest struct {
 	Channel    string                 `json:"channel"`
 	CampaignID *string                `json:"campaign_id"`
 	UserID     string                 `json:"user_id"`
-	Timestamp  int64                  `json:"timestamp"`
 	Tags       []string               `json:"tags"`
 	Metadata   map[string]interface{} `json:"metadata"`
+
+	// Timestamp is unix seconds, unix milliseconds or RFC 3339. TimestampUnit
+	// ("s" or "ms") fixes the unit of numeric values instead of detecting it.
+	Timestamp     RawTimestamp `json:"timestamp"`
+	TimestampUnit string       `json:"timestamp_unit,omitempty"`
+
+	// TenantID is resolved from the API key or X-Tenant-ID header, never
+	// from the body.
+	TenantID string `json:"-"`
+
+	// UserAgent and ClientIP describe the ingest request for enrichment.
+	UserAgent string `json:"-"`
+	ClientIP  string `json:"-"`
 }
 
 // Event is the domain model persisted in the database.
 type Event struct {
 	ID         int64
+	TenantID   string
 	EventName  string
 	Channel    string
 	CampaignID string
@@ -25,4 +38,8 @@ type Event struct {
 	Timestamp  time.Time
 	Tags       []string
 	Metadata   map[string]interface{}
+
+	// ReceivedAt is when the API accepted the event, as opposed to
+	// Timestamp, which the client reports.
+	ReceivedAt time.Time
 }
@@ -0,0 +1,30 @@
+package model
+
+import "errors"
+
+// ClientLimits overrides the deployment-wide rate limits and daily event
+// quota for an API key or tenant. Zero fields keep the default.
+type ClientLimits struct {
+	// IngestRate and QueryRate are sustained requests per second; the
+	// bursts are how many requests may arrive at once.
+	IngestRate  float64 `json:"ingest_rate,omitempty"`
+	IngestBurst int     `json:"ingest_burst,omitempty"`
+	QueryRate   float64 `json:"query_rate,omitempty"`
+	QueryBurst  int     `json:"query_burst,omitempty"`
+
+	// DailyEvents caps the events accepted per UTC day.
+	DailyEvents int64 `json:"daily_events,omitempty"`
+}
+
+// IsZero reports whether the limits override nothing.
+func (l ClientLimits) IsZero() bool {
+	return l == ClientLimits{}
+}
+
+// Validate rejects negative limits.
+func (l ClientLimits) Validate() error {
+	if l.IngestRate < 0 || l.QueryRate < 0 || l.IngestBurst < 0 || l.QueryBurst < 0 || l.DailyEvents < 0 {
+		return errors.New("limits must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package model
+
+import "regexp"
+
+// MetadataKeyPattern restricts metadata keys that may become column names or
+// be used in metrics filters and grouping.
+var MetadataKeyPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// MetadataGroupPrefix marks a group_by value that groups on a metadata key,
+// e.g. "metadata.currency".
+const MetadataGroupPrefix = "metadata."
+
+// PromotedColumn is a metadata key stored in its own typed column instead of
+// only inside the metadata JSON string.
+type PromotedColumn struct {
+	// Key is the metadata key, e.g. "price".
+	Key string
+
+	// Type is the declared ClickHouse type, e.g. "Float64" or
+	// "LowCardinality(String)".
+	Type string
+}
+
+// Column returns the events column backing the key.
+func (p PromotedColumn) Column() string {
+	return "meta_" + p.Key
+}
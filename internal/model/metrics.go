@@ -4,13 +4,31 @@ import "time"
 
 // MetricsFilter represents metrics query filters.
 type MetricsFilter struct {
+	// TenantID scopes the query; repositories always filter on it.
+	TenantID  string
 	EventName string
 	From      time.Time
 	To        time.Time
 	Channel   *string
 	GroupBy   string
+
+	// Metadata holds equality filters on metadata keys.
+	Metadata map[string]string
+
+	// Consistency selects whether not-yet-merged duplicates are counted.
+	Consistency string
 }
 
+// Consistency levels for metrics queries.
+const (
+	// ConsistencyEventual reads rows as stored. Duplicates are counted until
+	// a background merge collapses them.
+	ConsistencyEventual = "eventual"
+
+	// ConsistencyDedup collapses duplicates at query time, at extra cost.
+	ConsistencyDedup = "dedup"
+)
+
 // MetricsGroup is a grouped metrics result.
 type MetricsGroup struct {
 	Key             string `json:"key"`
@@ -18,6 +36,14 @@ type MetricsGroup struct {
 	UniqueUserCount uint64 `json:"unique_user_count"`
 }
 
+// MetricsResult is the aggregated output of a metrics query. Totals and
+// groups are computed in the same pass so they always describe the same data.
+type MetricsResult struct {
+	TotalCount  uint64
+	UniqueCount uint64
+	Groups      []MetricsGroup
+}
+
 // MetricsResponse is returned to clients for metrics queries.
 type MetricsResponse struct {
 	Meta MetricsMeta `json:"meta"`
@@ -30,6 +56,9 @@ type MetricsMeta struct {
 	Period    MetricsPeriod          `json:"period"`
 	Filters   map[string]interface{} `json:"filters,omitempty"`
 	GroupBy   string                 `json:"group_by,omitempty"`
+
+	Consistency     string `json:"consistency,omitempty"`
+	ConsistencyNote string `json:"consistency_note,omitempty"`
 }
 
 // MetricsPeriod captures the time window.
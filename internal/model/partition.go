@@ -0,0 +1,10 @@
+package model
+
+// Partition describes one daily partition of the events table.
+type Partition struct {
+	ID          string `json:"partition"`
+	Date        string `json:"date"`
+	Parts       uint64 `json:"parts"`
+	Rows        uint64 `json:"rows"`
+	BytesOnDisk uint64 `json:"bytes_on_disk"`
+}
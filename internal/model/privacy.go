@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Privacy request types.
+const (
+	PrivacyRequestErasure = "erasure"
+	PrivacyRequestAccess  = "access"
+)
+
+// Privacy request statuses. An erasure stays running until the storage
+// backend has physically finished removing the user's rows.
+const (
+	PrivacyStatusSubmitted = "submitted"
+	PrivacyStatusRunning   = "running"
+	PrivacyStatusCompleted = "completed"
+	PrivacyStatusFailed    = "failed"
+)
+
+// PrivacyRequestInput is the body of an erasure or access request.
+type PrivacyRequestInput struct {
+	// TenantID selects whose events are covered; empty means the default
+	// tenant.
+	TenantID    string `json:"tenant_id"`
+	UserID      string `json:"user_id"`
+	RequestedBy string `json:"requested_by"`
+	Reason      string `json:"reason"`
+}
+
+// PrivacyAuditEntry is one row of the append-only privacy audit trail.
+type PrivacyAuditEntry struct {
+	RequestID string
+	Type      string
+	TenantID  string
+	UserID    string
+	Status    string
+	Actor     string
+	Detail    string
+	At        time.Time
+}
+
+// PrivacyRequest is the current state of a privacy request, folded from its
+// audit entries.
+type PrivacyRequest struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	TenantID    string    `json:"tenant_id"`
+	UserID      string    `json:"user_id"`
+	Status      string    `json:"status"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserDataExport is the response to an access request.
+type UserDataExport struct {
+	Request PrivacyRequest `json:"request"`
+	Events  []UserEvent    `json:"events"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "regexp"
+
+// DefaultTenant owns requests that name no tenant and all events stored
+// before multi-tenancy was introduced.
+const DefaultTenant = "default"
+
+// TenantIDPattern restricts tenant IDs to characters that are safe in
+// headers, file names and SQL literals.
+var TenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// TenantOrDefault returns tenantID, or DefaultTenant when it is empty.
+func TenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return DefaultTenant
+	}
+	return tenantID
+}
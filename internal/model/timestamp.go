@@ -0,0 +1,137 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp units accepted as timestamp_unit.
+const (
+	UnitSeconds      = "s"
+	UnitMilliseconds = "ms"
+)
+
+// Unit detection bounds. Seconds below 1e10 reach 2286 and milliseconds from
+// 1e12 start in 2001; a value in between is read differently by each unit,
+// so it needs an explicit timestamp_unit.
+const (
+	maxDetectedSeconds      = 1e10
+	minDetectedMilliseconds = 1e12
+)
+
+// numericTimestampPattern matches decimal unix times. Exponents, signs and
+// special values such as NaN are rejected.
+var numericTimestampPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// RawTimestamp is a timestamp as sent by a client: a JSON number or string,
+// kept verbatim until ParseTimestamp reads it with the requested unit.
+type RawTimestamp string
+
+// UnmarshalJSON accepts a JSON number or string. null leaves it empty.
+func (t *RawTimestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*t = ""
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*t = RawTimestamp(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return errors.New("timestamp must be a number or a string")
+	}
+	*t = RawTimestamp(n)
+	return nil
+}
+
+// MarshalJSON writes numeric timestamps as JSON numbers and anything else as
+// a string.
+func (t RawTimestamp) MarshalJSON() ([]byte, error) {
+	if t == "" {
+		return []byte("null"), nil
+	}
+	if numericTimestampPattern.MatchString(string(t)) {
+		return []byte(t), nil
+	}
+	return json.Marshal(string(t))
+}
+
+// ParseTimestamp reads unix seconds, unix milliseconds or an RFC 3339 time.
+// unit is UnitSeconds, UnitMilliseconds or empty to detect the unit of
+// numeric values from their magnitude. Numbers may carry a decimal fraction,
+// e.g. 1723475612.125 seconds.
+func ParseTimestamp(raw, unit string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, errors.New("timestamp is empty")
+	}
+
+	switch unit {
+	case "", UnitSeconds, UnitMilliseconds:
+	default:
+		return time.Time{}, fmt.Errorf("timestamp_unit must be %s or %s, got %q", UnitSeconds, UnitMilliseconds, unit)
+	}
+
+	if !numericTimestampPattern.MatchString(raw) {
+		if unit != "" {
+			return time.Time{}, fmt.Errorf("timestamp_unit only applies to numeric timestamps, got %q", raw)
+		}
+		ts, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("timestamp %q is not unix seconds, unix milliseconds or RFC 3339", raw)
+		}
+		return ts.UTC(), nil
+	}
+
+	whole, fraction, _ := strings.Cut(raw, ".")
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp %s is out of range", raw)
+	}
+	if n == 0 && strings.Trim(fraction, "0") == "" {
+		return time.Time{}, errors.New("timestamp must be after 1970-01-01")
+	}
+
+	if unit == "" {
+		switch {
+		case n < maxDetectedSeconds:
+			unit = UnitSeconds
+		case n >= minDetectedMilliseconds:
+			unit = UnitMilliseconds
+		default:
+			return time.Time{}, fmt.Errorf("timestamp %s is ambiguous: set timestamp_unit to %s or %s", raw, UnitSeconds, UnitMilliseconds)
+		}
+	}
+
+	// Nanoseconds of the fraction, ignoring digits past nanosecond precision.
+	scale := int64(time.Second)
+	if unit == UnitMilliseconds {
+		scale = int64(time.Millisecond)
+	}
+	var nanos int64
+	for _, digit := range fraction {
+		scale /= 10
+		if scale == 0 {
+			break
+		}
+		nanos += int64(digit-'0') * scale
+	}
+
+	if unit == UnitMilliseconds {
+		return time.Unix(n/1000, (n%1000)*int64(time.Millisecond)+nanos).UTC(), nil
+	}
+	return time.Unix(n, nanos).UTC(), nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	seconds := time.Date(2024, 8, 12, 15, 13, 32, 0, time.UTC)
+	millis := seconds.Add(123 * time.Millisecond)
+
+	for _, tt := range []struct {
+		name string
+		raw  string
+		unit string
+		want time.Time
+	}{
+		{"seconds detected", "1723475612", "", seconds},
+		{"milliseconds detected", "1723475612123", "", millis},
+		{"fractional seconds", "1723475612.123", "", millis},
+		{"fractional milliseconds", "1723475612123.5", "", millis.Add(500 * time.Microsecond)},
+		{"explicit seconds", "1723475612", UnitSeconds, seconds},
+		{"explicit milliseconds", "1723475612123", UnitMilliseconds, millis},
+		{"explicit milliseconds in the ambiguous range", "86400000", UnitMilliseconds, time.Unix(86400, 0).UTC()},
+		{"explicit seconds in the ambiguous range", "20000000000", UnitSeconds, time.Unix(20000000000, 0).UTC()},
+		{"rfc3339", "2024-08-12T15:13:32Z", "", seconds},
+		{"rfc3339 with milliseconds and offset", "2024-08-12T18:13:32.123+03:00", "", millis},
+		{"surrounding spaces", " 1723475612 ", "", seconds},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.raw, tt.unit)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseTimestamp_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		raw     string
+		unit    string
+		message string
+	}{
+		{"empty", "", "", "timestamp is empty"},
+		{"ambiguous", "20000000000", "", "ambiguous: set timestamp_unit"},
+		{"zero", "0", "", "after 1970-01-01"},
+		{"negative", "-1723475612", "", "not unix seconds"},
+		{"exponent", "1.7e9", "", "not unix seconds"},
+		{"date only", "2024-08-12", "", "not unix seconds"},
+		{"unknown unit", "1723475612", "us", "timestamp_unit must be s or ms"},
+		{"unit with rfc3339", "2024-08-12T15:13:32Z", UnitSeconds, "only applies to numeric"},
+		{"out of range", "99999999999999999999", UnitMilliseconds, "out of range"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTimestamp(tt.raw, tt.unit)
+			require.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestRawTimestamp_JSON(t *testing.T) {
+	var req struct {
+		Number RawTimestamp `json:"number"`
+		Text   RawTimestamp `json:"text"`
+		Null   RawTimestamp `json:"null"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"number": 1723475612123, "text": "2024-08-12T15:13:32Z", "null": null}`), &req))
+	require.Equal(t, RawTimestamp("1723475612123"), req.Number)
+	require.Equal(t, RawTimestamp("2024-08-12T15:13:32Z"), req.Text)
+	require.Equal(t, RawTimestamp(""), req.Null)
+
+	out, err := json.Marshal(req)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"number": 1723475612123, "text": "2024-08-12T15:13:32Z", "null": null}`, string(out))
+
+	require.Error(t, json.Unmarshal([]byte(`{"number": true}`), &req))
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// UserEventsCursor is the position of the last event on a timeline page.
+// Events are ordered newest first by timestamp, with the remaining sorting
+// key columns breaking ties so that no event is skipped or repeated.
+type UserEventsCursor struct {
+	Timestamp  time.Time `json:"ts"`
+	EventName  string    `json:"event_name"`
+	Channel    string    `json:"channel"`
+	CampaignID string    `json:"campaign_id,omitempty"`
+}
+
+// UserEventsFilter selects a page of one user's raw events.
+type UserEventsFilter struct {
+	TenantID  string
+	UserID    string
+	From      time.Time
+	To        time.Time
+	EventName string
+
+	// After continues a timeline from the given position, exclusive.
+	After *UserEventsCursor
+
+	Limit int
+}
+
+// UserEvent is a raw event as returned by the user timeline API.
+type UserEvent struct {
+	EventName  string         `json:"event_name"`
+	Channel    string         `json:"channel"`
+	CampaignID string         `json:"campaign_id,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+	ReceivedAt time.Time      `json:"received_at"`
+	Tags       []string       `json:"tags"`
+	Metadata   map[string]any `json:"metadata"`
+}
+
+// UserEventsResponse is one page of a user's timeline.
+type UserEventsResponse struct {
+	UserID     string      `json:"user_id"`
+	Events     []UserEvent `json:"events"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// UserEventCount is the number of events of one name a user produced.
+type UserEventCount struct {
+	EventName string    `json:"event_name"`
+	Count     uint64    `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// UserSummary describes a user's activity within a time window.
+type UserSummary struct {
+	UserID      string           `json:"user_id"`
+	Period      MetricsPeriod    `json:"period"`
+	FirstSeen   *time.Time       `json:"first_seen"`
+	LastSeen    *time.Time       `json:"last_seen"`
+	TotalCount  uint64           `json:"total_count"`
+	EventCounts []UserEventCount `json:"event_counts"`
+}
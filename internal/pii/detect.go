@@ -0,0 +1,61 @@
+package pii
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// detector replaces the personal data it recognises inside a string.
+type detector func(string) string
+
+var detectorsByName = map[string]detector{
+	"email": maskEmails,
+	"phone": maskPhones,
+	"ip":    maskIPs,
+}
+
+// detectorOrder runs emails before IPs before phones regardless of the
+// configured order, so an IPv4 address is never taken for a phone number.
+var detectorOrder = []string{"email", "ip", "phone"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// phoneCandidate finds digit runs with common separators; maskPhones
+	// then keeps only those with an E.164-like digit count, which leaves
+	// dates and short numbers alone.
+	phoneCandidate = regexp.MustCompile(`(?:\+|\()?\d[\d\s().\-]{6,}\d`)
+
+	// ipCandidate finds tokens that may be IPv4 or IPv6 addresses; maskIPs
+	// confirms them with net.ParseIP so times like 12:30:45 are kept.
+	ipCandidate = regexp.MustCompile(`[0-9A-Fa-f]*[:.][0-9A-Fa-f:.]*[0-9A-Fa-f]`)
+)
+
+func maskEmails(s string) string {
+	return emailPattern.ReplaceAllString(s, "[email]")
+}
+
+func maskPhones(s string) string {
+	return phoneCandidate.ReplaceAllStringFunc(s, func(match string) string {
+		digits := 0
+		for _, r := range match {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits < 9 || digits > 15 || net.ParseIP(strings.TrimSpace(match)) != nil {
+			return match
+		}
+		return "[phone]"
+	})
+}
+
+func maskIPs(s string) string {
+	return ipCandidate.ReplaceAllStringFunc(s, func(match string) string {
+		if !strings.ContainsAny(match, ".:") || net.ParseIP(match) == nil {
+			return match
+		}
+		return "[ip]"
+	})
+}
@@ -0,0 +1,308 @@
+package pii
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"event-metrics-service/internal/model"
+)
+
+// Redacted replaces metadata values of masked keys.
+const Redacted = "[redacted]"
+
+// pseudonymHexLen is how many hex characters of the HMAC a pseudonym keeps
+// (128 bits, far beyond any realistic user count).
+const pseudonymHexLen = 32
+
+// Config is the versioned transform configuration loaded from PII_CONFIG_FILE.
+// Bump Version on every change so logs and stored data can be matched to the
+// transforms that produced them.
+type Config struct {
+	Version  int            `json:"version"`
+	UserID   UserIDConfig   `json:"user_id"`
+	Metadata MetadataConfig `json:"metadata"`
+}
+
+// UserIDConfig controls pseudonymization of user_id.
+type UserIDConfig struct {
+	Pseudonymize bool `json:"pseudonymize"`
+
+	// KeyID names the HMAC key in PII_HMAC_KEYS used for new events. It is
+	// part of every pseudonym, so rotating it is visible in stored data.
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// MetadataConfig lists metadata scrubbing rules. Key patterns are regular
+// expressions matched against keys at any nesting depth.
+type MetadataConfig struct {
+	DropKeys []string `json:"drop_keys,omitempty"`
+	MaskKeys []string `json:"mask_keys,omitempty"`
+
+	// MaskValues names built-in detectors (email, phone, ip) whose matches
+	// are replaced inside every string value.
+	MaskValues []string `json:"mask_values,omitempty"`
+
+	// MaxValueLength truncates longer string values to this many characters;
+	// zero disables truncation.
+	MaxValueLength int `json:"max_value_length,omitempty"`
+}
+
+// Transformer applies the configured transforms to built events.
+type Transformer struct {
+	version     int
+	keyID       string
+	keys        map[string][]byte
+	dropKeys    []*regexp.Regexp
+	maskKeys    []*regexp.Regexp
+	detectors   []detector
+	maxValueLen int
+}
+
+// Load reads the transform configuration from path and the HMAC keys from
+// rawKeys ("key_id=base64secret,..."). An empty path disables all transforms
+// and returns nil.
+func Load(path, rawKeys string) (*Transformer, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pii config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		return nil, fmt.Errorf("parse pii config: %w", err)
+	}
+
+	keys, err := ParseKeys(rawKeys)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg, keys)
+}
+
+// ParseKeys parses "key_id=base64secret" pairs separated by commas.
+func ParseKeys(raw string) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, secret, ok := strings.Cut(pair, "=")
+		if !ok || id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid pii key %q, expected key_id=base64secret", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pii key %s: %w", id, err)
+		}
+		if len(key) < 16 {
+			return nil, fmt.Errorf("pii key %s must be at least 16 bytes", id)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// New validates cfg and builds a Transformer.
+func New(cfg Config, keys map[string][]byte) (*Transformer, error) {
+	if cfg.Version <= 0 {
+		return nil, fmt.Errorf("pii config version must be positive")
+	}
+
+	if cfg.UserID.Pseudonymize {
+		if _, ok := keys[cfg.UserID.KeyID]; !ok {
+			return nil, fmt.Errorf("pii key %q is not configured", cfg.UserID.KeyID)
+		}
+	}
+
+	if cfg.Metadata.MaxValueLength < 0 {
+		return nil, fmt.Errorf("pii max_value_length must not be negative")
+	}
+
+	dropKeys, err := compileAll(cfg.Metadata.DropKeys)
+	if err != nil {
+		return nil, err
+	}
+	maskKeys, err := compileAll(cfg.Metadata.MaskKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := map[string]bool{}
+	for _, name := range cfg.Metadata.MaskValues {
+		if _, ok := detectorsByName[name]; !ok {
+			return nil, fmt.Errorf("unknown pii value detector %q, expected email, phone or ip", name)
+		}
+		enabled[name] = true
+	}
+	var detectors []detector
+	for _, name := range detectorOrder {
+		if enabled[name] {
+			detectors = append(detectors, detectorsByName[name])
+		}
+	}
+
+	t := &Transformer{
+		version:     cfg.Version,
+		keys:        keys,
+		dropKeys:    dropKeys,
+		maskKeys:    maskKeys,
+		detectors:   detectors,
+		maxValueLen: cfg.Metadata.MaxValueLength,
+	}
+	if cfg.UserID.Pseudonymize {
+		t.keyID = cfg.UserID.KeyID
+	}
+	return t, nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pii key pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Version returns the configuration version.
+func (t *Transformer) Version() int {
+	return t.version
+}
+
+// Apply returns the event with user_id pseudonymized and metadata scrubbed.
+// The caller's metadata map is not modified.
+func (t *Transformer) Apply(event model.Event) model.Event {
+	event.UserID = t.UserID(event.UserID)
+	if event.Metadata != nil {
+		event.Metadata = t.scrubMap(event.Metadata)
+	}
+	return event
+}
+
+// UserID returns the stored form of a user ID: "<key_id>:<hmac>" when
+// pseudonymization is enabled, the ID itself otherwise. IDs that already are
+// pseudonyms of a configured key are returned unchanged, so lookups accept
+// both forms.
+func (t *Transformer) UserID(userID string) string {
+	if t == nil || t.keyID == "" || t.isPseudonym(userID) {
+		return userID
+	}
+	return t.pseudonym(t.keyID, userID)
+}
+
+// UserIDs returns every stored form a user ID may have: the raw ID and its
+// pseudonym under each configured key. Erasure uses it to reach events
+// written before a key rotation.
+func (t *Transformer) UserIDs(userID string) []string {
+	if t == nil || t.isPseudonym(userID) {
+		return []string{userID}
+	}
+
+	keyIDs := make([]string, 0, len(t.keys))
+	for id := range t.keys {
+		keyIDs = append(keyIDs, id)
+	}
+	sort.Strings(keyIDs)
+
+	ids := []string{userID}
+	for _, id := range keyIDs {
+		ids = append(ids, t.pseudonym(id, userID))
+	}
+	return ids
+}
+
+func (t *Transformer) pseudonym(keyID, userID string) string {
+	mac := hmac.New(sha256.New, t.keys[keyID])
+	mac.Write([]byte(userID))
+	return keyID + ":" + hex.EncodeToString(mac.Sum(nil))[:pseudonymHexLen]
+}
+
+func (t *Transformer) isPseudonym(userID string) bool {
+	keyID, digest, ok := strings.Cut(userID, ":")
+	if !ok || len(digest) != pseudonymHexLen {
+		return false
+	}
+	if _, known := t.keys[keyID]; !known {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+func (t *Transformer) scrubMap(in map[string]any) map[string]any {
+	out := make(map[string]any, len(in))
+	for key, value := range in {
+		switch {
+		case matchesAny(t.dropKeys, key):
+			continue
+		case matchesAny(t.maskKeys, key):
+			out[key] = Redacted
+		default:
+			out[key] = t.scrubValue(value)
+		}
+	}
+	return out
+}
+
+func (t *Transformer) scrubValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return t.scrubMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = t.scrubValue(item)
+		}
+		return out
+	case string:
+		for _, d := range t.detectors {
+			v = d(v)
+		}
+		return truncate(v, t.maxValueLen)
+	default:
+		return value
+	}
+}
+
+func matchesAny(patterns []*regexp.Regexp, key string) bool {
+	for _, re := range patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate cuts s to max characters without splitting a UTF-8 sequence.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+
+	n := 0
+	for i := range s {
+		if n == max {
+			return s[:i]
+		}
+		n++
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package pii
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	keyOne = []byte("0123456789abcdef0123456789abcdef")
+	keyTwo = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func newTransformer(t *testing.T, cfg Config) *Transformer {
+	t.Helper()
+	tr, err := New(cfg, map[string][]byte{"k1": keyOne, "k2": keyTwo})
+	require.NoError(t, err)
+	return tr
+}
+
+func TestApply_PseudonymizesUserIDDeterministically(t *testing.T) {
+	tr := newTransformer(t, Config{Version: 1, UserID: UserIDConfig{Pseudonymize: true, KeyID: "k2"}})
+
+	first := tr.Apply(model.Event{UserID: "alice"})
+	second := tr.Apply(model.Event{UserID: "alice"})
+	other := tr.Apply(model.Event{UserID: "bob"})
+
+	require.True(t, strings.HasPrefix(first.UserID, "k2:"))
+	require.Len(t, first.UserID, len("k2:")+pseudonymHexLen)
+	require.Equal(t, first.UserID, second.UserID)
+	require.NotEqual(t, first.UserID, other.UserID)
+}
+
+func TestUserID_PassesPseudonymsThrough(t *testing.T) {
+	tr := newTransformer(t, Config{Version: 1, UserID: UserIDConfig{Pseudonymize: true, KeyID: "k2"}})
+	old := tr.pseudonym("k1", "alice")
+
+	require.Equal(t, old, tr.UserID(old))
+	require.Equal(t, tr.pseudonym("k2", "alice"), tr.UserID("alice"))
+}
+
+func TestUserIDs_CoversEveryKey(t *testing.T) {
+	tr := newTransformer(t, Config{Version: 2, UserID: UserIDConfig{Pseudonymize: true, KeyID: "k2"}})
+
+	require.Equal(t, []string{"alice", tr.pseudonym("k1", "alice"), tr.pseudonym("k2", "alice")}, tr.UserIDs("alice"))
+
+	var nilTransformer *Transformer
+	require.Equal(t, []string{"alice"}, nilTransformer.UserIDs("alice"))
+	require.Equal(t, "alice", nilTransformer.UserID("alice"))
+}
+
+func TestApply_ScrubsMetadata(t *testing.T) {
+	tr := newTransformer(t, Config{
+		Version: 1,
+		Metadata: MetadataConfig{
+			DropKeys:       []string{`^password$`},
+			MaskKeys:       []string{`(?i)email`},
+			MaskValues:     []string{"phone", "ip", "email"},
+			MaxValueLength: 8,
+		},
+	})
+	metadata := map[string]any{
+		"password": "hunter2",
+		"Email":    "a@example.com",
+		"note":     "mail x@y.io",
+		"contact":  map[string]any{"password": "x", "phone": "+90 555 123 45 67"},
+		"ips":      []any{"10.0.0.1", "2001:db8::1", "12:30:45"},
+		"day":      "2025-03-01",
+		"price":    9.99,
+		"long":     "ğğğğğğğğğğ",
+	}
+
+	event := tr.Apply(model.Event{UserID: "alice", Metadata: metadata})
+
+	require.Equal(t, map[string]any{
+		"Email":   Redacted,
+		"note":    "mail [em",
+		"contact": map[string]any{"phone": "[phone]"},
+		"ips":     []any{"[ip]", "[ip]", "12:30:45"},
+		"day":     "2025-03-",
+		"price":   9.99,
+		"long":    "ğğğğğğğğ",
+	}, event.Metadata)
+	require.Equal(t, "alice", event.UserID)
+	require.Equal(t, "hunter2", metadata["password"], "caller's map must not change")
+}
+
+func TestMaskPhones_KeepsDatesAndShortNumbers(t *testing.T) {
+	require.Equal(t, "2025-03-01", maskPhones("2025-03-01"))
+	require.Equal(t, "order 1234567", maskPhones("order 1234567"))
+	require.Equal(t, "call [phone] now", maskPhones("call (555) 123-4567 now"))
+}
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	keys := map[string][]byte{"k1": keyOne}
+
+	_, err := New(Config{}, keys)
+	require.ErrorContains(t, err, "version")
+
+	_, err = New(Config{Version: 1, UserID: UserIDConfig{Pseudonymize: true, KeyID: "missing"}}, keys)
+	require.ErrorContains(t, err, `pii key "missing" is not configured`)
+
+	_, err = New(Config{Version: 1, Metadata: MetadataConfig{DropKeys: []string{"("}}}, keys)
+	require.ErrorContains(t, err, "invalid pii key pattern")
+
+	_, err = New(Config{Version: 1, Metadata: MetadataConfig{MaskValues: []string{"ssn"}}}, keys)
+	require.ErrorContains(t, err, "unknown pii value detector")
+}
+
+func TestLoad(t *testing.T) {
+	tr, err := Load("", "")
+	require.NoError(t, err)
+	require.Nil(t, tr)
+
+	path := filepath.Join(t.TempDir(), "pii.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":3,"user_id":{"pseudonymize":true,"key_id":"k1"}}`), 0o600))
+
+	tr, err = Load(path, "k1="+base64.StdEncoding.EncodeToString(keyOne))
+	require.NoError(t, err)
+	require.Equal(t, 3, tr.Version())
+
+	_, err = Load(path, "k1=c2hvcnQ=")
+	require.ErrorContains(t, err, "at least 16 bytes")
+}
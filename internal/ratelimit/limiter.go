@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many Allow calls pass between sweeps of idle buckets.
+const sweepEvery = 4096
+
+// Limit configures a token bucket: Rate tokens per second refill it up to
+// Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// NewLimit builds a limit of rate requests per second. A zero burst allows
+// one second's worth of requests at once.
+func NewLimit(rate float64, burst int) Limit {
+	if rate <= 0 {
+		return Limit{}
+	}
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return Limit{Rate: rate, Burst: burst}
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Request names a bucket and the limit it enforces.
+type Request struct {
+	Key   string
+	Limit Limit
+}
+
+// Decision is the outcome of Allow for its most restrictive bucket.
+type Decision struct {
+	Allowed bool
+	Key     string
+	Limit   int
+	// Remaining is how many whole tokens are left.
+	Remaining int
+	// RetryAfter is how long until a token is available when not allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// Limiter holds in-process token buckets keyed by client.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+// NewLimiter builds an empty Limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allow takes one token from each bucket in reqs, or from none of them when
+// any is empty. Requests with a disabled limit are skipped; when none is
+// left the request is allowed and the decision has a zero Limit.
+func (l *Limiter) Allow(reqs ...Request) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweepLocked(now)
+	}
+
+	allowed := Decision{Allowed: true}
+	var denied *Decision
+	var taken []*bucket
+	for _, req := range reqs {
+		if !req.Limit.Enabled() {
+			continue
+		}
+
+		b := l.refillLocked(req, now)
+		if b.tokens < 1 {
+			wait := time.Duration((1 - b.tokens) / req.Limit.Rate * float64(time.Second))
+			if denied == nil || wait > denied.RetryAfter {
+				denied = &Decision{
+					Key:        req.Key,
+					Limit:      req.Limit.Burst,
+					RetryAfter: wait,
+					Reset:      untilFull(b.tokens, req.Limit),
+				}
+			}
+			continue
+		}
+
+		taken = append(taken, b)
+		remaining := int(b.tokens - 1)
+		if allowed.Limit == 0 || remaining < allowed.Remaining {
+			allowed = Decision{
+				Allowed:   true,
+				Key:       req.Key,
+				Limit:     req.Limit.Burst,
+				Remaining: remaining,
+				Reset:     untilFull(b.tokens-1, req.Limit),
+			}
+		}
+	}
+
+	if denied != nil {
+		return *denied
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	return allowed
+}
+
+func (l *Limiter) refillLocked(req Request, now time.Time) *bucket {
+	b, ok := l.buckets[req.Key]
+	if !ok {
+		b = &bucket{tokens: float64(req.Limit.Burst), updated: now, limit: req.Limit}
+		l.buckets[req.Key] = b
+		return b
+	}
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * req.Limit.Rate
+	}
+	// Also clamps buckets whose limit was lowered since the last request.
+	b.tokens = math.Min(b.tokens, float64(req.Limit.Burst))
+	b.updated = now
+	b.limit = req.Limit
+	return b
+}
+
+// sweepLocked drops buckets that have refilled completely since their last
+// request; a fresh bucket behaves the same.
+func (l *Limiter) sweepLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= untilFull(b.tokens, b.limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func untilFull(tokens float64, limit Limit) time.Duration {
+	missing := float64(limit.Burst) - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / limit.Rate * float64(time.Second))
+}
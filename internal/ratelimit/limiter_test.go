@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_RefillsAtRate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 2}
+
+	require.True(t, limiter.Allow(Request{Key: "a", Limit: limit}).Allowed)
+	second := limiter.Allow(Request{Key: "a", Limit: limit})
+	require.True(t, second.Allowed)
+	require.Equal(t, 0, second.Remaining)
+	require.Equal(t, time.Second, second.Reset)
+
+	denied := limiter.Allow(Request{Key: "a", Limit: limit})
+	require.False(t, denied.Allowed)
+	require.Equal(t, "a", denied.Key)
+	require.Equal(t, 500*time.Millisecond, denied.RetryAfter)
+
+	require.True(t, limiter.Allow(Request{Key: "b", Limit: limit}).Allowed, "buckets are per key")
+
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, limiter.Allow(Request{Key: "a", Limit: limit}).Allowed)
+}
+
+func TestLimiter_TakesFromAllBucketsOrNone(t *testing.T) {
+	limiter := NewLimiter()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	client := Request{Key: "client", Limit: Limit{Rate: 1, Burst: 5}}
+	tenant := Request{Key: "tenant", Limit: Limit{Rate: 1, Burst: 1}}
+
+	allowed := limiter.Allow(client, tenant)
+	require.True(t, allowed.Allowed)
+	require.Equal(t, "tenant", allowed.Key, "the most restrictive bucket is reported")
+
+	require.False(t, limiter.Allow(client, tenant).Allowed)
+	require.Equal(t, 3, limiter.Allow(client).Remaining, "the denied request took no client token")
+}
+
+func TestLimiter_DisabledLimitsAllow(t *testing.T) {
+	decision := NewLimiter().Allow(Request{Key: "a"})
+	require.True(t, decision.Allowed)
+	require.Zero(t, decision.Limit)
+}
+
+func TestNewLimit_DefaultBurst(t *testing.T) {
+	require.Equal(t, Limit{Rate: 0.5, Burst: 1}, NewLimit(0.5, 0))
+	require.Equal(t, Limit{Rate: 2.5, Burst: 3}, NewLimit(2.5, 0))
+	require.Equal(t, Limit{Rate: 10, Burst: 50}, NewLimit(10, 50))
+	require.False(t, NewLimit(0, 10).Enabled())
+}
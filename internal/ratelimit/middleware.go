@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response headers describing the most restrictive limit of a request.
+const (
+	HeaderLimit     = "X-RateLimit-Limit"
+	HeaderRemaining = "X-RateLimit-Remaining"
+	HeaderReset     = "X-RateLimit-Reset"
+
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset"
+)
+
+// Route selects the limits a middleware enforces.
+type Route int
+
+const (
+	// Ingest limits event ingestion and counts events against daily quotas.
+	Ingest Route = iota
+	// Query limits metrics, user and export queries.
+	Query
+)
+
+// String names the route in bucket keys.
+func (r Route) String() string {
+	if r == Query {
+		return "query"
+	}
+	return "ingest"
+}
+
+// Policy holds the per-client defaults. Zero values disable a limit.
+type Policy struct {
+	Ingest      Limit
+	Query       Limit
+	DailyEvents int64
+}
+
+// Enforcer applies rate limits and daily event quotas to each API key or
+// token subject, or to each client IP when the request carries neither,
+// and to each tenant that configures limits.
+type Enforcer struct {
+	policy  Policy
+	tenants *tenant.Registry
+	limiter *Limiter
+	quotas  *Quotas
+}
+
+// NewEnforcer builds an Enforcer with empty buckets and counters.
+func NewEnforcer(policy Policy, tenants *tenant.Registry) *Enforcer {
+	return &Enforcer{policy: policy, tenants: tenants, limiter: NewLimiter(), quotas: NewQuotas()}
+}
+
+// Usage returns today's per-client counters.
+func (e *Enforcer) Usage() Usage {
+	return e.quotas.Usage()
+}
+
+// Middleware enforces the limits of route. It must run after the tenant
+// middleware. Refused requests are answered with 429 and Retry-After.
+func (e *Enforcer) Middleware(route Route) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client, clientLimits := e.client(c)
+		tenantKey, tenantLimits := e.tenant(c)
+
+		// Each route has its own buckets so its limit does not consume or
+		// reshape the other route's. Quotas and usage stay per client.
+		prefix := route.String() + ":"
+		decision := e.limiter.Allow(
+			Request{Key: prefix + client, Limit: routeLimit(route, e.policy, clientLimits)},
+			Request{Key: prefix + tenantKey, Limit: routeLimit(route, Policy{}, tenantLimits)},
+		)
+		if decision.Limit > 0 {
+			c.Set(HeaderLimit, strconv.Itoa(decision.Limit))
+			c.Set(HeaderRemaining, strconv.Itoa(decision.Remaining))
+			c.Set(HeaderReset, seconds(decision.Reset))
+		}
+		if !decision.Allowed {
+			e.quotas.Throttled(strings.TrimPrefix(decision.Key, prefix))
+			c.Set(fiber.HeaderRetryAfter, seconds(decision.RetryAfter))
+			return fiber.NewError(fiber.StatusTooManyRequests, "rate limit exceeded")
+		}
+
+		if route != Ingest {
+			return c.Next()
+		}
+
+		reservations := []QuotaRequest{
+			{Key: client, Quota: dailyEvents(e.policy.DailyEvents, clientLimits)},
+			{Key: tenantKey, Quota: dailyEvents(0, tenantLimits)},
+		}
+		quota := e.quotas.Reserve(reservations...)
+		if quota.Limit > 0 {
+			c.Set(HeaderQuotaLimit, strconv.FormatInt(quota.Limit, 10))
+			c.Set(HeaderQuotaRemaining, strconv.FormatInt(quota.Remaining, 10))
+			c.Set(HeaderQuotaReset, seconds(quota.Reset))
+		}
+		if !quota.Allowed {
+			c.Set(fiber.HeaderRetryAfter, seconds(quota.Reset))
+			return fiber.NewError(fiber.StatusTooManyRequests, "daily event quota exceeded")
+		}
+
+		err := c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusMultipleChoices {
+			e.quotas.Release(reservations...)
+		}
+		return err
+	}
+}
+
+// client identifies the caller by API key or token subject, falling back
+// to its IP.
+func (e *Enforcer) client(c *fiber.Ctx) (string, *model.ClientLimits) {
+	if key, ok := auth.KeyFromContext(c); ok {
+		return "key:" + key.ID, key.Limits
+	}
+	if principal, ok := auth.PrincipalFromContext(c); ok {
+		return "sub:" + principal.Subject, nil
+	}
+	return "ip:" + c.IP(), nil
+}
+
+func (e *Enforcer) tenant(c *fiber.Ctx) (string, *model.ClientLimits) {
+	tenantID := tenant.FromContext(c)
+	return "tenant:" + tenantID, e.tenants.Limits(tenantID)
+}
+
+// routeLimit returns the limit of route from overrides, falling back to
+// defaults field by field.
+func routeLimit(route Route, defaults Policy, overrides *model.ClientLimits) Limit {
+	limit := defaults.Ingest
+	if route == Query {
+		limit = defaults.Query
+	}
+	if overrides == nil {
+		return limit
+	}
+
+	rate, burst := overrides.IngestRate, overrides.IngestBurst
+	if route == Query {
+		rate, burst = overrides.QueryRate, overrides.QueryBurst
+	}
+	if rate == 0 {
+		if burst == 0 {
+			return limit
+		}
+		rate = limit.Rate
+	}
+	return NewLimit(rate, burst)
+}
+
+func dailyEvents(fallback int64, overrides *model.ClientLimits) int64 {
+	if overrides != nil && overrides.DailyEvents > 0 {
+		return overrides.DailyEvents
+	}
+	return fallback
+}
+
+// seconds renders d as whole seconds, rounded up so clients never retry
+// too early.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
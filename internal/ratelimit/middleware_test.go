@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/tenant"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp(t *testing.T, enforcer *Enforcer, store *auth.KeyStore, tenants *tenant.Registry, status int) *fiber.App {
+	t.Helper()
+
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error { return c.SendStatus(status) }
+	app.Post("/events", auth.RequireScope(store, auth.ScopeWrite), tenant.Middleware(tenants), enforcer.Middleware(Ingest), handler)
+	app.Get("/metrics", tenant.Middleware(tenants), enforcer.Middleware(Query), handler)
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method, path, token string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set(auth.HeaderAPIKey, token)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestMiddleware_RateLimitPerKey(t *testing.T) {
+	store := auth.NewKeyStore("")
+	_, token, err := store.Create("web-sdk", []auth.Scope{auth.ScopeWrite}, "")
+	require.NoError(t, err)
+	_, otherToken, err := store.Create("backend", []auth.Scope{auth.ScopeWrite}, "")
+	require.NoError(t, err)
+
+	enforcer := NewEnforcer(Policy{Ingest: Limit{Rate: 0.001, Burst: 1}}, nil)
+	app := newTestApp(t, enforcer, store, nil, fiber.StatusAccepted)
+
+	resp := send(t, app, http.MethodPost, "/events", token)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get(HeaderLimit))
+	require.Equal(t, "0", resp.Header.Get(HeaderRemaining))
+
+	resp = send(t, app, http.MethodPost, "/events", token)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "1000", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	resp = send(t, app, http.MethodPost, "/events", otherToken)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestMiddleware_KeyOverridesAndQuota(t *testing.T) {
+	store := auth.NewKeyStore("")
+	key, token, err := store.Create("web-sdk", []auth.Scope{auth.ScopeWrite}, "")
+	require.NoError(t, err)
+	_, err = store.SetLimits(key.ID, &model.ClientLimits{DailyEvents: 2})
+	require.NoError(t, err)
+
+	enforcer := NewEnforcer(Policy{DailyEvents: 100}, nil)
+	app := newTestApp(t, enforcer, store, nil, fiber.StatusAccepted)
+
+	resp := send(t, app, http.MethodPost, "/events", token)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get(HeaderQuotaLimit))
+	require.Equal(t, "1", resp.Header.Get(HeaderQuotaRemaining))
+
+	require.Equal(t, http.StatusAccepted, send(t, app, http.MethodPost, "/events", token).StatusCode)
+
+	resp = send(t, app, http.MethodPost, "/events", token)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+
+	usage := enforcer.Usage()
+	require.Equal(t, ClientUsage{Key: "key:" + key.ID, Events: 2, Quota: 2, QuotaRejected: 1}, usage.Clients[0])
+}
+
+func TestMiddleware_RejectedEventsDoNotCount(t *testing.T) {
+	store := auth.NewKeyStore("")
+	_, token, err := store.Create("web-sdk", []auth.Scope{auth.ScopeWrite}, "")
+	require.NoError(t, err)
+
+	enforcer := NewEnforcer(Policy{DailyEvents: 1}, nil)
+	app := newTestApp(t, enforcer, store, nil, fiber.StatusBadRequest)
+
+	require.Equal(t, http.StatusBadRequest, send(t, app, http.MethodPost, "/events", token).StatusCode)
+	require.Equal(t, http.StatusBadRequest, send(t, app, http.MethodPost, "/events", token).StatusCode)
+	require.Zero(t, enforcer.Usage().Clients[0].Events)
+}
+
+func TestMiddleware_TenantLimitsAndQueryRoute(t *testing.T) {
+	tenants, err := tenant.NewRegistry([]tenant.Config{{ID: "acme", Limits: &model.ClientLimits{QueryRate: 0.001, QueryBurst: 1}}})
+	require.NoError(t, err)
+
+	enforcer := NewEnforcer(Policy{Query: Limit{Rate: 100, Burst: 100}}, tenants)
+	app := newTestApp(t, enforcer, auth.NewKeyStore(""), tenants, fiber.StatusOK)
+
+	query := func() *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set(tenant.HeaderTenantID, "acme")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp
+	}
+
+	require.Equal(t, http.StatusOK, query().StatusCode)
+	resp := query()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get(HeaderLimit), "the tenant bucket is the tighter one")
+
+	require.Equal(t, http.StatusOK, send(t, app, http.MethodGet, "/metrics", "").StatusCode, "other tenants are not affected")
+
+	usage := enforcer.Usage()
+	require.Equal(t, []ClientUsage{{Key: "tenant:acme", Throttled: 1}}, usage.Clients)
+}
+
+func TestMiddleware_RoutesHaveSeparateBuckets(t *testing.T) {
+	tenants, err := tenant.NewRegistry([]tenant.Config{{ID: "acme", Limits: &model.ClientLimits{IngestRate: 100, QueryRate: 0.001, QueryBurst: 1}}})
+	require.NoError(t, err)
+	enforcer := NewEnforcer(Policy{Ingest: Limit{Rate: 100, Burst: 100}, Query: Limit{Rate: 0.001, Burst: 1}}, tenants)
+
+	// Both routes see the same client IP and tenant.
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Post("/events", tenant.Middleware(tenants), enforcer.Middleware(Ingest), handler)
+	app.Get("/metrics", tenant.Middleware(tenants), enforcer.Middleware(Query), handler)
+	request := func(method, path string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(tenant.HeaderTenantID, "acme")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp
+	}
+
+	require.Equal(t, http.StatusOK, request(http.MethodGet, "/metrics").StatusCode)
+	require.Equal(t, http.StatusTooManyRequests, request(http.MethodGet, "/metrics").StatusCode)
+
+	for i := 0; i < 5; i++ {
+		resp := request(http.MethodPost, "/events")
+		require.Equal(t, http.StatusOK, resp.StatusCode, "the query limit must not affect ingest")
+		require.Equal(t, "100", resp.Header.Get(HeaderLimit))
+	}
+	require.Equal(t, http.StatusTooManyRequests, request(http.MethodGet, "/metrics").StatusCode)
+
+	// Usage stays keyed by client, not by route bucket.
+	usage := enforcer.Usage()
+	require.Equal(t, "ip:0.0.0.0", usage.Clients[0].Key)
+	require.Equal(t, int64(2), usage.Clients[0].Throttled)
+}
@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// QuotaRequest names a daily counter and its quota. A zero Quota counts
+// without limiting.
+type QuotaRequest struct {
+	Key   string
+	Quota int64
+}
+
+// QuotaDecision is the outcome of Reserve for its most restrictive quota.
+type QuotaDecision struct {
+	Allowed   bool
+	Key       string
+	Limit     int64
+	Remaining int64
+	// Reset is how long until the counters start over at UTC midnight.
+	Reset time.Duration
+}
+
+// ClientUsage holds one client's counters for the current day.
+type ClientUsage struct {
+	Key string `json:"key"`
+	// Events is how many events were accepted.
+	Events int64 `json:"events"`
+	Quota  int64 `json:"quota,omitempty"`
+	// QuotaRejected counts events refused because the quota was used up.
+	QuotaRejected int64 `json:"quota_rejected"`
+	// Throttled counts requests refused by a rate limit.
+	Throttled int64 `json:"throttled"`
+}
+
+// Usage is a snapshot of the counters of the current UTC day.
+type Usage struct {
+	Day     string        `json:"day"`
+	Clients []ClientUsage `json:"clients"`
+}
+
+// Quotas counts events and rejections per client and UTC day. Counters are
+// kept in process and start over at midnight.
+type Quotas struct {
+	mu      sync.Mutex
+	day     time.Time
+	clients map[string]*ClientUsage
+	now     func() time.Time
+}
+
+// NewQuotas builds empty counters.
+func NewQuotas() *Quotas {
+	return &Quotas{clients: map[string]*ClientUsage{}, now: time.Now}
+}
+
+// Reserve counts one event against each counter in reqs, or against none of
+// them when any quota is used up. Reservations for events that end up not
+// being accepted are returned with Release.
+func (q *Quotas) Reserve(reqs ...QuotaRequest) QuotaDecision {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	reset := q.rollLocked()
+	decision := QuotaDecision{Allowed: true, Reset: reset}
+	for _, req := range reqs {
+		usage := q.clientLocked(req.Key)
+		usage.Quota = req.Quota
+		if req.Quota <= 0 {
+			continue
+		}
+
+		remaining := req.Quota - usage.Events
+		if remaining <= 0 {
+			usage.QuotaRejected++
+			return QuotaDecision{Key: req.Key, Limit: req.Quota, Reset: reset}
+		}
+		if decision.Limit == 0 || remaining-1 < decision.Remaining {
+			decision = QuotaDecision{Allowed: true, Key: req.Key, Limit: req.Quota, Remaining: remaining - 1, Reset: reset}
+		}
+	}
+
+	for _, req := range reqs {
+		q.clients[req.Key].Events++
+	}
+	return decision
+}
+
+// Release returns events reserved earlier the same day.
+func (q *Quotas) Release(reqs ...QuotaRequest) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollLocked()
+	for _, req := range reqs {
+		if usage, ok := q.clients[req.Key]; ok && usage.Events > 0 {
+			usage.Events--
+		}
+	}
+}
+
+// Throttled counts a request refused by a rate limit.
+func (q *Quotas) Throttled(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollLocked()
+	q.clientLocked(key).Throttled++
+}
+
+// Usage returns today's counters sorted by client key.
+func (q *Quotas) Usage() Usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollLocked()
+	clients := make([]ClientUsage, 0, len(q.clients))
+	for _, usage := range q.clients {
+		clients = append(clients, *usage)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Key < clients[j].Key })
+	return Usage{Day: q.day.Format(time.DateOnly), Clients: clients}
+}
+
+// rollLocked starts new counters when the UTC day changed and returns the
+// time left until the next change.
+func (q *Quotas) rollLocked() time.Duration {
+	now := q.now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	if !today.Equal(q.day) {
+		q.day = today
+		q.clients = map[string]*ClientUsage{}
+	}
+	return today.Add(24 * time.Hour).Sub(now)
+}
+
+func (q *Quotas) clientLocked(key string) *ClientUsage {
+	usage, ok := q.clients[key]
+	if !ok {
+		usage = &ClientUsage{Key: key}
+		q.clients[key] = usage
+	}
+	return usage
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotas_ReserveAndRelease(t *testing.T) {
+	now := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	quotas := NewQuotas()
+	quotas.now = func() time.Time { return now }
+	client := QuotaRequest{Key: "key:a", Quota: 2}
+	tenant := QuotaRequest{Key: "tenant:default"}
+
+	first := quotas.Reserve(client, tenant)
+	require.True(t, first.Allowed)
+	require.Equal(t, int64(1), first.Remaining)
+	require.Equal(t, time.Hour, first.Reset)
+
+	require.True(t, quotas.Reserve(client, tenant).Allowed)
+	quotas.Release(client, tenant)
+	require.True(t, quotas.Reserve(client, tenant).Allowed, "released events do not count")
+
+	denied := quotas.Reserve(client, tenant)
+	require.False(t, denied.Allowed)
+	require.Equal(t, "key:a", denied.Key)
+
+	require.Equal(t, Usage{
+		Day: "2025-01-01",
+		Clients: []ClientUsage{
+			{Key: "key:a", Events: 2, Quota: 2, QuotaRejected: 1},
+			{Key: "tenant:default", Events: 2},
+		},
+	}, quotas.Usage())
+}
+
+func TestQuotas_ResetAtMidnight(t *testing.T) {
+	now := time.Date(2025, 1, 1, 23, 59, 0, 0, time.UTC)
+	quotas := NewQuotas()
+	quotas.now = func() time.Time { return now }
+	client := QuotaRequest{Key: "ip:10.0.0.1", Quota: 1}
+
+	require.True(t, quotas.Reserve(client).Allowed)
+	require.False(t, quotas.Reserve(client).Allowed)
+	quotas.Throttled("ip:10.0.0.1")
+
+	now = now.Add(time.Minute)
+	require.True(t, quotas.Reserve(client).Allowed)
+	require.Equal(t, Usage{
+		Day:     "2025-01-02",
+		Clients: []ClientUsage{{Key: "ip:10.0.0.1", Events: 1, Quota: 1}},
+	}, quotas.Usage())
+}
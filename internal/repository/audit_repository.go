@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// AuditRepository stores the access audit log.
+type AuditRepository interface {
+	// InsertAudit appends a batch of audit records.
+	InsertAudit(ctx context.Context, records []model.AuditRecord) error
+
+	// SearchAudit returns the records matching query, newest first.
+	SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error)
+}
+
+type auditRepository struct {
+	conn clickhouse.Conn
+}
+
+// NewAuditRepository creates an AuditRepository backed by ClickHouse.
+func NewAuditRepository(conn clickhouse.Conn) AuditRepository {
+	return &auditRepository{conn: conn}
+}
+
+const (
+	insertAuditQuery = `
+	INSERT INTO audit_log (at, principal, tenant_id, action, path, client_ip, filter, duration_ms, rows_read, status)
+`
+	selectAuditQuery = `SELECT at, principal, tenant_id, action, path, client_ip, filter, duration_ms, rows_read, status FROM audit_log`
+)
+
+func (r *auditRepository) InsertAudit(ctx context.Context, records []model.AuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	batch, err := r.conn.PrepareBatch(ctx, insertAuditQuery)
+	if err != nil {
+		return fmt.Errorf("prepare audit batch: %w", err)
+	}
+
+	for _, rec := range records {
+		err := batch.Append(rec.At, rec.Principal, rec.TenantID, rec.Action, rec.Path, rec.ClientIP,
+			rec.Filter, rec.DurationMs, rec.RowsRead, uint16(rec.Status))
+		if err != nil {
+			return fmt.Errorf("append audit batch: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("send audit batch: %w", err)
+	}
+	return nil
+}
+
+func (r *auditRepository) SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	where, args := auditConditions(query, func(int) string { return "?" })
+	// Compared as text so the bounds keep their milliseconds; see tsParam.
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			args[i] = tsArg(t)
+		}
+	}
+	sql := selectAuditQuery + where + fmt.Sprintf(" ORDER BY at DESC LIMIT %d", query.Limit)
+
+	rows, err := r.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	records := []model.AuditRecord{}
+	for rows.Next() {
+		var rec model.AuditRecord
+		var status uint16
+		if err := rows.Scan(&rec.At, &rec.Principal, &rec.TenantID, &rec.Action, &rec.Path, &rec.ClientIP,
+			&rec.Filter, &rec.DurationMs, &rec.RowsRead, &status); err != nil {
+			return nil, fmt.Errorf("scan audit log: %w", err)
+		}
+		rec.At = rec.At.UTC()
+		rec.Status = int(status)
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit log: %w", err)
+	}
+	return records, nil
+}
+
+// auditConditions builds the WHERE clause of an audit search. placeholder
+// renders the n-th (1-based) bind parameter of the SQL dialect.
+func auditConditions(query model.AuditQuery, placeholder func(n int) string) (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+placeholder(len(args)))
+	}
+
+	if query.TenantID != "" {
+		add("tenant_id = ", query.TenantID)
+	}
+	if query.Principal != "" {
+		add("principal = ", query.Principal)
+	}
+	if query.Action != "" {
+		add("action = ", query.Action)
+	}
+	if !query.From.IsZero() {
+		add("at >= ", query.From)
+	}
+	if !query.To.IsZero() {
+		add("at <= ", query.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
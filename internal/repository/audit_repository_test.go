@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockclickhousebatch"
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditRepositoryTestSuite struct {
+	suite.Suite
+
+	connMock *mockclickhouseconnection.Connection
+	repo     AuditRepository
+}
+
+func TestAuditRepository(t *testing.T) {
+	suite.Run(t, new(AuditRepositoryTestSuite))
+}
+
+func (s *AuditRepositoryTestSuite) SetupTest() {
+	s.connMock = &mockclickhouseconnection.Connection{}
+	s.repo = NewAuditRepository(s.connMock)
+}
+
+func (s *AuditRepositoryTestSuite) TearDownTest() {
+	s.connMock.AssertExpectations(s.T())
+}
+
+func (s *AuditRepositoryTestSuite) TestInsertAudit() {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	batch := &mockclickhousebatch.Batch{}
+	s.connMock.On("PrepareBatch", mock.Anything, insertAuditQuery).Return(batch, nil).Once()
+	batch.On("Append", at, "key:k1", "acme", "GET /metrics", "/metrics", "10.0.0.1", `{"event_name":"purchase"}`,
+		uint64(12), uint64(42), uint16(200)).Return(nil).Once()
+	batch.On("Send").Return(nil).Once()
+
+	err := s.repo.InsertAudit(context.Background(), []model.AuditRecord{{
+		At:         at,
+		Principal:  "key:k1",
+		TenantID:   "acme",
+		Action:     "GET /metrics",
+		Path:       "/metrics",
+		ClientIP:   "10.0.0.1",
+		Filter:     `{"event_name":"purchase"}`,
+		DurationMs: 12,
+		RowsRead:   42,
+		Status:     200,
+	}})
+
+	s.NoError(err)
+	batch.AssertExpectations(s.T())
+}
+
+func (s *AuditRepositoryTestSuite) TestInsertAudit_Empty() {
+	s.NoError(s.repo.InsertAudit(context.Background(), nil))
+	s.connMock.AssertNotCalled(s.T(), "PrepareBatch", mock.Anything, mock.Anything)
+}
+
+func (s *AuditRepositoryTestSuite) TestSearchAudit() {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 500_000_000, time.UTC)
+	at := from.Add(time.Hour)
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything,
+		selectAuditQuery+" WHERE tenant_id = ? AND action = ? AND at >= ? ORDER BY at DESC LIMIT 50",
+		[]any{"acme", "GET /metrics", "2025-03-01 00:00:00.500"}).
+		Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			*args.Get(0).(*time.Time) = at
+			*args.Get(1).(*string) = "sub:analyst"
+			*args.Get(2).(*string) = "acme"
+			*args.Get(3).(*string) = "GET /metrics"
+			*args.Get(4).(*string) = "/metrics"
+			*args.Get(7).(*uint64) = 8
+			*args.Get(8).(*uint64) = 1000
+			*args.Get(9).(*uint16) = 200
+		}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	records, err := s.repo.SearchAudit(context.Background(), model.AuditQuery{
+		TenantID: "acme",
+		Action:   "GET /metrics",
+		From:     from,
+		Limit:    50,
+	})
+
+	s.NoError(err)
+	s.Equal([]model.AuditRecord{{
+		At:         at,
+		Principal:  "sub:analyst",
+		TenantID:   "acme",
+		Action:     "GET /metrics",
+		Path:       "/metrics",
+		DurationMs: 8,
+		RowsRead:   1000,
+		Status:     200,
+	}}, records)
+	rows.AssertExpectations(s.T())
+}
+
+func TestMemoryAuditRepository_Search(t *testing.T) {
+	repo := NewMemoryAuditRepository()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	records := []model.AuditRecord{
+		{At: start, TenantID: "acme", Action: "GET /metrics"},
+		{At: start.Add(time.Minute), TenantID: "globex", Action: "GET /metrics"},
+		{At: start.Add(2 * time.Minute), TenantID: "acme", Action: "GET /metrics"},
+		{At: start.Add(3 * time.Minute), Action: "POST /admin/keys"},
+	}
+	require.NoError(t, repo.InsertAudit(context.Background(), records))
+
+	got, err := repo.SearchAudit(context.Background(), model.AuditQuery{TenantID: "acme", Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, []model.AuditRecord{records[2], records[0]}, got)
+
+	got, err = repo.SearchAudit(context.Background(), model.AuditQuery{From: start.Add(time.Minute), Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []model.AuditRecord{records[3], records[2]}, got)
+}
@@ -0,0 +1,133 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"event-metrics-service/internal/config"
+	"event-metrics-service/internal/db"
+	"event-metrics-service/internal/repository"
+	"event-metrics-service/internal/repository/repositorytest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance_Memory(t *testing.T) {
+	repositorytest.Run(t, repositorytest.Harness{
+		New: func(t *testing.T) repository.EventRepository {
+			return repository.NewMemoryEventRepository()
+		},
+		Late: func(t *testing.T, repo repository.EventRepository) repository.LateEventRepository {
+			return repository.NewMemoryLateEventRepository(repo)
+		},
+		Privacy: func(t *testing.T, repo repository.EventRepository) repository.PrivacyRepository {
+			return repository.NewMemoryPrivacyRepository(repo)
+		},
+	})
+}
+
+// TestConformance_ClickHouse runs the suite against ClickHouse when either
+// CLICKHOUSE_TEST_ADDRS points at a running server or CLICKHOUSE_TEST_BINARY
+// names a clickhouse binary to start a throwaway server from. It uses (and
+// truncates) the database named by CLICKHOUSE_TEST_DB, default
+// events_conformance.
+func TestConformance_ClickHouse(t *testing.T) {
+	addrs := os.Getenv("CLICKHOUSE_TEST_ADDRS")
+	if addrs == "" {
+		binary := os.Getenv("CLICKHOUSE_TEST_BINARY")
+		if binary == "" {
+			t.Skip("neither CLICKHOUSE_TEST_ADDRS nor CLICKHOUSE_TEST_BINARY is set")
+		}
+		addrs = repositorytest.StartClickHouse(t, binary)
+	}
+
+	database := envOr("CLICKHOUSE_TEST_DB", "events_conformance")
+	cfg := &config.Config{
+		ClickHouseAddrs:   strings.Split(addrs, ","),
+		ClickHouseUser:    envOr("CLICKHOUSE_TEST_USER", "default"),
+		ClickHousePass:    os.Getenv("CLICKHOUSE_TEST_PASSWORD"),
+		ClickHouseDB:      "default",
+		DBMaxConns:        4,
+		DBMinConns:        1,
+		HealthPingRetries: 5,
+	}
+	ctx := context.Background()
+
+	admin, err := db.NewConnection(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, admin.Exec(ctx, "CREATE DATABASE IF NOT EXISTS "+database))
+	admin.Close()
+
+	cfg.ClickHouseDB = database
+	conn, err := db.NewConnection(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, db.RunMigrations(ctx, conn, db.SchemaOptions{}))
+
+	repo := repository.NewEventRepository(conn, repository.QueryLimits{}, nil)
+	late := repository.NewLateEventRepository(conn)
+	privacy := repository.NewPrivacyRepository(conn, repository.DeleteModeMutation)
+	repositorytest.Run(t, repositorytest.Harness{
+		New: func(t *testing.T) repository.EventRepository {
+			require.NoError(t, conn.Exec(ctx, "TRUNCATE TABLE events"))
+			require.NoError(t, conn.Exec(ctx, "TRUNCATE TABLE late_events"))
+			return repo
+		},
+		Late: func(t *testing.T, _ repository.EventRepository) repository.LateEventRepository {
+			return late
+		},
+		Privacy: func(t *testing.T, _ repository.EventRepository) repository.PrivacyRepository {
+			return privacy
+		},
+		// ReplacingMergeTree only collapses duplicates when parts merge.
+		Settle: func(t *testing.T) {
+			require.NoError(t, conn.Exec(ctx, "OPTIMIZE TABLE events FINAL"))
+		},
+	})
+}
+
+// TestConformance_Postgres runs the suite against the PostgreSQL backend when
+// POSTGRES_TEST_DSN is set. The events table in that database is truncated.
+func TestConformance_Postgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.NewPostgresPool(ctx, &config.Config{
+		PostgresDSN:       dsn,
+		DBMaxConns:        4,
+		DBMinConns:        1,
+		HealthPingRetries: 5,
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	require.NoError(t, db.EnsurePostgresSchema(ctx, pool))
+
+	repo := repository.NewPostgresEventRepository(pool)
+	late := repository.NewPostgresLateEventRepository(pool)
+	privacy := repository.NewPostgresPrivacyRepository(pool)
+	repositorytest.Run(t, repositorytest.Harness{
+		New: func(t *testing.T) repository.EventRepository {
+			_, err := pool.Exec(ctx, "TRUNCATE TABLE events, late_events")
+			require.NoError(t, err)
+			return repo
+		},
+		Late: func(t *testing.T, _ repository.EventRepository) repository.LateEventRepository {
+			return late
+		},
+		Privacy: func(t *testing.T, _ repository.EventRepository) repository.PrivacyRepository {
+			return privacy
+		},
+	})
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
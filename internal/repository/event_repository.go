@@ -2,10 +2,15 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"event-metrics-service/internal/audit"
 	"event-metrics-service/internal/model"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -18,33 +23,84 @@ type EventRepository interface {
 	Create(ctx context.Context, event model.Event) error
 
 	// CreateBatch inserts multiple events efficiently using ClickHouse batches.
+	// Events without a TenantID are stored under model.DefaultTenant.
 	CreateBatch(ctx context.Context, events []model.Event) error
 
-	// FetchMetrics aggregates data based on filters.
-	FetchMetrics(ctx context.Context, filter model.MetricsFilter) (uint64, uint64, []model.MetricsGroup, error)
+	// FetchMetrics aggregates totals and groups based on filters in a single query.
+	// Like every read below it only sees the filter's tenant; an empty
+	// TenantID means model.DefaultTenant.
+	FetchMetrics(ctx context.Context, filter model.MetricsFilter) (model.MetricsResult, error)
+
+	// FetchUserEvents returns up to filter.Limit raw events of one user,
+	// newest first, continuing after filter.After when set.
+	FetchUserEvents(ctx context.Context, filter model.UserEventsFilter) ([]model.Event, error)
+
+	// FetchUserEventCounts returns per event name counts and first/last
+	// timestamps of one user's events within [from, to], ordered by name.
+	FetchUserEventCounts(ctx context.Context, tenantID, userID string, from, to time.Time) ([]model.UserEventCount, error)
+
+	// ExportEvents streams the raw events matching filter in timestamp order
+	// to fn without buffering the result. GroupBy is ignored.
+	ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error
+}
+
+var (
+	// ErrQueryTimeout is returned when a query runs past its deadline.
+	ErrQueryTimeout = errors.New("query timed out")
+
+	// ErrQueryLimitExceeded is returned when a query trips a resource guardrail
+	// such as max_rows_to_read or max_memory_usage.
+	ErrQueryLimitExceeded = errors.New("query exceeded resource limits")
+)
+
+// QueryLimits caps the resources a single read query may consume. Zero values
+// leave the server defaults in place.
+type QueryLimits struct {
+	MaxRowsToRead  uint64
+	MaxMemoryUsage uint64
 }
 
 type eventRepository struct {
-	conn clickhouse.Conn
+	conn     clickhouse.Conn
+	limits   QueryLimits
+	promoted []model.PromotedColumn
 }
 
-// NewEventRepository creates an EventRepository backed by ClickHouse.
-func NewEventRepository(conn clickhouse.Conn) EventRepository {
-	return &eventRepository{conn: conn}
+// NewEventRepository creates an EventRepository backed by ClickHouse. Promoted
+// metadata keys are written to, and queried from, their typed columns.
+func NewEventRepository(conn clickhouse.Conn, limits QueryLimits, promoted []model.PromotedColumn) EventRepository {
+	return &eventRepository{conn: conn, limits: limits, promoted: promoted}
 }
 
 const insertEventQuery = `
-	INSERT INTO events (event_name, channel, campaign_id, user_id, ts, tags, metadata)
-	VALUES (?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO events (tenant_id, event_name, channel, campaign_id, user_id, ts, tags, metadata, received_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
-func (r *eventRepository) Create(ctx context.Context, event model.Event) error {
+// insertQuery extends insertEventQuery with the promoted metadata columns.
+func (r *eventRepository) insertQuery() string {
+	if len(r.promoted) == 0 {
+		return insertEventQuery
+	}
+
+	columns := []string{"tenant_id", "event_name", "channel", "campaign_id", "user_id", "ts", "tags", "metadata", "received_at"}
+	for _, col := range r.promoted {
+		columns = append(columns, col.Column())
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+
+	return fmt.Sprintf("\n\tINSERT INTO events (%s)\n\tVALUES (%s)\n", strings.Join(columns, ", "), placeholders)
+}
+
+// rowValues returns the insert values for an event in insertQuery order.
+func (r *eventRepository) rowValues(event model.Event) ([]any, error) {
 	metadata, err := marshalMetadata(event.Metadata)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = r.conn.Exec(ctx, insertEventQuery,
+	values := []any{
+		model.TenantOrDefault(event.TenantID),
 		event.EventName,
 		event.Channel,
 		nullIfEmpty(event.CampaignID),
@@ -52,9 +108,21 @@ func (r *eventRepository) Create(ctx context.Context, event model.Event) error {
 		event.Timestamp,
 		event.Tags,
 		metadata,
-	)
+		receivedAt(event),
+	}
+	for _, col := range r.promoted {
+		values = append(values, promotedValue(col, event.Metadata[col.Key]))
+	}
+	return values, nil
+}
 
-	return err
+func (r *eventRepository) Create(ctx context.Context, event model.Event) error {
+	values, err := r.rowValues(event)
+	if err != nil {
+		return err
+	}
+
+	return r.conn.Exec(ctx, r.insertQuery(), values...)
 }
 
 func (r *eventRepository) CreateBatch(ctx context.Context, events []model.Event) error {
@@ -62,26 +130,18 @@ func (r *eventRepository) CreateBatch(ctx context.Context, events []model.Event)
 		return nil
 	}
 
-	batch, err := r.conn.PrepareBatch(ctx, insertEventQuery)
+	batch, err := r.conn.PrepareBatch(ctx, r.insertQuery())
 	if err != nil {
 		return fmt.Errorf("prepare batch: %w", err)
 	}
 
 	for _, event := range events {
-		metadata, err := marshalMetadata(event.Metadata)
+		values, err := r.rowValues(event)
 		if err != nil {
 			return err
 		}
 
-		if err := batch.Append(
-			event.EventName,
-			event.Channel,
-			nullIfEmpty(event.CampaignID),
-			event.UserID,
-			event.Timestamp,
-			event.Tags,
-			metadata,
-		); err != nil {
+		if err := batch.Append(values...); err != nil {
 			return fmt.Errorf("append batch: %w", err)
 		}
 	}
@@ -93,73 +153,168 @@ func (r *eventRepository) CreateBatch(ctx context.Context, events []model.Event)
 	return nil
 }
 
-func (r *eventRepository) FetchMetrics(ctx context.Context, filter model.MetricsFilter) (uint64, uint64, []model.MetricsGroup, error) {
-	whereParts := []string{"event_name = ?"}
-	args := []any{filter.EventName}
+func (r *eventRepository) FetchMetrics(ctx context.Context, filter model.MetricsFilter) (model.MetricsResult, error) {
+	where, whereArgs := r.buildWhereClause(filter)
 
-	if !filter.From.IsZero() {
-		whereParts = append(whereParts, "ts >= ?")
-		args = append(args, filter.From)
+	dedup := filter.Consistency == model.ConsistencyDedup
+	source := "events"
+	if dedup {
+		// FINAL merges duplicate sorting keys at read time, as a background
+		// merge would, at the cost of extra CPU and reading more data.
+		source = "events FINAL"
 	}
 
-	if !filter.To.IsZero() {
-		whereParts = append(whereParts, "ts <= ?")
-		args = append(args, filter.To)
+	query, args, err := r.buildMetricsQuery(filter.GroupBy, source, where)
+	if err != nil {
+		return model.MetricsResult{}, err
 	}
+	args = append(args, whereArgs...)
 
-	if filter.Channel != nil && *filter.Channel != "" {
-		whereParts = append(whereParts, "channel = ?")
-		args = append(args, *filter.Channel)
+	rows, err := r.conn.Query(r.queryContext(ctx, dedup), query, args...)
+	if err != nil {
+		return model.MetricsResult{}, fmt.Errorf("query metrics: %w", classifyQueryError(err))
 	}
+	defer rows.Close()
 
-	where := ""
-	if len(whereParts) > 0 {
-		where = "WHERE " + strings.Join(whereParts, " AND ")
+	// The server may hit a guardrail while streaming groups or the totals
+	// block, so both are classified like the query itself.
+	groups, err := scanMetricGroups(rows)
+	if err != nil {
+		return model.MetricsResult{}, classifyQueryError(err)
 	}
 
-	var totalCount, uniqueCount uint64
-	totalsQuery := fmt.Sprintf("SELECT COUNT(*), COUNT(DISTINCT user_id) FROM events %s", where)
-	row := r.conn.QueryRow(ctx, totalsQuery, args...)
-	if err := row.Scan(&totalCount, &uniqueCount); err != nil {
-		return 0, 0, nil, fmt.Errorf("query totals: %w", err)
+	total, unique, err := scanMetricTotals(rows, len(groups))
+	if err != nil {
+		return model.MetricsResult{}, classifyQueryError(err)
 	}
 
-	groupQuery, err := buildGroupQuery(filter.GroupBy, where)
-	if err != nil {
-		return 0, 0, nil, err
+	return model.MetricsResult{
+		TotalCount:  total,
+		UniqueCount: unique,
+		Groups:      groups,
+	}, nil
+}
+
+// queryContext attaches per-query settings. Wrapping the context also lets the
+// driver translate its deadline into max_execution_time on the server. The
+// rows the server reports reading are added to the request's audit trace.
+func (r *eventRepository) queryContext(ctx context.Context, final bool) context.Context {
+	settings := clickhouse.Settings{}
+	if final {
+		// ts is in both the partition and the sorting key, so duplicates never
+		// span partitions and FINAL can merge each partition independently.
+		settings["do_not_merge_across_partitions_select_final"] = 1
 	}
+	if r.limits.MaxRowsToRead > 0 {
+		settings["max_rows_to_read"] = r.limits.MaxRowsToRead
+	}
+	if r.limits.MaxMemoryUsage > 0 {
+		settings["max_memory_usage"] = r.limits.MaxMemoryUsage
+	}
+	return clickhouse.Context(ctx,
+		clickhouse.WithSettings(settings),
+		clickhouse.WithProgress(func(p *clickhouse.Progress) {
+			audit.AddRowsRead(ctx, p.Rows)
+		}),
+	)
+}
 
-	rows, err := r.conn.Query(ctx, groupQuery, args...)
-	if err != nil {
-		return 0, 0, nil, fmt.Errorf("query groups: %w", err)
+// classifyQueryError tags timeouts and guardrail trips with the sentinel
+// errors above so callers can map them without knowing ClickHouse codes.
+func classifyQueryError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
 	}
-	defer rows.Close()
 
-	groups, err := scanMetricGroups(rows)
-	if err != nil {
-		return 0, 0, nil, err
+	var exception *clickhouse.Exception
+	if !errors.As(err, &exception) {
+		return err
+	}
+
+	switch exception.Code {
+	case 159: // TIMEOUT_EXCEEDED
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	case 158, 241, 307: // TOO_MANY_ROWS, MEMORY_LIMIT_EXCEEDED, TOO_MANY_BYTES
+		return fmt.Errorf("%w: %w", ErrQueryLimitExceeded, err)
+	default:
+		return err
+	}
+}
+
+// tsParam binds a time compared with a DateTime64(3) column. The driver
+// renders positional time.Time arguments at whole seconds, so times are
+// sent as UTC text with milliseconds and parsed server side.
+const tsParam = "toDateTime64(?, 3, 'UTC')"
+
+// tsArg formats t for tsParam.
+func tsArg(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000")
+}
+
+func (r *eventRepository) buildWhereClause(filter model.MetricsFilter) (string, []any) {
+	// tenant_id leads the sorting key, so this predicate both isolates
+	// tenants and prunes the scan to one tenant's granules.
+	whereParts := []string{"tenant_id = ?", "event_name = ?"}
+	args := []any{model.TenantOrDefault(filter.TenantID), filter.EventName}
+
+	if !filter.From.IsZero() {
+		whereParts = append(whereParts, "ts >= "+tsParam)
+		args = append(args, tsArg(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		whereParts = append(whereParts, "ts <= "+tsParam)
+		args = append(args, tsArg(filter.To))
+	}
+
+	if filter.Channel != nil && *filter.Channel != "" {
+		whereParts = append(whereParts, "channel = ?")
+		args = append(args, *filter.Channel)
 	}
 
-	return totalCount, uniqueCount, groups, nil
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		expr, exprArgs := r.metadataFilter(key, filter.Metadata[key])
+		whereParts = append(whereParts, expr)
+		args = append(args, exprArgs...)
+	}
+
+	return "WHERE " + strings.Join(whereParts, " AND "), args
 }
 
-func buildGroupQuery(groupBy, where string) (string, error) {
+// buildMetricsQuery returns a grouped query over source whose WITH TOTALS row
+// carries the overall counts, so totals and groups come from a single scan.
+// The returned args belong to the SELECT list and precede the WHERE args.
+func (r *eventRepository) buildMetricsQuery(groupBy, source, where string) (string, []any, error) {
 	// SQL injection protection: only allowed values are accepted via switch.
-	switch groupBy {
-	case "channel":
+	switch {
+	case groupBy == "channel":
 		return fmt.Sprintf(
-			"SELECT channel, COUNT(*), COUNT(DISTINCT user_id) FROM events %s GROUP BY channel ORDER BY channel",
-			where), nil
-	case "hour":
+			"SELECT channel, COUNT(*), COUNT(DISTINCT user_id) FROM %s %s GROUP BY channel WITH TOTALS ORDER BY channel",
+			source, where), nil, nil
+	case groupBy == "hour":
 		return fmt.Sprintf(
-			"SELECT formatDateTime(ts, '%%Y-%%m-%%dT%%H:00:00Z'), COUNT(*), COUNT(DISTINCT user_id) FROM events %s GROUP BY 1 ORDER BY 1",
-			where), nil
-	case "day":
+			"SELECT formatDateTime(ts, '%%Y-%%m-%%dT%%H:00:00Z'), COUNT(*), COUNT(DISTINCT user_id) FROM %s %s GROUP BY 1 WITH TOTALS ORDER BY 1",
+			source, where), nil, nil
+	case groupBy == "day":
+		return fmt.Sprintf(
+			"SELECT formatDateTime(ts, '%%Y-%%m-%%d'), COUNT(*), COUNT(DISTINCT user_id) FROM %s %s GROUP BY 1 WITH TOTALS ORDER BY 1",
+			source, where), nil, nil
+	case strings.HasPrefix(groupBy, model.MetadataGroupPrefix):
+		key := strings.TrimPrefix(groupBy, model.MetadataGroupPrefix)
+		if !model.MetadataKeyPattern.MatchString(key) {
+			return "", nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+		}
+		expr, args := r.metadataKeyExpr(key)
 		return fmt.Sprintf(
-			"SELECT formatDateTime(ts, '%%Y-%%m-%%d'), COUNT(*), COUNT(DISTINCT user_id) FROM events %s GROUP BY 1 ORDER BY 1",
-			where), nil
+			"SELECT %s, COUNT(*), COUNT(DISTINCT user_id) FROM %s %s GROUP BY 1 WITH TOTALS ORDER BY 1",
+			expr, source, where), args, nil
 	default:
-		return "", fmt.Errorf("unsupported group_by: %s", groupBy)
+		return "", nil, fmt.Errorf("unsupported group_by: %s", groupBy)
 	}
 }
 
@@ -184,6 +339,20 @@ func scanMetricGroups(rows driver.Rows) ([]model.MetricsGroup, error) {
 	return groups, nil
 }
 
+// scanMetricTotals reads the WITH TOTALS row. It must be called after all
+// group rows have been consumed. An empty result set carries no totals row.
+func scanMetricTotals(rows driver.Rows, groupCount int) (uint64, uint64, error) {
+	var key string
+	var total, unique uint64
+	if err := rows.Totals(&key, &total, &unique); err != nil {
+		if errors.Is(err, sql.ErrNoRows) && groupCount == 0 {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("scan totals: %w", err)
+	}
+	return total, unique, nil
+}
+
 func marshalMetadata(metadata map[string]interface{}) (string, error) {
 	if metadata == nil {
 		return "{}", nil
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -9,7 +10,9 @@ import (
 	"event-metrics-service/internal/model"
 	"event-metrics-service/internal/testdata/mockclickhousebatch"
 	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
 
+	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -47,6 +50,7 @@ func (s *EventRepositoryTestSuite) TestCreate_Success() {
 		CampaignID: "cmp-123",
 		UserID:     "user-1",
 		Timestamp:  ts,
+		ReceivedAt: ts.Add(time.Minute),
 		Tags:       []string{"electronics", "homepage"},
 		Metadata: map[string]any{
 			"price":  99.9,
@@ -59,15 +63,17 @@ func (s *EventRepositoryTestSuite) TestCreate_Success() {
 
 	s.connMock.On(
 		"Exec",
-		mock.Anything,    // context
-		insertEventQuery, // query
-		event.EventName,  // event_name
-		event.Channel,    // channel
-		event.CampaignID, // nullIfEmpty -> string
-		event.UserID,     // user_id
-		event.Timestamp,  // ts
-		event.Tags,       // tags
-		metadataJSON,     // metadata (JSON string)
+		mock.Anything,       // context
+		insertEventQuery,    // query
+		model.DefaultTenant, // tenant_id
+		event.EventName,     // event_name
+		event.Channel,       // channel
+		event.CampaignID,    // nullIfEmpty -> string
+		event.UserID,        // user_id
+		event.Timestamp,     // ts
+		event.Tags,          // tags
+		metadataJSON,        // metadata (JSON string)
+		ts.Add(time.Minute), // received_at
 	).Return(nil).Once()
 
 	err = s.repository.Create(ctx, event)
@@ -95,6 +101,7 @@ func (s *EventRepositoryTestSuite) TestCreate_NoCampaignID_UsesNil() {
 		"Exec",
 		mock.Anything,
 		insertEventQuery,
+		model.DefaultTenant,
 		event.EventName,
 		event.Channel,
 		nil,
@@ -102,6 +109,7 @@ func (s *EventRepositoryTestSuite) TestCreate_NoCampaignID_UsesNil() {
 		event.Timestamp,
 		event.Tags,
 		metadataJSON,
+		mock.AnythingOfType("time.Time"), // received_at
 	).Return(nil).Once()
 
 	err = s.repository.Create(ctx, event)
@@ -200,13 +208,15 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_AppendError() {
 	// Return error on Append call.
 	s.batchMock.On(
 		"Append",
+		model.DefaultTenant,
 		events[0].EventName,
 		events[0].Channel,
 		nullIfEmpty(events[0].CampaignID),
 		events[0].UserID,
 		events[0].Timestamp,
 		events[0].Tags,
-		mock.Anything, // metadata JSON string
+		mock.Anything,                    // metadata JSON string
+		mock.AnythingOfType("time.Time"), // received_at
 	).Return(expectedErr).Once()
 
 	err := s.repository.CreateBatch(ctx, events)
@@ -253,6 +263,7 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_SendError() {
 	// 1. event append success
 	s.batchMock.On(
 		"Append",
+		model.DefaultTenant,
 		events[0].EventName,
 		events[0].Channel,
 		nullIfEmpty(events[0].CampaignID),
@@ -260,11 +271,13 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_SendError() {
 		events[0].Timestamp,
 		events[0].Tags,
 		mock.Anything,
+		mock.AnythingOfType("time.Time"), // received_at
 	).Return(nil).Once()
 
 	// 2. event append success (CampaignID is empty → nil)
 	s.batchMock.On(
 		"Append",
+		model.DefaultTenant,
 		events[1].EventName,
 		events[1].Channel,
 		nullIfEmpty(events[1].CampaignID),
@@ -272,6 +285,7 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_SendError() {
 		events[1].Timestamp,
 		events[1].Tags,
 		mock.Anything,
+		mock.AnythingOfType("time.Time"), // received_at
 	).Return(nil).Once()
 
 	// Send returns error
@@ -297,6 +311,7 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_Success() {
 			Metadata:   map[string]any{"k": "v"},
 		},
 		{
+			TenantID:   "acme",
 			EventName:  "checkout_start",
 			Channel:    "mobile_app",
 			CampaignID: "", // expects nil to be passed
@@ -316,6 +331,7 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_Success() {
 	// 1. event append success
 	s.batchMock.On(
 		"Append",
+		model.DefaultTenant,
 		events[0].EventName,
 		events[0].Channel,
 		nullIfEmpty(events[0].CampaignID),
@@ -323,11 +339,13 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_Success() {
 		events[0].Timestamp,
 		events[0].Tags,
 		mock.Anything,
+		mock.AnythingOfType("time.Time"), // received_at
 	).Return(nil).Once()
 
-	// 2. event append success
+	// 2. event append success, stored under its own tenant
 	s.batchMock.On(
 		"Append",
+		"acme",
 		events[1].EventName,
 		events[1].Channel,
 		nullIfEmpty(events[1].CampaignID),
@@ -335,6 +353,7 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_Success() {
 		events[1].Timestamp,
 		events[1].Tags,
 		mock.Anything,
+		mock.AnythingOfType("time.Time"), // received_at
 	).Return(nil).Once()
 
 	s.batchMock.On("Send").Return(nil).Once()
@@ -342,3 +361,282 @@ func (s *EventRepositoryTestSuite) TestCreateBatch_Success() {
 	err := s.repository.CreateBatch(ctx, events)
 	s.NoError(err)
 }
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_SingleQueryWithTotals() {
+	ctx := context.Background()
+	channel := "web"
+	filter := model.MetricsFilter{
+		TenantID:  "acme",
+		EventName: "product_view",
+		From:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2025, 1, 1, 23, 59, 59, 999_000_000, time.UTC),
+		Channel:   &channel,
+		GroupBy:   "day",
+	}
+
+	expectedQuery := "SELECT formatDateTime(ts, '%Y-%m-%d'), COUNT(*), COUNT(DISTINCT user_id) FROM events " +
+		"WHERE tenant_id = ? AND event_name = ? AND ts >= toDateTime64(?, 3, 'UTC') AND ts <= toDateTime64(?, 3, 'UTC') AND channel = ? GROUP BY 1 WITH TOTALS ORDER BY 1"
+	expectedArgs := []any{"acme", filter.EventName, "2025-01-01 00:00:00.000", "2025-01-01 23:59:59.999", channel}
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, expectedArgs).Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "2025-01-01"
+		*args.Get(1).(*uint64) = 7
+		*args.Get(2).(*uint64) = 4
+	}).Return(nil).Once()
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "2025-01-02"
+		*args.Get(1).(*uint64) = 3
+		*args.Get(2).(*uint64) = 2
+	}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*uint64) = 10
+		*args.Get(2).(*uint64) = 5
+	}).Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	result, err := s.repository.FetchMetrics(ctx, filter)
+
+	s.NoError(err)
+	s.Equal(uint64(10), result.TotalCount)
+	s.Equal(uint64(5), result.UniqueCount)
+	s.Equal([]model.MetricsGroup{
+		{Key: "2025-01-01", TotalCount: 7, UniqueUserCount: 4},
+		{Key: "2025-01-02", TotalCount: 3, UniqueUserCount: 2},
+	}, result.Groups)
+	rows.AssertExpectations(s.T())
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_DedupReadsFinal() {
+	filter := model.MetricsFilter{EventName: "product_view", GroupBy: "channel", Consistency: model.ConsistencyDedup}
+
+	expectedQuery := "SELECT channel, COUNT(*), COUNT(DISTINCT user_id) FROM events FINAL " +
+		"WHERE tenant_id = ? AND event_name = ? GROUP BY channel WITH TOTALS ORDER BY channel"
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, []any{model.DefaultTenant, filter.EventName}).Return(rows, nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows).Once()
+	rows.On("Close").Return(nil).Once()
+
+	_, err := s.repository.FetchMetrics(context.Background(), filter)
+
+	s.NoError(err)
+	rows.AssertExpectations(s.T())
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_EmptyResultHasZeroTotals() {
+	ctx := context.Background()
+	filter := model.MetricsFilter{EventName: "signup", GroupBy: "channel"}
+
+	expectedQuery := "SELECT channel, COUNT(*), COUNT(DISTINCT user_id) FROM events " +
+		"WHERE tenant_id = ? AND event_name = ? GROUP BY channel WITH TOTALS ORDER BY channel"
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, []any{model.DefaultTenant, "signup"}).Return(rows, nil).Once()
+
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows).Once()
+	rows.On("Close").Return(nil).Once()
+
+	result, err := s.repository.FetchMetrics(ctx, filter)
+
+	s.NoError(err)
+	s.Equal(model.MetricsResult{}, result)
+	rows.AssertExpectations(s.T())
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_MissingTotals() {
+	ctx := context.Background()
+	filter := model.MetricsFilter{EventName: "signup", GroupBy: "hour"}
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows).Once()
+	rows.On("Close").Return(nil).Once()
+
+	_, err := s.repository.FetchMetrics(ctx, filter)
+
+	s.ErrorIs(err, sql.ErrNoRows)
+	s.ErrorContains(err, "scan totals")
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_UnsupportedGroupBy() {
+	_, err := s.repository.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "week"})
+
+	s.ErrorContains(err, "unsupported group_by")
+	s.connMock.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_QueryError() {
+	expectedErr := errors.New("query error")
+	s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&mockclickhouserows.Rows{}, expectedErr).Once()
+
+	_, err := s.repository.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "channel"})
+
+	s.ErrorIs(err, expectedErr)
+	s.ErrorContains(err, "query metrics")
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_GuardrailErrors() {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "context deadline", err: context.DeadlineExceeded, expected: ErrQueryTimeout},
+		{name: "server timeout", err: &clickhouse.Exception{Code: 159}, expected: ErrQueryTimeout},
+		{name: "too many rows", err: &clickhouse.Exception{Code: 158}, expected: ErrQueryLimitExceeded},
+		{name: "memory limit", err: &clickhouse.Exception{Code: 241}, expected: ErrQueryLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&mockclickhouserows.Rows{}, tt.err).Once()
+
+			_, err := s.repository.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "channel"})
+
+			s.ErrorIs(err, tt.expected)
+			s.ErrorIs(err, tt.err)
+		})
+	}
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_GuardrailErrorsWhileReading() {
+	timeout := &clickhouse.Exception{Code: 159}
+	tooManyBytes := &clickhouse.Exception{Code: 307}
+
+	s.Run("groups", func() {
+		rows := &mockclickhouserows.Rows{}
+		s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil).Once()
+		rows.On("Next").Return(false).Once()
+		rows.On("Err").Return(timeout).Once()
+		rows.On("Close").Return(nil).Once()
+
+		_, err := s.repository.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "channel"})
+
+		s.ErrorIs(err, ErrQueryTimeout)
+		s.ErrorIs(err, timeout)
+	})
+
+	s.Run("totals", func() {
+		rows := &mockclickhouserows.Rows{}
+		s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil).Once()
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		rows.On("Next").Return(false).Once()
+		rows.On("Err").Return(nil).Once()
+		rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Return(tooManyBytes).Once()
+		rows.On("Close").Return(nil).Once()
+
+		_, err := s.repository.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "channel"})
+
+		s.ErrorIs(err, ErrQueryLimitExceeded)
+		s.ErrorIs(err, tooManyBytes)
+		s.ErrorContains(err, "scan totals")
+	})
+}
+
+func (s *EventRepositoryTestSuite) TestCreateBatch_PromotedColumns() {
+	ctx := context.Background()
+	s.repository.promoted = []model.PromotedColumn{
+		{Key: "price", Type: "Float64"},
+		{Key: "currency", Type: "LowCardinality(String)"},
+		{Key: "quantity", Type: "UInt32"},
+	}
+
+	event := model.Event{
+		EventName: "purchase",
+		Channel:   "web",
+		UserID:    "user-1",
+		Timestamp: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		Tags:      []string{},
+		Metadata:  map[string]any{"price": 99.9, "currency": "TRY", "quantity": "two"},
+	}
+
+	expectedQuery := "\n\tINSERT INTO events (tenant_id, event_name, channel, campaign_id, user_id, ts, tags, metadata, received_at, meta_price, meta_currency, meta_quantity)" +
+		"\n\tVALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)\n"
+	s.connMock.On("PrepareBatch", mock.Anything, expectedQuery).Return(s.batchMock, nil).Once()
+
+	s.batchMock.On(
+		"Append",
+		model.DefaultTenant,
+		event.EventName,
+		event.Channel,
+		nil,
+		event.UserID,
+		event.Timestamp,
+		event.Tags,
+		mock.Anything,                    // metadata JSON keeps every key
+		mock.AnythingOfType("time.Time"), // received_at
+		99.9,
+		"TRY",
+		nil, // mismatched type is stored as NULL
+	).Return(nil).Once()
+	s.batchMock.On("Send").Return(nil).Once()
+
+	err := s.repository.CreateBatch(ctx, []model.Event{event})
+	s.NoError(err)
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_MetadataUsesPromotedColumn() {
+	ctx := context.Background()
+	s.repository.promoted = []model.PromotedColumn{{Key: "currency", Type: "LowCardinality(String)"}}
+	filter := model.MetricsFilter{
+		EventName: "purchase",
+		GroupBy:   "metadata.currency",
+		Metadata:  map[string]string{"referrer": "google"},
+	}
+
+	expectedQuery := "SELECT ifNull(toString(meta_currency), ''), COUNT(*), COUNT(DISTINCT user_id) FROM events " +
+		"WHERE tenant_id = ? AND event_name = ? AND JSONExtractRaw(metadata, ?) IN (?, ?) GROUP BY 1 WITH TOTALS ORDER BY 1"
+	expectedArgs := []any{model.DefaultTenant, "purchase", "referrer", `"google"`, "google"}
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, expectedArgs).Return(rows, nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows).Once()
+	rows.On("Close").Return(nil).Once()
+
+	_, err := s.repository.FetchMetrics(ctx, filter)
+	s.NoError(err)
+}
+
+func (s *EventRepositoryTestSuite) TestFetchMetrics_MetadataFallsBackToJSON() {
+	ctx := context.Background()
+	s.repository.promoted = []model.PromotedColumn{{Key: "price", Type: "Float64"}}
+	filter := model.MetricsFilter{
+		EventName: "purchase",
+		GroupBy:   "metadata.currency",
+		Metadata:  map[string]string{"price": "99.9"},
+	}
+
+	expectedQuery := "SELECT if(JSONType(metadata, ?) = 'String', JSONExtractString(metadata, ?), JSONExtractRaw(metadata, ?)), " +
+		"COUNT(*), COUNT(DISTINCT user_id) FROM events " +
+		"WHERE tenant_id = ? AND event_name = ? AND meta_price = ? GROUP BY 1 WITH TOTALS ORDER BY 1"
+	expectedArgs := []any{"currency", "currency", "currency", model.DefaultTenant, "purchase", "99.9"}
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, expectedArgs).Return(rows, nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Totals", mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows).Once()
+	rows.On("Close").Return(nil).Once()
+
+	_, err := s.repository.FetchMetrics(ctx, filter)
+	s.NoError(err)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// ExportEvents streams matching events in timestamp order, calling fn for each
+// row as it arrives from the server. An error from fn stops the query.
+//
+// Exports deliberately skip the metrics guardrails (max_rows_to_read,
+// max_memory_usage): reading many rows is the point, and streaming keeps
+// memory flat on both ends.
+func (r *eventRepository) ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error {
+	where, args := r.buildWhereClause(filter)
+
+	source := "events"
+	settings := clickhouse.Settings{}
+	if filter.Consistency == model.ConsistencyDedup {
+		source = "events FINAL"
+		settings["do_not_merge_across_partitions_select_final"] = 1
+	}
+
+	// event_name is fixed by the filter, so ORDER BY ts follows the table's
+	// sorting key and ClickHouse can stream it without a full sort.
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY ts", eventColumns, source, where)
+
+	rows, err := r.conn.Query(clickhouse.Context(ctx, clickhouse.WithSettings(settings)), query, args...)
+	if err != nil {
+		return fmt.Errorf("query export: %w", classifyQueryError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate export: %w", classifyQueryError(err))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// LateEventRepository stores events whose timestamp lies further in the past
+// than the ingest past tolerance. They are kept apart from the events table
+// so they neither change historical metrics nor create old partitions.
+type LateEventRepository interface {
+	// CreateBatch inserts late events. Events without a TenantID are stored
+	// under model.DefaultTenant.
+	CreateBatch(ctx context.Context, events []model.Event) error
+}
+
+// BatchWriter is the part of a repository the batch worker writes through.
+// Both EventRepository and LateEventRepository implement it.
+type BatchWriter interface {
+	CreateBatch(ctx context.Context, events []model.Event) error
+}
+
+type lateEventRepository struct {
+	conn clickhouse.Conn
+}
+
+// NewLateEventRepository creates a LateEventRepository backed by ClickHouse.
+func NewLateEventRepository(conn clickhouse.Conn) LateEventRepository {
+	return &lateEventRepository{conn: conn}
+}
+
+const insertLateEventQuery = `
+	INSERT INTO late_events (tenant_id, event_name, channel, campaign_id, user_id, ts, tags, metadata, received_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+func (r *lateEventRepository) CreateBatch(ctx context.Context, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch, err := r.conn.PrepareBatch(ctx, insertLateEventQuery)
+	if err != nil {
+		return fmt.Errorf("prepare late batch: %w", err)
+	}
+
+	for _, event := range events {
+		metadata, err := marshalMetadata(event.Metadata)
+		if err != nil {
+			return err
+		}
+
+		err = batch.Append(
+			model.TenantOrDefault(event.TenantID),
+			event.EventName,
+			event.Channel,
+			nullIfEmpty(event.CampaignID),
+			event.UserID,
+			event.Timestamp,
+			event.Tags,
+			metadata,
+			receivedAt(event),
+		)
+		if err != nil {
+			return fmt.Errorf("append late batch: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("send late batch: %w", err)
+	}
+	return nil
+}
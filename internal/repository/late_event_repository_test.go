@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockclickhousebatch"
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLateEventRepository_CreateBatch(t *testing.T) {
+	conn := &mockclickhouseconnection.Connection{}
+	batch := &mockclickhousebatch.Batch{}
+	repo := NewLateEventRepository(conn)
+
+	ts := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	receivedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := model.Event{
+		EventName:  "purchase",
+		Channel:    "web",
+		UserID:     "u1",
+		Timestamp:  ts,
+		Tags:       []string{"sale"},
+		Metadata:   map[string]any{"price": 9.5},
+		ReceivedAt: receivedAt,
+	}
+
+	conn.On("PrepareBatch", mock.Anything, insertLateEventQuery).Return(batch, nil).Once()
+	batch.On("Append", model.DefaultTenant, "purchase", "web", nil, "u1", ts, []string{"sale"}, `{"price":9.5}`, receivedAt).Return(nil).Once()
+	batch.On("Send").Return(nil).Once()
+
+	require.NoError(t, repo.CreateBatch(context.Background(), []model.Event{event}))
+	conn.AssertExpectations(t)
+	batch.AssertExpectations(t)
+}
+
+func TestLateEventRepository_CreateBatchErrors(t *testing.T) {
+	require.NoError(t, NewLateEventRepository(nil).CreateBatch(context.Background(), nil))
+
+	conn := &mockclickhouseconnection.Connection{}
+	expectedErr := errors.New("prepare failed")
+	conn.On("PrepareBatch", mock.Anything, insertLateEventQuery).Return(nil, expectedErr).Once()
+
+	err := NewLateEventRepository(conn).CreateBatch(context.Background(), []model.Event{{EventName: "click"}})
+	require.ErrorIs(t, err, expectedErr)
+	require.ErrorContains(t, err, "prepare late batch")
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"event-metrics-service/internal/model"
+)
+
+type memoryAuditRepository struct {
+	mu      sync.Mutex
+	records []model.AuditRecord
+}
+
+// NewMemoryAuditRepository creates an AuditRepository that keeps the audit
+// log in memory.
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (r *memoryAuditRepository) InsertAudit(ctx context.Context, records []model.AuditRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, records...)
+	return nil
+}
+
+func (r *memoryAuditRepository) SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	r.mu.Lock()
+	records := []model.AuditRecord{}
+	for _, rec := range r.records {
+		if matchesAuditQuery(rec, query) {
+			records = append(records, rec)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].At.After(records[j].At)
+	})
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[:query.Limit]
+	}
+	return records, nil
+}
+
+func matchesAuditQuery(rec model.AuditRecord, query model.AuditQuery) bool {
+	if query.TenantID != "" && rec.TenantID != query.TenantID {
+		return false
+	}
+	if query.Principal != "" && rec.Principal != query.Principal {
+		return false
+	}
+	if query.Action != "" && rec.Action != query.Action {
+		return false
+	}
+	if !query.From.IsZero() && rec.At.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && rec.At.After(query.To) {
+		return false
+	}
+	return true
+}
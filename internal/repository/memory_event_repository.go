@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"event-metrics-service/internal/audit"
+	"event-metrics-service/internal/model"
+)
+
+type memoryEventRepository struct {
+	mu     sync.RWMutex
+	events map[string]model.Event
+
+	// late holds the events written through NewMemoryLateEventRepository.
+	late []model.Event
+}
+
+// NewMemoryEventRepository creates an EventRepository that keeps events in
+// process memory. Events with the same sorting key replace each other, which
+// matches ClickHouse after its background merges. Data is lost on restart.
+func NewMemoryEventRepository() EventRepository {
+	return &memoryEventRepository{events: map[string]model.Event{}}
+}
+
+func (r *memoryEventRepository) Create(ctx context.Context, event model.Event) error {
+	return r.CreateBatch(ctx, []model.Event{event})
+}
+
+func (r *memoryEventRepository) CreateBatch(ctx context.Context, events []model.Event) error {
+	stored := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		normalized, err := normalizeMemoryEvent(event)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, normalized)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range stored {
+		key := idempotencyKey(event.TenantID, event.EventName, event.Timestamp, event.UserID, event.Channel, event.CampaignID)
+		r.events[key] = event
+	}
+	return nil
+}
+
+func (r *memoryEventRepository) FetchMetrics(ctx context.Context, filter model.MetricsFilter) (model.MetricsResult, error) {
+	groupKey, err := memoryGroupKey(filter.GroupBy)
+	if err != nil {
+		return model.MetricsResult{}, err
+	}
+
+	type bucket struct {
+		total int
+		users map[string]struct{}
+	}
+	buckets := map[string]*bucket{}
+	allUsers := map[string]struct{}{}
+	var total uint64
+
+	r.mu.RLock()
+	audit.AddRowsRead(ctx, uint64(len(r.events)))
+	for _, event := range r.events {
+		if !matchesMetricsFilter(event, filter) {
+			continue
+		}
+
+		key := groupKey(event)
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{users: map[string]struct{}{}}
+			buckets[key] = b
+		}
+		b.total++
+		b.users[event.UserID] = struct{}{}
+
+		total++
+		allUsers[event.UserID] = struct{}{}
+	}
+	r.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return model.MetricsResult{}, fmt.Errorf("query metrics: %w", classifyQueryError(err))
+	}
+
+	result := model.MetricsResult{TotalCount: total, UniqueCount: uint64(len(allUsers))}
+	for key, b := range buckets {
+		result.Groups = append(result.Groups, model.MetricsGroup{
+			Key:             key,
+			TotalCount:      uint64(b.total),
+			UniqueUserCount: uint64(len(b.users)),
+		})
+	}
+	sort.Slice(result.Groups, func(i, j int) bool { return result.Groups[i].Key < result.Groups[j].Key })
+
+	return result, nil
+}
+
+// normalizeMemoryEvent stores events the way ClickHouse returns them:
+// millisecond UTC timestamps and metadata decoded from its JSON form, which
+// also detaches the stored copy from the caller's maps and slices.
+func normalizeMemoryEvent(event model.Event) (model.Event, error) {
+	metadata, err := marshalMetadata(event.Metadata)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(metadata), &decoded); err != nil {
+		return model.Event{}, fmt.Errorf("unmarshal metadata: %w", err)
+	}
+
+	event.Metadata = decoded
+	event.TenantID = model.TenantOrDefault(event.TenantID)
+	event.Timestamp = event.Timestamp.UTC().Truncate(time.Millisecond)
+	event.ReceivedAt = receivedAt(event).Truncate(time.Millisecond)
+	event.Tags = append([]string{}, event.Tags...)
+	return event, nil
+}
+
+// memoryGroupKey returns the grouping function for a group_by value, using
+// the same key formats as the SQL backends.
+func memoryGroupKey(groupBy string) (func(model.Event) string, error) {
+	switch {
+	case groupBy == "channel":
+		return func(e model.Event) string { return e.Channel }, nil
+	case groupBy == "hour":
+		return func(e model.Event) string { return e.Timestamp.Format("2006-01-02T15:00:00Z") }, nil
+	case groupBy == "day":
+		return func(e model.Event) string { return e.Timestamp.Format("2006-01-02") }, nil
+	case strings.HasPrefix(groupBy, model.MetadataGroupPrefix):
+		key := strings.TrimPrefix(groupBy, model.MetadataGroupPrefix)
+		if !model.MetadataKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+		}
+		return func(e model.Event) string {
+			value, ok := e.Metadata[key]
+			if !ok {
+				return ""
+			}
+			text, _ := metadataText(value)
+			return text
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+	}
+}
+
+func matchesMetricsFilter(event model.Event, filter model.MetricsFilter) bool {
+	if event.TenantID != model.TenantOrDefault(filter.TenantID) || event.EventName != filter.EventName {
+		return false
+	}
+	if !filter.From.IsZero() && event.Timestamp.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && event.Timestamp.After(filter.To) {
+		return false
+	}
+	if filter.Channel != nil && *filter.Channel != "" && event.Channel != *filter.Channel {
+		return false
+	}
+
+	for key, want := range filter.Metadata {
+		value, ok := event.Metadata[key]
+		if !ok {
+			return false
+		}
+		text, raw := metadataText(value)
+		if text != want && raw != want {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataText returns a metadata value as grouped by the SQL backends (bare
+// strings, raw JSON for everything else) together with its raw JSON form.
+func metadataText(value any) (string, string) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", ""
+	}
+	if s, ok := value.(string); ok {
+		return s, string(b)
+	}
+	return string(b), string(b)
+}
+
+func (r *memoryEventRepository) FetchUserEvents(ctx context.Context, filter model.UserEventsFilter) ([]model.Event, error) {
+	r.mu.RLock()
+	var events []model.Event
+	for _, event := range r.events {
+		if event.TenantID != model.TenantOrDefault(filter.TenantID) || event.UserID != filter.UserID {
+			continue
+		}
+		if event.Timestamp.Before(filter.From) || event.Timestamp.After(filter.To) {
+			continue
+		}
+		if filter.EventName != "" && event.EventName != filter.EventName {
+			continue
+		}
+		if filter.After != nil && !userEventBefore(event, *filter.After) {
+			continue
+		}
+		events = append(events, event)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		return userEventBefore(events[j], cursorOf(events[i]))
+	})
+
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+
+	out := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		copied, err := normalizeMemoryEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, copied)
+	}
+	return out, nil
+}
+
+func (r *memoryEventRepository) FetchUserEventCounts(ctx context.Context, tenantID, userID string, from, to time.Time) ([]model.UserEventCount, error) {
+	byName := map[string]*model.UserEventCount{}
+	tenantID = model.TenantOrDefault(tenantID)
+
+	r.mu.RLock()
+	for _, event := range r.events {
+		if event.TenantID != tenantID || event.UserID != userID || event.Timestamp.Before(from) || event.Timestamp.After(to) {
+			continue
+		}
+
+		c, ok := byName[event.EventName]
+		if !ok {
+			c = &model.UserEventCount{EventName: event.EventName, FirstSeen: event.Timestamp, LastSeen: event.Timestamp}
+			byName[event.EventName] = c
+		}
+		c.Count++
+		if event.Timestamp.Before(c.FirstSeen) {
+			c.FirstSeen = event.Timestamp
+		}
+		if event.Timestamp.After(c.LastSeen) {
+			c.LastSeen = event.Timestamp
+		}
+	}
+	r.mu.RUnlock()
+
+	counts := make([]model.UserEventCount, 0, len(byName))
+	for _, c := range byName {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].EventName < counts[j].EventName })
+	return counts, nil
+}
+
+func cursorOf(event model.Event) model.UserEventsCursor {
+	return model.UserEventsCursor{
+		Timestamp:  event.Timestamp,
+		EventName:  event.EventName,
+		Channel:    event.Channel,
+		CampaignID: event.CampaignID,
+	}
+}
+
+// userEventBefore reports whether event sorts strictly before the cursor
+// position in newest-first timeline order, i.e. its (ts, event_name, channel,
+// campaign_id) tuple is smaller.
+func userEventBefore(event model.Event, cursor model.UserEventsCursor) bool {
+	if !event.Timestamp.Equal(cursor.Timestamp) {
+		return event.Timestamp.Before(cursor.Timestamp)
+	}
+	if event.EventName != cursor.EventName {
+		return event.EventName < cursor.EventName
+	}
+	if event.Channel != cursor.Channel {
+		return event.Channel < cursor.Channel
+	}
+	return event.CampaignID < cursor.CampaignID
+}
+
+func (r *memoryEventRepository) ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error {
+	r.mu.RLock()
+	var events []model.Event
+	for _, event := range r.events {
+		if matchesMetricsFilter(event, filter) {
+			events = append(events, event)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("export: %w", classifyQueryError(err))
+		}
+
+		copied, err := normalizeMemoryEvent(event)
+		if err != nil {
+			return err
+		}
+		if err := fn(copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
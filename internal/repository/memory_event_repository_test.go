@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryEventRepository_ReplacesDuplicateSortingKeys(t *testing.T) {
+	repo := NewMemoryEventRepository()
+	ts := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := model.Event{EventName: "click", Channel: "web", UserID: "u1", Timestamp: ts}
+
+	require.NoError(t, repo.CreateBatch(context.Background(), []model.Event{event, event}))
+	require.NoError(t, repo.Create(context.Background(), event))
+
+	result, err := repo.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "click", GroupBy: "channel"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), result.TotalCount)
+}
+
+func TestMemoryEventRepository_DetachesFromCaller(t *testing.T) {
+	repo := NewMemoryEventRepository()
+	metadata := map[string]any{"currency": "TRY"}
+	event := model.Event{EventName: "purchase", Channel: "web", UserID: "u1", Timestamp: time.Now(), Metadata: metadata}
+	require.NoError(t, repo.Create(context.Background(), event))
+
+	metadata["currency"] = "USD"
+
+	result, err := repo.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "purchase", GroupBy: "metadata.currency"})
+	require.NoError(t, err)
+	require.Equal(t, []model.MetricsGroup{{Key: "TRY", TotalCount: 1, UniqueUserCount: 1}}, result.Groups)
+}
+
+func TestMemoryEventRepository_CancelledContext(t *testing.T) {
+	repo := NewMemoryEventRepository()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := repo.FetchMetrics(ctx, model.MetricsFilter{EventName: "click", GroupBy: "channel"})
+	require.ErrorIs(t, err, ErrQueryTimeout)
+}
+
+func TestMemoryPrivacyRepository_DeletesOnlyTheUser(t *testing.T) {
+	events := NewMemoryEventRepository()
+	privacy := NewMemoryPrivacyRepository(events)
+	ts := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, events.CreateBatch(context.Background(), []model.Event{
+		{EventName: "click", Channel: "web", UserID: "u1", Timestamp: ts},
+		{EventName: "click", Channel: "web", UserID: "u2", Timestamp: ts},
+	}))
+
+	require.NoError(t, privacy.DeleteUserEvents(context.Background(), model.DefaultTenant, "u1"))
+
+	result, err := events.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "click", GroupBy: "channel"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), result.TotalCount)
+
+	pending, err := privacy.PendingUserDeletes(context.Background(), model.DefaultTenant, "u1")
+	require.NoError(t, err)
+	require.Zero(t, pending)
+}
+
+func TestMemoryLateEventRepository_KeptApartAndErased(t *testing.T) {
+	events := NewMemoryEventRepository()
+	late := NewMemoryLateEventRepository(events)
+	privacy := NewMemoryPrivacyRepository(events)
+	ts := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, late.CreateBatch(context.Background(), []model.Event{
+		{EventName: "click", Channel: "web", UserID: "u1", Timestamp: ts},
+		{EventName: "click", Channel: "web", UserID: "u2", Timestamp: ts},
+	}))
+
+	result, err := events.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "click", GroupBy: "channel"})
+	require.NoError(t, err)
+	require.Zero(t, result.TotalCount)
+
+	require.NoError(t, privacy.DeleteUserEvents(context.Background(), model.DefaultTenant, "u1"))
+	mem := events.(*memoryEventRepository)
+	require.Len(t, mem.late, 1)
+	require.Equal(t, "u2", mem.late[0].UserID)
+	require.False(t, mem.late[0].ReceivedAt.IsZero())
+
+	require.ErrorIs(t, NewMemoryLateEventRepository(nil).CreateBatch(context.Background(), nil), ErrNotSupported)
+}
+
+func TestMemoryPrivacyRepository_OtherEventRepository(t *testing.T) {
+	privacy := NewMemoryPrivacyRepository(nil)
+	require.ErrorIs(t, privacy.DeleteUserEvents(context.Background(), model.DefaultTenant, "u1"), ErrNotSupported)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"event-metrics-service/internal/model"
+)
+
+type memoryLateEventRepository struct {
+	events *memoryEventRepository
+}
+
+// NewMemoryLateEventRepository creates a LateEventRepository that keeps late
+// events next to the given in-memory event repository, so privacy erasure
+// covers them too. Late events are dropped for any other EventRepository.
+func NewMemoryLateEventRepository(events EventRepository) LateEventRepository {
+	mem, _ := events.(*memoryEventRepository)
+	return &memoryLateEventRepository{events: mem}
+}
+
+func (r *memoryLateEventRepository) CreateBatch(ctx context.Context, events []model.Event) error {
+	if r.events == nil {
+		return ErrNotSupported
+	}
+
+	stored := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		normalized, err := normalizeMemoryEvent(event)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, normalized)
+	}
+
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+
+	r.events.late = append(r.events.late, stored...)
+	return nil
+}
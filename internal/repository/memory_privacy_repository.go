@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"event-metrics-service/internal/model"
+)
+
+type memoryPrivacyRepository struct {
+	events *memoryEventRepository
+
+	mu    sync.Mutex
+	audit []model.PrivacyAuditEntry
+}
+
+// NewMemoryPrivacyRepository creates a PrivacyRepository that erases from the
+// given in-memory event repository and keeps the audit trail in memory.
+// Erasure is not supported for any other EventRepository.
+func NewMemoryPrivacyRepository(events EventRepository) PrivacyRepository {
+	mem, _ := events.(*memoryEventRepository)
+	return &memoryPrivacyRepository{events: mem}
+}
+
+func (r *memoryPrivacyRepository) DeleteUserEvents(ctx context.Context, tenantID, userID string) error {
+	if r.events == nil {
+		return ErrNotSupported
+	}
+
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+
+	tenantID = model.TenantOrDefault(tenantID)
+	for key, event := range r.events.events {
+		if event.TenantID == tenantID && event.UserID == userID {
+			delete(r.events.events, key)
+		}
+	}
+	r.events.late = slices.DeleteFunc(r.events.late, func(event model.Event) bool {
+		return event.TenantID == tenantID && event.UserID == userID
+	})
+	return nil
+}
+
+func (r *memoryPrivacyRepository) FetchUserLateEvents(ctx context.Context, tenantID, userID string) ([]model.Event, error) {
+	if r.events == nil {
+		return nil, ErrNotSupported
+	}
+
+	tenantID = model.TenantOrDefault(tenantID)
+	r.events.mu.RLock()
+	var events []model.Event
+	for _, event := range r.events.late {
+		if event.TenantID == tenantID && event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	r.events.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		return userEventBefore(events[j], cursorOf(events[i]))
+	})
+
+	out := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		copied, err := normalizeMemoryEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, copied)
+	}
+	return out, nil
+}
+
+func (r *memoryPrivacyRepository) PendingUserDeletes(ctx context.Context, tenantID, userID string) (int, error) {
+	return 0, nil
+}
+
+func (r *memoryPrivacyRepository) AppendAudit(ctx context.Context, entry model.PrivacyAuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.TenantID = model.TenantOrDefault(entry.TenantID)
+	r.audit = append(r.audit, entry)
+	return nil
+}
+
+func (r *memoryPrivacyRepository) ListAudit(ctx context.Context, requestID string) ([]model.PrivacyAuditEntry, error) {
+	r.mu.Lock()
+	entries := []model.PrivacyAuditEntry{}
+	for _, e := range r.audit {
+		if requestID == "" || e.RequestID == requestID {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].RequestID != entries[j].RequestID {
+			return entries[i].RequestID < entries[j].RequestID
+		}
+		return entries[i].At.Before(entries[j].At)
+	})
+	return entries, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"event-metrics-service/internal/model"
+)
+
+func (r *eventRepository) promotedColumn(key string) (model.PromotedColumn, bool) {
+	for _, col := range r.promoted {
+		if col.Key == key {
+			return col, true
+		}
+	}
+	return model.PromotedColumn{}, false
+}
+
+// metadataKeyExpr returns a String expression for a metadata key, reading the
+// promoted column when there is one and parsing the JSON otherwise.
+func (r *eventRepository) metadataKeyExpr(key string) (string, []any) {
+	if col, ok := r.promotedColumn(key); ok {
+		return fmt.Sprintf("ifNull(toString(%s), '')", col.Column()), nil
+	}
+	return "if(JSONType(metadata, ?) = 'String', JSONExtractString(metadata, ?), JSONExtractRaw(metadata, ?))",
+		[]any{key, key, key}
+}
+
+// metadataFilter returns an equality predicate on a metadata key. For JSON
+// values both the bare and the quoted form match, so "99.9" finds a number and
+// "TRY" finds a string.
+func (r *eventRepository) metadataFilter(key, value string) (string, []any) {
+	if col, ok := r.promotedColumn(key); ok {
+		return fmt.Sprintf("%s = ?", col.Column()), []any{value}
+	}
+
+	quoted, _ := json.Marshal(value)
+	return "JSONExtractRaw(metadata, ?) IN (?, ?)", []any{key, string(quoted), value}
+}
+
+// promotedValue converts a decoded metadata value to the Go type expected by
+// the promoted column. Missing or mismatched values are stored as NULL; the
+// original value stays available in the metadata JSON.
+func promotedValue(col model.PromotedColumn, raw any) any {
+	if raw == nil {
+		return nil
+	}
+
+	switch col.Type {
+	case "String", "LowCardinality(String)":
+		if s, ok := raw.(string); ok {
+			return s
+		}
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return nil
+		}
+		return string(b)
+	case "Bool":
+		if b, ok := raw.(bool); ok {
+			return b
+		}
+		return nil
+	case "Float32":
+		if f, ok := toFloat(raw); ok {
+			return float32(f)
+		}
+	case "Float64":
+		if f, ok := toFloat(raw); ok {
+			return f
+		}
+	case "Int32":
+		if i, ok := toInt(raw); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return int32(i)
+		}
+	case "Int64":
+		if i, ok := toInt(raw); ok {
+			return i
+		}
+	case "UInt32":
+		if i, ok := toInt(raw); ok && i >= 0 && i <= math.MaxUint32 {
+			return uint32(i)
+		}
+	case "UInt64":
+		if i, ok := toInt(raw); ok && i >= 0 {
+			return uint64(i)
+		}
+	}
+	return nil
+}
+
+func toFloat(raw any) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toInt(raw any) (int64, bool) {
+	switch v := raw.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := strconv.ParseInt(v.String(), 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// PartitionRepository exposes partition-level maintenance of the events table.
+type PartitionRepository interface {
+	// ListPartitions returns active partitions with their row counts and size.
+	ListPartitions(ctx context.Context) ([]model.Partition, error)
+
+	// DropPartition removes a single partition by its ID (YYYYMMDD).
+	DropPartition(ctx context.Context, id string) error
+
+	// OptimizePartition forces a final merge of a partition, collapsing
+	// duplicate events.
+	OptimizePartition(ctx context.Context, id string) error
+}
+
+// ErrNotSupported is returned by operations the configured storage backend
+// cannot perform.
+var ErrNotSupported = errors.New("not supported by the storage backend")
+
+type partitionRepository struct {
+	conn clickhouse.Conn
+}
+
+// NewPartitionRepository creates a PartitionRepository backed by ClickHouse.
+func NewPartitionRepository(conn clickhouse.Conn) PartitionRepository {
+	return &partitionRepository{conn: conn}
+}
+
+const listPartitionsQuery = `
+	SELECT partition_id, count(), sum(rows), sum(bytes_on_disk)
+	FROM system.parts
+	WHERE database = currentDatabase() AND table = 'events' AND active
+	GROUP BY partition_id
+	ORDER BY partition_id
+`
+
+const (
+	dropPartitionQuery     = `ALTER TABLE events DROP PARTITION ID ?`
+	optimizePartitionQuery = `OPTIMIZE TABLE events PARTITION ID ? FINAL`
+)
+
+func (r *partitionRepository) ListPartitions(ctx context.Context) ([]model.Partition, error) {
+	rows, err := r.conn.Query(ctx, listPartitionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []model.Partition
+	for rows.Next() {
+		var p model.Partition
+		if err := rows.Scan(&p.ID, &p.Parts, &p.Rows, &p.BytesOnDisk); err != nil {
+			return nil, fmt.Errorf("scan partition: %w", err)
+		}
+		if day, err := time.Parse("20060102", p.ID); err == nil {
+			p.Date = day.Format("2006-01-02")
+		}
+		partitions = append(partitions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate partitions: %w", err)
+	}
+	return partitions, nil
+}
+
+func (r *partitionRepository) DropPartition(ctx context.Context, id string) error {
+	if err := r.conn.Exec(ctx, dropPartitionQuery, id); err != nil {
+		return fmt.Errorf("drop partition %s: %w", id, err)
+	}
+	return nil
+}
+
+func (r *partitionRepository) OptimizePartition(ctx context.Context, id string) error {
+	if err := r.conn.Exec(ctx, optimizePartitionQuery, id); err != nil {
+		return fmt.Errorf("optimize partition %s: %w", id, err)
+	}
+	return nil
+}
+
+type unsupportedPartitionRepository struct{}
+
+// NewUnsupportedPartitionRepository returns a PartitionRepository for backends
+// without ClickHouse-style partitions; every call fails with ErrNotSupported.
+func NewUnsupportedPartitionRepository() PartitionRepository {
+	return unsupportedPartitionRepository{}
+}
+
+func (unsupportedPartitionRepository) ListPartitions(context.Context) ([]model.Partition, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedPartitionRepository) DropPartition(context.Context, string) error {
+	return ErrNotSupported
+}
+
+func (unsupportedPartitionRepository) OptimizePartition(context.Context, string) error {
+	return ErrNotSupported
+}
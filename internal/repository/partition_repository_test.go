@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PartitionRepositoryTestSuite struct {
+	suite.Suite
+
+	repository *partitionRepository
+	connMock   *mockclickhouseconnection.Connection
+}
+
+func TestPartitionRepository(t *testing.T) {
+	suite.Run(t, new(PartitionRepositoryTestSuite))
+}
+
+func (s *PartitionRepositoryTestSuite) SetupTest() {
+	s.connMock = &mockclickhouseconnection.Connection{}
+	s.repository = &partitionRepository{conn: s.connMock}
+}
+
+func (s *PartitionRepositoryTestSuite) TearDownTest() {
+	s.connMock.AssertExpectations(s.T())
+}
+
+func (s *PartitionRepositoryTestSuite) TestListPartitions_Success() {
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, listPartitionsQuery, []any(nil)).Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "20250101"
+		*args.Get(1).(*uint64) = 2
+		*args.Get(2).(*uint64) = 1500
+		*args.Get(3).(*uint64) = 4096
+	}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	partitions, err := s.repository.ListPartitions(context.Background())
+
+	s.NoError(err)
+	s.Equal([]model.Partition{
+		{ID: "20250101", Date: "2025-01-01", Parts: 2, Rows: 1500, BytesOnDisk: 4096},
+	}, partitions)
+	rows.AssertExpectations(s.T())
+}
+
+func (s *PartitionRepositoryTestSuite) TestDropPartition_Success() {
+	s.connMock.On("Exec", mock.Anything, dropPartitionQuery, "20250101").Return(nil).Once()
+
+	err := s.repository.DropPartition(context.Background(), "20250101")
+	s.NoError(err)
+}
+
+func (s *PartitionRepositoryTestSuite) TestDropPartition_Error() {
+	expectedErr := errors.New("exec error")
+	s.connMock.On("Exec", mock.Anything, dropPartitionQuery, "20250101").Return(expectedErr).Once()
+
+	err := s.repository.DropPartition(context.Background(), "20250101")
+	s.ErrorIs(err, expectedErr)
+	s.ErrorContains(err, "drop partition 20250101")
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type postgresAuditRepository struct {
+	conn PostgresConn
+}
+
+// NewPostgresAuditRepository creates an AuditRepository backed by
+// PostgreSQL.
+func NewPostgresAuditRepository(conn PostgresConn) AuditRepository {
+	return &postgresAuditRepository{conn: conn}
+}
+
+var postgresAuditColumns = []string{"at", "principal", "tenant_id", "action", "path", "client_ip", "filter", "duration_ms", "rows_read", "status"}
+
+const selectPostgresAuditQuery = `SELECT at, principal, tenant_id, action, path, client_ip, filter, duration_ms, rows_read, status FROM audit_log`
+
+func (r *postgresAuditRepository) InsertAudit(ctx context.Context, records []model.AuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(records))
+	for _, rec := range records {
+		rows = append(rows, []any{rec.At, rec.Principal, rec.TenantID, rec.Action, rec.Path, rec.ClientIP,
+			rec.Filter, int64(rec.DurationMs), int64(rec.RowsRead), rec.Status})
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin audit batch: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"audit_log"}, postgresAuditColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy audit batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit audit batch: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresAuditRepository) SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	where, args := auditConditions(query, func(n int) string { return "$" + strconv.Itoa(n) })
+	sql := selectPostgresAuditQuery + where + fmt.Sprintf(" ORDER BY at DESC LIMIT %d", query.Limit)
+
+	rows, err := r.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	records := []model.AuditRecord{}
+	for rows.Next() {
+		var rec model.AuditRecord
+		var durationMs, rowsRead int64
+		if err := rows.Scan(&rec.At, &rec.Principal, &rec.TenantID, &rec.Action, &rec.Path, &rec.ClientIP,
+			&rec.Filter, &durationMs, &rowsRead, &rec.Status); err != nil {
+			return nil, fmt.Errorf("scan audit log: %w", err)
+		}
+		rec.At = rec.At.UTC()
+		rec.DurationMs = uint64(durationMs)
+		rec.RowsRead = uint64(rowsRead)
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit log: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgresConn is the subset of *pgxpool.Pool used by the PostgreSQL
+// repository.
+type PostgresConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type postgresEventRepository struct {
+	conn PostgresConn
+}
+
+// NewPostgresEventRepository creates an EventRepository backed by PostgreSQL.
+// Duplicates are dropped on insert via the idempotency_key unique constraint.
+func NewPostgresEventRepository(conn PostgresConn) EventRepository {
+	return &postgresEventRepository{conn: conn}
+}
+
+const insertPostgresEventQuery = `
+	INSERT INTO events (tenant_id, event_name, channel, campaign_id, user_id, ts, tags, metadata, received_at, idempotency_key)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (idempotency_key) DO NOTHING
+`
+
+const (
+	createPostgresStagingQuery = `CREATE TEMP TABLE events_staging (LIKE events INCLUDING DEFAULTS) ON COMMIT DROP`
+	flushPostgresStagingQuery  = `INSERT INTO events SELECT * FROM events_staging ON CONFLICT (idempotency_key) DO NOTHING`
+)
+
+var postgresCopyColumns = []string{"tenant_id", "event_name", "channel", "campaign_id", "user_id", "ts", "tags", "metadata", "received_at", "idempotency_key"}
+
+func (r *postgresEventRepository) Create(ctx context.Context, event model.Event) error {
+	values, err := postgresRowValues(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn.Exec(ctx, insertPostgresEventQuery, values...)
+	return err
+}
+
+// CreateBatch streams events into a temporary staging table with COPY and
+// moves them into events in one statement, because COPY itself cannot skip
+// rows that violate the unique constraint.
+func (r *postgresEventRepository) CreateBatch(ctx context.Context, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(events))
+	for _, event := range events {
+		values, err := postgresRowValues(event)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, values)
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin batch: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if _, err := tx.Exec(ctx, createPostgresStagingQuery); err != nil {
+		return fmt.Errorf("create staging table: %w", err)
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"events_staging"}, postgresCopyColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy batch: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, flushPostgresStagingQuery); err != nil {
+		return fmt.Errorf("flush batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit batch: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresEventRepository) FetchMetrics(ctx context.Context, filter model.MetricsFilter) (model.MetricsResult, error) {
+	query, args, err := buildPostgresMetricsQuery(filter)
+	if err != nil {
+		return model.MetricsResult{}, err
+	}
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return model.MetricsResult{}, fmt.Errorf("query metrics: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var result model.MetricsResult
+	for rows.Next() {
+		var key *string
+		var total, unique int64
+		var isTotal int32
+		if err := rows.Scan(&key, &total, &unique, &isTotal); err != nil {
+			return model.MetricsResult{}, fmt.Errorf("scan group: %w", err)
+		}
+
+		if isTotal == 1 {
+			result.TotalCount = uint64(total)
+			result.UniqueCount = uint64(unique)
+			continue
+		}
+
+		group := model.MetricsGroup{TotalCount: uint64(total), UniqueUserCount: uint64(unique)}
+		if key != nil {
+			group.Key = *key
+		}
+		result.Groups = append(result.Groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return model.MetricsResult{}, fmt.Errorf("iterate groups: %w", classifyPostgresError(err))
+	}
+	return result, nil
+}
+
+// postgresArgs collects positional arguments and hands out $n placeholders.
+type postgresArgs []any
+
+func (a *postgresArgs) add(value any) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// buildPostgresMetricsQuery mirrors the ClickHouse WITH TOTALS query: the
+// empty grouping set yields the overall counts in the same scan, flagged by
+// GROUPING(k) = 1 and sorted after the groups.
+func buildPostgresMetricsQuery(filter model.MetricsFilter) (string, []any, error) {
+	var args postgresArgs
+
+	var keyExpr string
+	switch {
+	case filter.GroupBy == "channel":
+		keyExpr = "channel"
+	case filter.GroupBy == "hour":
+		keyExpr = `to_char(ts AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:00:00"Z"')`
+	case filter.GroupBy == "day":
+		keyExpr = `to_char(ts AT TIME ZONE 'UTC', 'YYYY-MM-DD')`
+	case strings.HasPrefix(filter.GroupBy, model.MetadataGroupPrefix):
+		key := strings.TrimPrefix(filter.GroupBy, model.MetadataGroupPrefix)
+		if !model.MetadataKeyPattern.MatchString(key) {
+			return "", nil, fmt.Errorf("unsupported group_by: %s", filter.GroupBy)
+		}
+		keyExpr = fmt.Sprintf("COALESCE(metadata ->> %s, '')", args.add(key))
+	default:
+		return "", nil, fmt.Errorf("unsupported group_by: %s", filter.GroupBy)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT k, COUNT(*), COUNT(DISTINCT user_id), GROUPING(k) FROM (SELECT %s AS k, user_id FROM events WHERE %s) AS e GROUP BY GROUPING SETS ((k), ()) ORDER BY GROUPING(k), k",
+		keyExpr, postgresWhere(filter, &args))
+
+	return query, args, nil
+}
+
+// postgresWhere renders the metrics filter as a WHERE condition, adding its
+// values to args.
+func postgresWhere(filter model.MetricsFilter, args *postgresArgs) string {
+	whereParts := []string{
+		"tenant_id = " + args.add(model.TenantOrDefault(filter.TenantID)),
+		"event_name = " + args.add(filter.EventName),
+	}
+
+	if !filter.From.IsZero() {
+		whereParts = append(whereParts, "ts >= "+args.add(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		whereParts = append(whereParts, "ts <= "+args.add(filter.To))
+	}
+
+	if filter.Channel != nil && *filter.Channel != "" {
+		whereParts = append(whereParts, "channel = "+args.add(*filter.Channel))
+	}
+
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// Like the ClickHouse filter, match the bare value as well as its raw
+		// JSON form so "99.9" finds a number and "\"TRY\"" a string.
+		k, v := args.add(key), args.add(filter.Metadata[key])
+		whereParts = append(whereParts, fmt.Sprintf("(metadata ->> %s = %s OR (metadata -> %s)::text = %s)", k, v, k, v))
+	}
+
+	return strings.Join(whereParts, " AND ")
+}
+
+func postgresRowValues(event model.Event) ([]any, error) {
+	metadata, err := marshalMetadata(event.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := event.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	ts := event.Timestamp.UTC().Truncate(time.Millisecond)
+	tenantID := model.TenantOrDefault(event.TenantID)
+	return []any{
+		tenantID,
+		event.EventName,
+		event.Channel,
+		nullIfEmpty(event.CampaignID),
+		event.UserID,
+		ts,
+		tags,
+		metadata,
+		receivedAt(event).Truncate(time.Millisecond),
+		idempotencyKey(tenantID, event.EventName, ts, event.UserID, event.Channel, event.CampaignID),
+	}, nil
+}
+
+// idempotencyKey hashes the same columns ClickHouse sorts (and therefore
+// deduplicates) events by, at the same millisecond precision. The default
+// tenant is left out so keys of rows written before tenants existed stay
+// valid.
+func idempotencyKey(tenantID, eventName string, ts time.Time, userID, channel, campaignID string) string {
+	parts := []string{eventName, strconv.FormatInt(ts.UnixMilli(), 10), userID, channel, campaignID}
+	if tenantID != model.DefaultTenant {
+		parts = append([]string{tenantID}, parts...)
+	}
+
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// classifyPostgresError maps cancellations and resource errors to the shared
+// sentinel errors.
+func classifyPostgresError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case "57014": // query_canceled, raised by statement_timeout
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	case "53200", "54000": // out_of_memory, program_limit_exceeded
+		return fmt.Errorf("%w: %w", ErrQueryLimitExceeded, err)
+	default:
+		return err
+	}
+}
+
+const postgresUserEventCountsQuery = `
+	SELECT event_name, COUNT(*), MIN(ts), MAX(ts)
+	FROM events
+	WHERE tenant_id = $1 AND user_id = $2 AND ts >= $3 AND ts <= $4
+	GROUP BY event_name
+	ORDER BY event_name
+`
+
+func (r *postgresEventRepository) FetchUserEvents(ctx context.Context, filter model.UserEventsFilter) ([]model.Event, error) {
+	var args postgresArgs
+	whereParts := []string{
+		"tenant_id = " + args.add(model.TenantOrDefault(filter.TenantID)),
+		"user_id = " + args.add(filter.UserID),
+		"ts >= " + args.add(filter.From),
+		"ts <= " + args.add(filter.To),
+	}
+
+	if filter.EventName != "" {
+		whereParts = append(whereParts, "event_name = "+args.add(filter.EventName))
+	}
+
+	if after := filter.After; after != nil {
+		whereParts = append(whereParts, fmt.Sprintf("(ts, event_name, channel, COALESCE(campaign_id, '')) < (%s, %s, %s, %s)",
+			args.add(after.Timestamp), args.add(after.EventName), args.add(after.Channel), args.add(after.CampaignID)))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM events WHERE %s "+
+			"ORDER BY ts DESC, event_name DESC, channel DESC, COALESCE(campaign_id, '') DESC LIMIT %s",
+		postgresEventColumns, strings.Join(whereParts, " AND "), args.add(filter.Limit))
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query user events: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	events := []model.Event{}
+	for rows.Next() {
+		event, err := scanPostgresEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user events: %w", classifyPostgresError(err))
+	}
+	return events, nil
+}
+
+func (r *postgresEventRepository) FetchUserEventCounts(ctx context.Context, tenantID, userID string, from, to time.Time) ([]model.UserEventCount, error) {
+	rows, err := r.conn.Query(ctx, postgresUserEventCountsQuery, model.TenantOrDefault(tenantID), userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query user event counts: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	counts := []model.UserEventCount{}
+	for rows.Next() {
+		var c model.UserEventCount
+		var count int64
+		if err := rows.Scan(&c.EventName, &count, &c.FirstSeen, &c.LastSeen); err != nil {
+			return nil, fmt.Errorf("scan user event count: %w", err)
+		}
+		c.Count = uint64(count)
+		c.FirstSeen = c.FirstSeen.UTC()
+		c.LastSeen = c.LastSeen.UTC()
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user event counts: %w", classifyPostgresError(err))
+	}
+	return counts, nil
+}
+
+// Rows written before received_at existed fall back to their insert time.
+const postgresEventColumns = "event_name, channel, COALESCE(campaign_id, ''), user_id, ts, tags, metadata, COALESCE(received_at, ingested_at)"
+
+// ExportEvents streams matching events in timestamp order. pgx reads rows off
+// the wire as they are consumed, so the result set is never held in memory.
+func (r *postgresEventRepository) ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error {
+	var args postgresArgs
+	query := fmt.Sprintf("SELECT %s FROM events WHERE %s ORDER BY ts", postgresEventColumns, postgresWhere(filter, &args))
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query export: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanPostgresEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate export: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+// scanPostgresEvent reads a row selected with postgresEventColumns.
+func scanPostgresEvent(rows pgx.Rows) (model.Event, error) {
+	var event model.Event
+	var metadata []byte
+	if err := rows.Scan(&event.EventName, &event.Channel, &event.CampaignID, &event.UserID, &event.Timestamp, &event.Tags, &metadata, &event.ReceivedAt); err != nil {
+		return model.Event{}, fmt.Errorf("scan event: %w", err)
+	}
+
+	var err error
+	if event.Metadata, err = unmarshalMetadata(string(metadata)); err != nil {
+		return model.Event{}, err
+	}
+	event.Timestamp = event.Timestamp.UTC()
+	event.ReceivedAt = event.ReceivedAt.UTC()
+	return event, nil
+}
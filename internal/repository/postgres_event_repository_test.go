@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockpostgresconnection"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PostgresEventRepositoryTestSuite struct {
+	suite.Suite
+
+	repository *postgresEventRepository
+	connMock   *mockpostgresconnection.Connection
+}
+
+func TestPostgresEventRepository(t *testing.T) {
+	suite.Run(t, new(PostgresEventRepositoryTestSuite))
+}
+
+func (s *PostgresEventRepositoryTestSuite) SetupTest() {
+	s.connMock = &mockpostgresconnection.Connection{}
+	s.repository = &postgresEventRepository{conn: s.connMock}
+}
+
+func (s *PostgresEventRepositoryTestSuite) TearDownTest() {
+	s.connMock.AssertExpectations(s.T())
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestCreate_Success() {
+	ts := time.Date(2025, 1, 1, 10, 0, 0, 123456789, time.UTC)
+	event := model.Event{
+		EventName:  "product_view",
+		Channel:    "web",
+		UserID:     "user-1",
+		Timestamp:  ts,
+		ReceivedAt: ts.Add(time.Minute),
+		Metadata:   map[string]any{"price": 99.9},
+	}
+	truncated := ts.Truncate(time.Millisecond)
+
+	s.connMock.On(
+		"Exec",
+		mock.Anything,
+		insertPostgresEventQuery,
+		model.DefaultTenant,
+		"product_view",
+		"web",
+		nil, // campaign_id
+		"user-1",
+		truncated,
+		[]string{}, // nil tags become an empty array
+		`{"price":99.9}`,
+		truncated.Add(time.Minute), // received_at
+		idempotencyKey(model.DefaultTenant, "product_view", truncated, "user-1", "web", ""),
+	).Return(nil).Once()
+
+	s.NoError(s.repository.Create(context.Background(), event))
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestCreateBatch_EmptySlice_NoOp() {
+	s.NoError(s.repository.CreateBatch(context.Background(), nil))
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestCreateBatch_BeginError() {
+	s.connMock.On("Begin", mock.Anything).Return(nil, errors.New("pool closed")).Once()
+
+	err := s.repository.CreateBatch(context.Background(), []model.Event{{EventName: "click"}})
+	s.ErrorContains(err, "begin batch: pool closed")
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestFetchMetrics_QueryErrorIsClassified() {
+	s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &pgconn.PgError{Code: "57014"}).Once()
+
+	_, err := s.repository.FetchMetrics(context.Background(), model.MetricsFilter{EventName: "click", GroupBy: "channel"})
+	s.ErrorIs(err, ErrQueryTimeout)
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestBuildMetricsQuery_GroupByModes() {
+	tests := []struct {
+		groupBy string
+		keyExpr string
+	}{
+		{"channel", "channel"},
+		{"hour", `to_char(ts AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:00:00"Z"')`},
+		{"day", `to_char(ts AT TIME ZONE 'UTC', 'YYYY-MM-DD')`},
+	}
+
+	for _, tt := range tests {
+		query, args, err := buildPostgresMetricsQuery(model.MetricsFilter{EventName: "click", GroupBy: tt.groupBy})
+		s.Require().NoError(err, tt.groupBy)
+		s.Equal(
+			"SELECT k, COUNT(*), COUNT(DISTINCT user_id), GROUPING(k) FROM (SELECT "+tt.keyExpr+
+				" AS k, user_id FROM events WHERE tenant_id = $1 AND event_name = $2) AS e GROUP BY GROUPING SETS ((k), ()) ORDER BY GROUPING(k), k",
+			query, tt.groupBy)
+		s.Equal([]any{model.DefaultTenant, "click"}, args, tt.groupBy)
+	}
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestBuildMetricsQuery_FiltersAndMetadataGroup() {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	channel := "web"
+
+	query, args, err := buildPostgresMetricsQuery(model.MetricsFilter{
+		TenantID:  "acme",
+		EventName: "purchase",
+		From:      from,
+		To:        to,
+		Channel:   &channel,
+		GroupBy:   "metadata.currency",
+		Metadata:  map[string]string{"source": "seo"},
+	})
+	s.Require().NoError(err)
+	s.Equal(
+		"SELECT k, COUNT(*), COUNT(DISTINCT user_id), GROUPING(k) FROM (SELECT COALESCE(metadata ->> $1, '') AS k, user_id FROM events "+
+			"WHERE tenant_id = $2 AND event_name = $3 AND ts >= $4 AND ts <= $5 AND channel = $6 AND (metadata ->> $7 = $8 OR (metadata -> $7)::text = $8)) AS e "+
+			"GROUP BY GROUPING SETS ((k), ()) ORDER BY GROUPING(k), k",
+		query)
+	s.Equal([]any{"currency", "acme", "purchase", from, to, "web", "source", "seo"}, args)
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestBuildMetricsQuery_UnsupportedGroupBy() {
+	_, _, err := buildPostgresMetricsQuery(model.MetricsFilter{EventName: "click", GroupBy: "metadata.a;b"})
+	s.ErrorContains(err, "unsupported group_by")
+}
+
+func (s *PostgresEventRepositoryTestSuite) TestIdempotencyKey_MatchesSortingKey() {
+	ts := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	key := idempotencyKey(model.DefaultTenant, "click", ts, "u1", "web", "")
+
+	s.Equal(key, idempotencyKey(model.DefaultTenant, "click", ts, "u1", "web", ""))
+	s.NotEqual(key, idempotencyKey(model.DefaultTenant, "click", ts.Add(time.Millisecond), "u1", "web", ""))
+	s.NotEqual(key, idempotencyKey(model.DefaultTenant, "click", ts, "u1", "web", "cmp"))
+	s.NotEqual(key, idempotencyKey("acme", "click", ts, "u1", "web", ""))
+	// Field boundaries are delimited, so shifting characters between fields
+	// changes the key.
+	s.NotEqual(idempotencyKey(model.DefaultTenant, "ab", ts, "c", "", ""), idempotencyKey(model.DefaultTenant, "a", ts, "bc", "", ""))
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type postgresLateEventRepository struct {
+	conn PostgresConn
+}
+
+// NewPostgresLateEventRepository creates a LateEventRepository backed by
+// PostgreSQL.
+func NewPostgresLateEventRepository(conn PostgresConn) LateEventRepository {
+	return &postgresLateEventRepository{conn: conn}
+}
+
+var postgresLateEventColumns = []string{"tenant_id", "event_name", "channel", "campaign_id", "user_id", "ts", "tags", "metadata", "received_at"}
+
+func (r *postgresLateEventRepository) CreateBatch(ctx context.Context, events []model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(events))
+	for _, event := range events {
+		metadata, err := marshalMetadata(event.Metadata)
+		if err != nil {
+			return err
+		}
+		tags := event.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		rows = append(rows, []any{
+			model.TenantOrDefault(event.TenantID),
+			event.EventName,
+			event.Channel,
+			nullIfEmpty(event.CampaignID),
+			event.UserID,
+			event.Timestamp.UTC().Truncate(time.Millisecond),
+			tags,
+			metadata,
+			receivedAt(event).Truncate(time.Millisecond),
+		})
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin late batch: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"late_events"}, postgresLateEventColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy late batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit late batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"event-metrics-service/internal/model"
+)
+
+type postgresPrivacyRepository struct {
+	conn PostgresConn
+}
+
+// NewPostgresPrivacyRepository creates a PrivacyRepository backed by
+// PostgreSQL. Deletes are transactional, so nothing is ever pending.
+func NewPostgresPrivacyRepository(conn PostgresConn) PrivacyRepository {
+	return &postgresPrivacyRepository{conn: conn}
+}
+
+const (
+	insertPostgresPrivacyAuditQuery = `
+	INSERT INTO privacy_audit (request_id, request_type, tenant_id, user_id, status, actor, detail, at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+	selectPostgresPrivacyAuditQuery = `SELECT request_id, request_type, tenant_id, user_id, status, actor, detail, at FROM privacy_audit`
+
+	// late_events has no ingested_at, so received_at is selected as is.
+	selectPostgresUserLateEventsQuery = `
+	SELECT event_name, channel, COALESCE(campaign_id, ''), user_id, ts, tags, metadata, received_at
+	FROM late_events
+	WHERE tenant_id = $1 AND user_id = $2
+	ORDER BY ts DESC, event_name DESC, channel DESC, COALESCE(campaign_id, '') DESC
+`
+)
+
+func (r *postgresPrivacyRepository) DeleteUserEvents(ctx context.Context, tenantID, userID string) error {
+	for _, table := range erasureTables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE tenant_id = $1 AND user_id = $2", table)
+		if _, err := r.conn.Exec(ctx, query, model.TenantOrDefault(tenantID), userID); err != nil {
+			return fmt.Errorf("delete user events from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (r *postgresPrivacyRepository) FetchUserLateEvents(ctx context.Context, tenantID, userID string) ([]model.Event, error) {
+	rows, err := r.conn.Query(ctx, selectPostgresUserLateEventsQuery, model.TenantOrDefault(tenantID), userID)
+	if err != nil {
+		return nil, fmt.Errorf("query user late events: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	events := []model.Event{}
+	for rows.Next() {
+		event, err := scanPostgresEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user late events: %w", classifyPostgresError(err))
+	}
+	return events, nil
+}
+
+func (r *postgresPrivacyRepository) PendingUserDeletes(ctx context.Context, tenantID, userID string) (int, error) {
+	return 0, nil
+}
+
+func (r *postgresPrivacyRepository) AppendAudit(ctx context.Context, entry model.PrivacyAuditEntry) error {
+	_, err := r.conn.Exec(ctx, insertPostgresPrivacyAuditQuery,
+		entry.RequestID, entry.Type, model.TenantOrDefault(entry.TenantID), entry.UserID, entry.Status, entry.Actor, entry.Detail, entry.At)
+	if err != nil {
+		return fmt.Errorf("insert privacy audit: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresPrivacyRepository) ListAudit(ctx context.Context, requestID string) ([]model.PrivacyAuditEntry, error) {
+	query := selectPostgresPrivacyAuditQuery
+	var args []any
+	if requestID != "" {
+		query += " WHERE request_id = $1"
+		args = append(args, requestID)
+	}
+	query += " ORDER BY request_id, at"
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query privacy audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.PrivacyAuditEntry{}
+	for rows.Next() {
+		var e model.PrivacyAuditEntry
+		if err := rows.Scan(&e.RequestID, &e.Type, &e.TenantID, &e.UserID, &e.Status, &e.Actor, &e.Detail, &e.At); err != nil {
+			return nil, fmt.Errorf("scan privacy audit: %w", err)
+		}
+		e.At = e.At.UTC()
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate privacy audit: %w", err)
+	}
+	return entries, nil
+}
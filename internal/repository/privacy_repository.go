@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// PrivacyRepository removes a user's data and keeps the audit trail of
+// privacy requests.
+type PrivacyRepository interface {
+	// DeleteUserEvents starts removing every stored event of the user within
+	// the tenant. On ClickHouse this submits a mutation that completes in the
+	// background.
+	DeleteUserEvents(ctx context.Context, tenantID, userID string) error
+
+	// FetchUserLateEvents returns every event of the user within the tenant
+	// that was stored apart as late, newest first. Access requests export
+	// them together with the user's timeline.
+	FetchUserLateEvents(ctx context.Context, tenantID, userID string) ([]model.Event, error)
+
+	// PendingUserDeletes returns how many submitted deletes of the user's
+	// events within the tenant have not finished yet.
+	PendingUserDeletes(ctx context.Context, tenantID, userID string) (int, error)
+
+	// AppendAudit records a status change of a privacy request.
+	AppendAudit(ctx context.Context, entry model.PrivacyAuditEntry) error
+
+	// ListAudit returns the audit entries of one request, or of all requests
+	// when requestID is empty, ordered by request and time.
+	ListAudit(ctx context.Context, requestID string) ([]model.PrivacyAuditEntry, error)
+}
+
+// Delete modes for ClickHouse erasure.
+const (
+	// DeleteModeMutation rewrites the affected parts with ALTER TABLE ...
+	// DELETE. Rows disappear once the mutation has finished.
+	DeleteModeMutation = "mutation"
+
+	// DeleteModeLightweight masks rows immediately with DELETE FROM and
+	// purges them from disk during later merges.
+	DeleteModeLightweight = "lightweight"
+)
+
+// erasureTables lists every table holding per-user rows. Tables added later
+// (rollups, side tables) must be added here so erasure covers them.
+var erasureTables = []string{"events", "late_events"}
+
+type privacyRepository struct {
+	conn       clickhouse.Conn
+	deleteMode string
+}
+
+// NewPrivacyRepository creates a PrivacyRepository backed by ClickHouse.
+func NewPrivacyRepository(conn clickhouse.Conn, deleteMode string) PrivacyRepository {
+	return &privacyRepository{conn: conn, deleteMode: deleteMode}
+}
+
+const (
+	insertPrivacyAuditQuery = `
+	INSERT INTO privacy_audit (request_id, request_type, tenant_id, user_id, status, actor, detail, at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+	selectPrivacyAuditQuery = `SELECT request_id, request_type, tenant_id, user_id, status, actor, detail, at FROM privacy_audit`
+
+	selectUserLateEventsQuery = `SELECT ` + eventColumns + ` FROM late_events WHERE tenant_id = ? AND user_id = ? ` + userEventsOrder
+
+	// Mutations are listed by their formatted command, which carries the
+	// tenant_id and user_id predicates as quoted literals. Each is matched on
+	// its own because ClickHouse may add parentheses around AND operands.
+	pendingUserDeletesQuery = `
+	SELECT count()
+	FROM system.mutations
+	WHERE database = currentDatabase() AND table IN ? AND NOT is_done
+		AND position(command, ?) > 0 AND position(command, ?) > 0
+`
+)
+
+func (r *privacyRepository) DeleteUserEvents(ctx context.Context, tenantID, userID string) error {
+	for _, table := range erasureTables {
+		query := fmt.Sprintf("ALTER TABLE %s DELETE WHERE tenant_id = ? AND user_id = ?", table)
+		if r.deleteMode == DeleteModeLightweight {
+			query = fmt.Sprintf("DELETE FROM %s WHERE tenant_id = ? AND user_id = ?", table)
+		}
+
+		if err := r.conn.Exec(ctx, query, model.TenantOrDefault(tenantID), userID); err != nil {
+			return fmt.Errorf("delete user events from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (r *privacyRepository) FetchUserLateEvents(ctx context.Context, tenantID, userID string) ([]model.Event, error) {
+	rows, err := r.conn.Query(ctx, selectUserLateEventsQuery, model.TenantOrDefault(tenantID), userID)
+	if err != nil {
+		return nil, fmt.Errorf("query user late events: %w", classifyQueryError(err))
+	}
+	defer rows.Close()
+
+	events := []model.Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user late events: %w", classifyQueryError(err))
+	}
+	return events, nil
+}
+
+func (r *privacyRepository) PendingUserDeletes(ctx context.Context, tenantID, userID string) (int, error) {
+	rows, err := r.conn.Query(ctx, pendingUserDeletesQuery, erasureTables,
+		equalsPredicate("tenant_id", model.TenantOrDefault(tenantID)), equalsPredicate("user_id", userID))
+	if err != nil {
+		return 0, fmt.Errorf("query pending deletes: %w", err)
+	}
+	defer rows.Close()
+
+	var pending uint64
+	if rows.Next() {
+		if err := rows.Scan(&pending); err != nil {
+			return 0, fmt.Errorf("scan pending deletes: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate pending deletes: %w", err)
+	}
+	return int(pending), nil
+}
+
+var clickhouseStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// equalsPredicate is a column = 'value' condition as ClickHouse prints it
+// in system.mutations.command. The closing quote keeps a value from matching
+// a longer one that starts with it.
+func equalsPredicate(column, value string) string {
+	return column + " = '" + clickhouseStringEscaper.Replace(value) + "'"
+}
+
+func (r *privacyRepository) AppendAudit(ctx context.Context, entry model.PrivacyAuditEntry) error {
+	err := r.conn.Exec(ctx, insertPrivacyAuditQuery,
+		entry.RequestID, entry.Type, model.TenantOrDefault(entry.TenantID), entry.UserID, entry.Status, entry.Actor, entry.Detail, entry.At)
+	if err != nil {
+		return fmt.Errorf("insert privacy audit: %w", err)
+	}
+	return nil
+}
+
+func (r *privacyRepository) ListAudit(ctx context.Context, requestID string) ([]model.PrivacyAuditEntry, error) {
+	query := selectPrivacyAuditQuery
+	var args []any
+	if requestID != "" {
+		query += " WHERE request_id = ?"
+		args = append(args, requestID)
+	}
+	query += " ORDER BY request_id, at"
+
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query privacy audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.PrivacyAuditEntry{}
+	for rows.Next() {
+		var e model.PrivacyAuditEntry
+		if err := rows.Scan(&e.RequestID, &e.Type, &e.TenantID, &e.UserID, &e.Status, &e.Actor, &e.Detail, &e.At); err != nil {
+			return nil, fmt.Errorf("scan privacy audit: %w", err)
+		}
+		e.At = e.At.UTC()
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate privacy audit: %w", err)
+	}
+	return entries, nil
+}
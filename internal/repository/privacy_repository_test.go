@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockclickhouseconnection"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PrivacyRepositoryTestSuite struct {
+	suite.Suite
+
+	connMock *mockclickhouseconnection.Connection
+}
+
+func TestPrivacyRepository(t *testing.T) {
+	suite.Run(t, new(PrivacyRepositoryTestSuite))
+}
+
+func (s *PrivacyRepositoryTestSuite) SetupTest() {
+	s.connMock = &mockclickhouseconnection.Connection{}
+}
+
+func (s *PrivacyRepositoryTestSuite) TearDownTest() {
+	s.connMock.AssertExpectations(s.T())
+}
+
+func (s *PrivacyRepositoryTestSuite) TestDeleteUserEvents_Mutation() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeMutation)
+	s.connMock.On("Exec", mock.Anything, "ALTER TABLE events DELETE WHERE tenant_id = ? AND user_id = ?", "acme", "u1").Return(nil).Once()
+	s.connMock.On("Exec", mock.Anything, "ALTER TABLE late_events DELETE WHERE tenant_id = ? AND user_id = ?", "acme", "u1").Return(nil).Once()
+
+	s.NoError(repo.DeleteUserEvents(context.Background(), "acme", "u1"))
+}
+
+func (s *PrivacyRepositoryTestSuite) TestDeleteUserEvents_Lightweight() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeLightweight)
+	s.connMock.On("Exec", mock.Anything, "DELETE FROM events WHERE tenant_id = ? AND user_id = ?", model.DefaultTenant, "u1").Return(nil).Once()
+	s.connMock.On("Exec", mock.Anything, "DELETE FROM late_events WHERE tenant_id = ? AND user_id = ?", model.DefaultTenant, "u1").Return(nil).Once()
+
+	s.NoError(repo.DeleteUserEvents(context.Background(), "", "u1"))
+}
+
+func (s *PrivacyRepositoryTestSuite) TestDeleteUserEvents_Error() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeMutation)
+	expectedErr := errors.New("exec error")
+	s.connMock.On("Exec", mock.Anything, mock.Anything, model.DefaultTenant, "u1").Return(expectedErr).Once()
+
+	err := repo.DeleteUserEvents(context.Background(), model.DefaultTenant, "u1")
+	s.ErrorIs(err, expectedErr)
+	s.ErrorContains(err, "delete user events from events")
+}
+
+func (s *PrivacyRepositoryTestSuite) TestFetchUserLateEvents() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeMutation)
+	ts := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything,
+		"SELECT "+eventColumns+" FROM late_events WHERE tenant_id = ? AND user_id = ? "+userEventsOrder, []any{"acme", "u1"}).
+		Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = "purchase"
+			*args.Get(1).(*string) = "web"
+			*args.Get(3).(*string) = "u1"
+			*args.Get(4).(*time.Time) = ts
+			*args.Get(5).(*[]string) = []string{}
+			*args.Get(6).(*string) = `{"price":9.5}`
+			*args.Get(7).(*time.Time) = ts.Add(48 * time.Hour)
+		}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	events, err := repo.FetchUserLateEvents(context.Background(), "acme", "u1")
+
+	s.NoError(err)
+	s.Equal([]model.Event{{
+		EventName:  "purchase",
+		Channel:    "web",
+		UserID:     "u1",
+		Timestamp:  ts,
+		Tags:       []string{},
+		Metadata:   map[string]any{"price": 9.5},
+		ReceivedAt: ts.Add(48 * time.Hour),
+	}}, events)
+}
+
+// expectPendingDeletes answers the pending deletes lookup for the given
+// predicates with n.
+func (s *PrivacyRepositoryTestSuite) expectPendingDeletes(tenantPredicate, userPredicate string, n uint64) {
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, pendingUserDeletesQuery, []any{erasureTables, tenantPredicate, userPredicate}).
+		Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*uint64) = n
+	}).Return(nil).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+}
+
+func (s *PrivacyRepositoryTestSuite) TestPendingUserDeletes_EscapesUserID() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeMutation)
+	s.expectPendingDeletes(`tenant_id = 'default'`, `user_id = 'o\'brien'`, 2)
+
+	pending, err := repo.PendingUserDeletes(context.Background(), "", "o'brien")
+
+	s.NoError(err)
+	s.Equal(2, pending)
+}
+
+// TestPendingUserDeletes_ScopedToTenant covers the same user erased in two
+// tenants: only the mutations of the asked tenant count.
+func (s *PrivacyRepositoryTestSuite) TestPendingUserDeletes_ScopedToTenant() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeMutation)
+	s.expectPendingDeletes(`tenant_id = 'acme'`, `user_id = 'u1'`, 0)
+	s.expectPendingDeletes(`tenant_id = 'globex'`, `user_id = 'u1'`, 2)
+
+	pending, err := repo.PendingUserDeletes(context.Background(), "acme", "u1")
+	s.NoError(err)
+	s.Zero(pending)
+
+	pending, err = repo.PendingUserDeletes(context.Background(), "globex", "u1")
+	s.NoError(err)
+	s.Equal(2, pending)
+}
+
+func (s *PrivacyRepositoryTestSuite) TestListAudit_ByRequest() {
+	repo := NewPrivacyRepository(s.connMock, DeleteModeMutation)
+	at := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, selectPrivacyAuditQuery+" WHERE request_id = ? ORDER BY request_id, at", []any{"r1"}).
+		Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = "r1"
+			*args.Get(1).(*string) = model.PrivacyRequestErasure
+			*args.Get(2).(*string) = "acme"
+			*args.Get(3).(*string) = "u1"
+			*args.Get(4).(*string) = model.PrivacyStatusSubmitted
+			*args.Get(5).(*string) = "dpo"
+			*args.Get(6).(*string) = ""
+			*args.Get(7).(*time.Time) = at
+		}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	entries, err := repo.ListAudit(context.Background(), "r1")
+
+	s.NoError(err)
+	s.Equal([]model.PrivacyAuditEntry{{
+		RequestID: "r1",
+		Type:      model.PrivacyRequestErasure,
+		TenantID:  "acme",
+		UserID:    "u1",
+		Status:    model.PrivacyStatusSubmitted,
+		Actor:     "dpo",
+		At:        at,
+	}}, entries)
+	rows.AssertExpectations(s.T())
+}
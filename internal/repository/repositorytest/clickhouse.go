@@ -0,0 +1,94 @@
+package repositorytest
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// StartClickHouse launches a throwaway `clickhouse server` from binary with
+// its data in a test temp dir and returns its native protocol address. The
+// server is stopped when the test finishes.
+func StartClickHouse(t *testing.T, binary string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	tcpPort := freePort(t)
+
+	logFile, err := os.Create(filepath.Join(dir, "server.log"))
+	if err != nil {
+		t.Fatalf("create clickhouse log: %v", err)
+	}
+	t.Cleanup(func() { logFile.Close() })
+
+	// Every listener gets its own free port so parallel runs don't collide.
+	cmd := exec.Command(binary, "server", "--",
+		"--path="+dir+"/",
+		"--listen_host=127.0.0.1",
+		"--tcp_port="+tcpPort,
+		"--http_port="+freePort(t),
+		"--mysql_port="+freePort(t),
+		"--postgresql_port="+freePort(t),
+		"--interserver_http_port="+freePort(t),
+		"--logger.console=1",
+		"--logger.level=warning",
+	)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start clickhouse: %v", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		select {
+		case <-exited:
+		case <-time.After(10 * time.Second):
+			_ = cmd.Process.Kill()
+			<-exited
+		}
+	})
+
+	addr := net.JoinHostPort("127.0.0.1", tcpPort)
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return addr
+		}
+
+		select {
+		case <-exited:
+			t.Fatalf("clickhouse exited during startup, see %s", logFile.Name())
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("clickhouse did not start listening on %s, see %s", addr, logFile.Name())
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("reserve port: %v", err)
+	}
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
@@ -0,0 +1,655 @@
+// Package repositorytest holds a conformance suite that every
+// repository.EventRepository implementation must pass.
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Harness adapts a backend to the conformance suite.
+type Harness struct {
+	// New returns an empty repository. It runs before every case; backends
+	// that share state across cases should clear it here.
+	New func(t *testing.T) repository.EventRepository
+
+	// Settle makes written data reflect final deduplicated state, e.g. by
+	// forcing a merge. Nil means writes are settled immediately.
+	Settle func(t *testing.T)
+
+	// Late and Privacy return the late event and privacy repositories that
+	// share storage with repo. Privacy cases are skipped when either is nil.
+	Late    func(t *testing.T, repo repository.EventRepository) repository.LateEventRepository
+	Privacy func(t *testing.T, repo repository.EventRepository) repository.PrivacyRepository
+}
+
+// base is the reference time all seeded events are relative to.
+var base = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// Run executes every conformance case against h.
+func Run(t *testing.T, h Harness) {
+	settle := h.Settle
+	if settle == nil {
+		settle = func(*testing.T) {}
+	}
+
+	cases := []struct {
+		name string
+		run  func(t *testing.T, repo repository.EventRepository)
+	}{
+		{"TotalsAndUniques", testTotalsAndUniques},
+		{"GroupByChannel", testGroupByChannel},
+		{"GroupByHour", testGroupByHour},
+		{"GroupByDay", testGroupByDay},
+		{"GroupByMetadata", testGroupByMetadata},
+		{"ChannelFilter", testChannelFilter},
+		{"MetadataFilter", testMetadataFilter},
+		{"TimeWindow", testTimeWindow},
+		{"EmptyResult", testEmptyResult},
+		{"CreateSingle", testCreateSingle},
+		{"WindowBoundariesInclusive", testWindowBoundariesInclusive},
+		{"NullCampaign", testNullCampaign},
+		{"DedupConsistency", testDedupConsistency},
+		{"UserTimelinePagination", testUserTimelinePagination},
+		{"UserTimelineFilters", testUserTimelineFilters},
+		{"UserEventCounts", testUserEventCounts},
+		{"ExportEvents", testExportEvents},
+		{"SubSecondBounds", testSubSecondBounds},
+		{"TenantIsolation", testTenantIsolation},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, h.New(t))
+		})
+	}
+
+	// Dedup cases need the backend to settle between write and read.
+	dedupCases := []struct {
+		name string
+		run  func(t *testing.T, repo repository.EventRepository, settle func(*testing.T))
+	}{
+		{"DedupSortingKey", testDedupSortingKey},
+		{"DedupAcrossBatches", testDedupAcrossBatches},
+	}
+
+	for _, tc := range dedupCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, h.New(t), settle)
+		})
+	}
+
+	privacyCases := []struct {
+		name string
+		run  func(t *testing.T, repo repository.EventRepository, late repository.LateEventRepository, privacy repository.PrivacyRepository)
+	}{
+		{"PrivacyLateEvents", testPrivacyLateEvents},
+	}
+
+	for _, tc := range privacyCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if h.Late == nil || h.Privacy == nil {
+				t.Skip("backend has no late event or privacy repository")
+			}
+			repo := h.New(t)
+			tc.run(t, repo, h.Late(t, repo), h.Privacy(t, repo))
+		})
+	}
+}
+
+func seed(t *testing.T, repo repository.EventRepository, events ...model.Event) {
+	t.Helper()
+	require.NoError(t, repo.CreateBatch(context.Background(), events))
+}
+
+func fetch(t *testing.T, repo repository.EventRepository, filter model.MetricsFilter) model.MetricsResult {
+	t.Helper()
+	if filter.From.IsZero() {
+		filter.From = base.Add(-30 * 24 * time.Hour)
+	}
+	if filter.To.IsZero() {
+		filter.To = base.Add(30 * 24 * time.Hour)
+	}
+
+	result, err := repo.FetchMetrics(context.Background(), filter)
+	require.NoError(t, err)
+	return result
+}
+
+func event(name, channel, user string, offset time.Duration) model.Event {
+	return model.Event{
+		EventName: name,
+		Channel:   channel,
+		UserID:    user,
+		Timestamp: base.Add(offset),
+		Tags:      []string{},
+	}
+}
+
+func group(key string, total, unique uint64) model.MetricsGroup {
+	return model.MetricsGroup{Key: key, TotalCount: total, UniqueUserCount: unique}
+}
+
+func testTotalsAndUniques(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", 0),
+		event("product_view", "web", "u1", time.Minute),
+		event("product_view", "mobile_app", "u2", 2*time.Minute),
+		event("purchase", "web", "u3", 3*time.Minute),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "channel"})
+	require.Equal(t, uint64(3), result.TotalCount)
+	require.Equal(t, uint64(2), result.UniqueCount)
+}
+
+func testGroupByChannel(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", 0),
+		event("product_view", "web", "u2", time.Minute),
+		event("product_view", "web", "u2", 2*time.Minute),
+		event("product_view", "mobile_app", "u1", 3*time.Minute),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "channel"})
+	require.Equal(t, []model.MetricsGroup{
+		group("mobile_app", 1, 1),
+		group("web", 3, 2),
+	}, result.Groups)
+}
+
+func testGroupByHour(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", 0),
+		event("product_view", "web", "u2", 59*time.Minute),
+		event("product_view", "web", "u1", time.Hour),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "hour"})
+	require.Equal(t, []model.MetricsGroup{
+		group("2025-03-10T12:00:00Z", 2, 2),
+		group("2025-03-10T13:00:00Z", 1, 1),
+	}, result.Groups)
+}
+
+func testGroupByDay(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", -13*time.Hour),
+		event("product_view", "web", "u1", 0),
+		event("product_view", "web", "u2", 11*time.Hour+59*time.Minute),
+		event("product_view", "web", "u3", 12*time.Hour),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "day"})
+	require.Equal(t, []model.MetricsGroup{
+		group("2025-03-09", 1, 1),
+		group("2025-03-10", 2, 2),
+		group("2025-03-11", 1, 1),
+	}, result.Groups)
+}
+
+func testGroupByMetadata(t *testing.T, repo repository.EventRepository) {
+	try := event("purchase", "web", "u1", 0)
+	try.Metadata = map[string]any{"currency": "TRY", "price": 10.5}
+	usd := event("purchase", "web", "u2", time.Minute)
+	usd.Metadata = map[string]any{"currency": "USD", "price": 10.5}
+	none := event("purchase", "web", "u3", 2*time.Minute)
+	seed(t, repo, try, usd, none)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "purchase", GroupBy: "metadata.currency"})
+	require.Equal(t, []model.MetricsGroup{
+		group("", 1, 1),
+		group("TRY", 1, 1),
+		group("USD", 1, 1),
+	}, result.Groups)
+
+	result = fetch(t, repo, model.MetricsFilter{EventName: "purchase", GroupBy: "metadata.price"})
+	require.Equal(t, []model.MetricsGroup{
+		group("", 1, 1),
+		group("10.5", 2, 2),
+	}, result.Groups)
+}
+
+func testChannelFilter(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", 0),
+		event("product_view", "mobile_app", "u2", time.Minute),
+	)
+
+	channel := "web"
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "channel", Channel: &channel})
+	require.Equal(t, uint64(1), result.TotalCount)
+	require.Equal(t, []model.MetricsGroup{group("web", 1, 1)}, result.Groups)
+}
+
+func testMetadataFilter(t *testing.T, repo repository.EventRepository) {
+	cheap := event("purchase", "web", "u1", 0)
+	cheap.Metadata = map[string]any{"currency": "TRY", "price": 99.9}
+	expensive := event("purchase", "web", "u2", time.Minute)
+	expensive.Metadata = map[string]any{"currency": "TRY", "price": 500}
+	seed(t, repo, cheap, expensive)
+
+	result := fetch(t, repo, model.MetricsFilter{
+		EventName: "purchase",
+		GroupBy:   "channel",
+		Metadata:  map[string]string{"currency": "TRY", "price": "99.9"},
+	})
+	require.Equal(t, uint64(1), result.TotalCount)
+	require.Equal(t, []model.MetricsGroup{group("web", 1, 1)}, result.Groups)
+}
+
+func testTimeWindow(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", -2*time.Hour),
+		event("product_view", "web", "u2", 0),
+		event("product_view", "web", "u3", 2*time.Hour),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{
+		EventName: "product_view",
+		GroupBy:   "channel",
+		From:      base.Add(-time.Hour),
+		To:        base.Add(time.Hour),
+	})
+	require.Equal(t, uint64(1), result.TotalCount)
+	require.Equal(t, uint64(1), result.UniqueCount)
+}
+
+func testEmptyResult(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo, event("product_view", "web", "u1", 0))
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "purchase", GroupBy: "channel"})
+	require.Zero(t, result.TotalCount)
+	require.Zero(t, result.UniqueCount)
+	require.Empty(t, result.Groups)
+}
+
+func testCreateSingle(t *testing.T, repo repository.EventRepository) {
+	require.NoError(t, repo.Create(context.Background(), event("signup", "web", "u1", 0)))
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "signup", GroupBy: "channel"})
+	require.Equal(t, uint64(1), result.TotalCount)
+	require.Equal(t, []model.MetricsGroup{group("web", 1, 1)}, result.Groups)
+}
+
+func testWindowBoundariesInclusive(t *testing.T, repo repository.EventRepository) {
+	from := base
+	to := base.Add(time.Hour)
+	seed(t, repo,
+		event("product_view", "web", "before", -time.Second),
+		event("product_view", "web", "at_from", 0),
+		event("product_view", "web", "at_to", time.Hour),
+		event("product_view", "web", "after", time.Hour+time.Second),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "hour", From: from, To: to})
+	require.Equal(t, uint64(2), result.TotalCount)
+	require.Equal(t, uint64(2), result.UniqueCount)
+	require.Equal(t, []model.MetricsGroup{
+		group("2025-03-10T12:00:00Z", 1, 1),
+		group("2025-03-10T13:00:00Z", 1, 1),
+	}, result.Groups)
+}
+
+func testNullCampaign(t *testing.T, repo repository.EventRepository) {
+	withCampaign := event("product_view", "web", "u1", 0)
+	withCampaign.CampaignID = "cmp_1"
+	withoutCampaign := event("product_view", "web", "u1", 0)
+	seed(t, repo, withCampaign, withoutCampaign, event("product_view", "web", "u2", time.Minute))
+
+	// An absent campaign is stored as NULL and is part of the sorting key, so
+	// it neither drops the event nor collides with a set campaign.
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "channel"})
+	require.Equal(t, uint64(3), result.TotalCount)
+	require.Equal(t, uint64(2), result.UniqueCount)
+	require.Equal(t, []model.MetricsGroup{group("web", 3, 2)}, result.Groups)
+}
+
+func testDedupSortingKey(t *testing.T, repo repository.EventRepository, settle func(*testing.T)) {
+	original := event("purchase", "web", "u1", 0)
+	original.Metadata = map[string]any{"price": 10}
+	// Same sorting key (event_name, ts, user_id, channel, campaign_id) with
+	// different payload: a duplicate submission.
+	resent := event("purchase", "web", "u1", 0)
+	resent.Tags = []string{"retry"}
+	resent.Metadata = map[string]any{"price": 10}
+	// A different campaign makes it a distinct event.
+	otherCampaign := event("purchase", "web", "u1", 0)
+	otherCampaign.CampaignID = "cmp_1"
+
+	seed(t, repo, original, resent, otherCampaign)
+	settle(t)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "purchase", GroupBy: "channel"})
+	require.Equal(t, uint64(2), result.TotalCount)
+	require.Equal(t, uint64(1), result.UniqueCount)
+}
+
+func testDedupAcrossBatches(t *testing.T, repo repository.EventRepository, settle func(*testing.T)) {
+	first := []model.Event{
+		event("product_view", "web", "u1", 0),
+		event("product_view", "web", "u2", time.Minute),
+	}
+	second := []model.Event{
+		event("product_view", "web", "u2", time.Minute),
+		event("product_view", "mobile_app", "u3", 2*time.Minute),
+	}
+	seed(t, repo, first...)
+	seed(t, repo, second...)
+	settle(t)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "product_view", GroupBy: "channel"})
+	require.Equal(t, uint64(3), result.TotalCount)
+	require.Equal(t, uint64(3), result.UniqueCount)
+	require.Equal(t, []model.MetricsGroup{
+		group("mobile_app", 1, 1),
+		group("web", 2, 2),
+	}, result.Groups)
+}
+
+func testDedupConsistency(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo, event("product_view", "web", "u1", 0), event("product_view", "web", "u2", time.Minute))
+	seed(t, repo, event("product_view", "web", "u1", 0))
+
+	// consistency=dedup must collapse duplicates without waiting for merges.
+	result := fetch(t, repo, model.MetricsFilter{
+		EventName:   "product_view",
+		GroupBy:     "channel",
+		Consistency: model.ConsistencyDedup,
+	})
+	require.Equal(t, uint64(2), result.TotalCount)
+	require.Equal(t, []model.MetricsGroup{group("web", 2, 2)}, result.Groups)
+}
+
+func userEvents(t *testing.T, repo repository.EventRepository, filter model.UserEventsFilter) []model.Event {
+	t.Helper()
+	if filter.From.IsZero() {
+		filter.From = base.Add(-30 * 24 * time.Hour)
+	}
+	if filter.To.IsZero() {
+		filter.To = base.Add(30 * 24 * time.Hour)
+	}
+
+	events, err := repo.FetchUserEvents(context.Background(), filter)
+	require.NoError(t, err)
+	return events
+}
+
+func testUserTimelinePagination(t *testing.T, repo repository.EventRepository) {
+	withCampaign := event("product_view", "web", "u1", time.Minute)
+	withCampaign.CampaignID = "cmp_1"
+	seed(t, repo,
+		event("add_to_cart", "web", "u1", 0),
+		// Four events share a timestamp and differ only in the remaining
+		// sorting key columns; paging must neither skip nor repeat them.
+		event("product_view", "web", "u1", time.Minute),
+		withCampaign,
+		event("product_view", "mobile_app", "u1", time.Minute),
+		event("add_to_cart", "web", "u1", time.Minute),
+		event("purchase", "web", "u1", 2*time.Minute),
+		event("purchase", "web", "u2", 3*time.Minute),
+	)
+
+	var got []model.Event
+	var after *model.UserEventsCursor
+	for range 10 {
+		page := userEvents(t, repo, model.UserEventsFilter{UserID: "u1", After: after, Limit: 2})
+		got = append(got, page...)
+		if len(page) < 2 {
+			break
+		}
+		last := page[len(page)-1]
+		after = &model.UserEventsCursor{
+			Timestamp:  last.Timestamp,
+			EventName:  last.EventName,
+			Channel:    last.Channel,
+			CampaignID: last.CampaignID,
+		}
+	}
+
+	type key struct {
+		name, channel, campaign string
+		offset                  time.Duration
+	}
+	keys := make([]key, len(got))
+	for i, e := range got {
+		require.Equal(t, "u1", e.UserID)
+		keys[i] = key{e.EventName, e.Channel, e.CampaignID, e.Timestamp.Sub(base)}
+	}
+	require.Equal(t, []key{
+		{"purchase", "web", "", 2 * time.Minute},
+		{"product_view", "web", "cmp_1", time.Minute},
+		{"product_view", "web", "", time.Minute},
+		{"product_view", "mobile_app", "", time.Minute},
+		{"add_to_cart", "web", "", time.Minute},
+		{"add_to_cart", "web", "", 0},
+	}, keys)
+}
+
+func testUserTimelineFilters(t *testing.T, repo repository.EventRepository) {
+	view := event("product_view", "web", "u1", time.Minute)
+	view.Tags = []string{"sale"}
+	view.Metadata = map[string]any{"sku": "A-1", "price": 9.5}
+	view.ReceivedAt = base.Add(3 * time.Minute)
+	seed(t, repo,
+		event("product_view", "web", "u1", -time.Hour),
+		view,
+		event("purchase", "web", "u1", 2*time.Minute),
+		event("product_view", "web", "u1", 2*time.Hour),
+	)
+
+	events := userEvents(t, repo, model.UserEventsFilter{
+		UserID:    "u1",
+		EventName: "product_view",
+		From:      base,
+		To:        base.Add(time.Hour),
+		Limit:     10,
+	})
+	require.Len(t, events, 1)
+	require.Equal(t, base.Add(time.Minute), events[0].Timestamp)
+	require.Equal(t, []string{"sale"}, events[0].Tags)
+	require.Equal(t, map[string]any{"sku": "A-1", "price": 9.5}, events[0].Metadata)
+	require.Equal(t, base.Add(3*time.Minute), events[0].ReceivedAt)
+}
+
+func testUserEventCounts(t *testing.T, repo repository.EventRepository) {
+	seed(t, repo,
+		event("product_view", "web", "u1", -time.Hour),
+		event("product_view", "web", "u1", 0),
+		event("product_view", "mobile_app", "u1", 10*time.Minute),
+		event("purchase", "web", "u1", 5*time.Minute),
+		event("purchase", "web", "u2", 6*time.Minute),
+	)
+
+	counts, err := repo.FetchUserEventCounts(context.Background(), model.DefaultTenant, "u1", base, base.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []model.UserEventCount{
+		{EventName: "product_view", Count: 2, FirstSeen: base, LastSeen: base.Add(10 * time.Minute)},
+		{EventName: "purchase", Count: 1, FirstSeen: base.Add(5 * time.Minute), LastSeen: base.Add(5 * time.Minute)},
+	}, counts)
+
+	counts, err = repo.FetchUserEventCounts(context.Background(), model.DefaultTenant, "nobody", base, base.Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, counts)
+}
+
+func testExportEvents(t *testing.T, repo repository.EventRepository) {
+	tagged := event("purchase", "web", "u1", 2*time.Minute)
+	tagged.Tags = []string{"sale"}
+	tagged.Metadata = map[string]any{"currency": "TRY"}
+	seed(t, repo,
+		tagged,
+		event("purchase", "mobile_app", "u2", time.Minute),
+		event("purchase", "web", "u3", 0),
+		event("purchase", "web", "u4", 48*time.Hour),
+		event("product_view", "web", "u1", time.Minute),
+	)
+
+	channel := "web"
+	var users []string
+	var exported []model.Event
+	err := repo.ExportEvents(context.Background(), model.MetricsFilter{
+		EventName: "purchase",
+		From:      base,
+		To:        base.Add(time.Hour),
+		Channel:   &channel,
+	}, func(e model.Event) error {
+		users = append(users, e.UserID)
+		exported = append(exported, e)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"u3", "u1"}, users)
+	require.Equal(t, []string{"sale"}, exported[1].Tags)
+	require.Equal(t, map[string]any{"currency": "TRY"}, exported[1].Metadata)
+
+	users = nil
+	err = repo.ExportEvents(context.Background(), model.MetricsFilter{
+		EventName: "purchase",
+		From:      base,
+		To:        base.Add(time.Hour),
+		Metadata:  map[string]string{"currency": "TRY"},
+	}, func(e model.Event) error {
+		users = append(users, e.UserID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"u1"}, users)
+}
+
+func testSubSecondBounds(t *testing.T, repo repository.EventRepository) {
+	// All events fall in the same second; only milliseconds tell them apart.
+	seed(t, repo,
+		event("purchase", "web", "u1", 100*time.Millisecond),
+		event("purchase", "mobile_app", "u1", 400*time.Millisecond),
+		event("purchase", "ios", "u1", 700*time.Millisecond),
+		event("purchase", "android", "u1", 900*time.Millisecond),
+	)
+	from := base.Add(200 * time.Millisecond)
+	to := base.Add(800 * time.Millisecond)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "purchase", GroupBy: "channel", From: from, To: to})
+	require.Equal(t, uint64(2), result.TotalCount)
+
+	counts, err := repo.FetchUserEventCounts(context.Background(), model.DefaultTenant, "u1", from, to)
+	require.NoError(t, err)
+	require.Equal(t, []model.UserEventCount{
+		{EventName: "purchase", Count: 2, FirstSeen: base.Add(400 * time.Millisecond), LastSeen: base.Add(700 * time.Millisecond)},
+	}, counts)
+
+	var exported []string
+	err = repo.ExportEvents(context.Background(), model.MetricsFilter{EventName: "purchase", From: from, To: to}, func(e model.Event) error {
+		exported = append(exported, e.Channel)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"mobile_app", "ios"}, exported)
+
+	// A cursor inside the second must still return the older events of
+	// that second.
+	page := userEvents(t, repo, model.UserEventsFilter{UserID: "u1", Limit: 2})
+	require.Len(t, page, 2)
+	last := page[1]
+	require.Equal(t, base.Add(700*time.Millisecond), last.Timestamp)
+
+	page = userEvents(t, repo, model.UserEventsFilter{
+		UserID: "u1",
+		After:  &model.UserEventsCursor{Timestamp: last.Timestamp, EventName: last.EventName, Channel: last.Channel, CampaignID: last.CampaignID},
+		Limit:  2,
+	})
+	channels := make([]string, len(page))
+	for i, e := range page {
+		channels[i] = e.Channel
+	}
+	require.Equal(t, []string{"mobile_app", "web"}, channels)
+}
+
+func testTenantIsolation(t *testing.T, repo repository.EventRepository) {
+	acme := func(e model.Event) model.Event {
+		e.TenantID = "acme"
+		return e
+	}
+	// The acme events share every other sorting key column with default
+	// tenant events, so they must not be collapsed as duplicates.
+	seed(t, repo,
+		event("purchase", "web", "u1", 0),
+		acme(event("purchase", "web", "u1", 0)),
+		acme(event("purchase", "mobile_app", "u2", time.Minute)),
+	)
+
+	result := fetch(t, repo, model.MetricsFilter{EventName: "purchase", GroupBy: "channel"})
+	require.Equal(t, []model.MetricsGroup{group("web", 1, 1)}, result.Groups)
+
+	result = fetch(t, repo, model.MetricsFilter{TenantID: "acme", EventName: "purchase", GroupBy: "channel", Consistency: model.ConsistencyDedup})
+	require.Equal(t, []model.MetricsGroup{group("mobile_app", 1, 1), group("web", 1, 1)}, result.Groups)
+
+	result = fetch(t, repo, model.MetricsFilter{TenantID: "other", EventName: "purchase", GroupBy: "channel"})
+	require.Zero(t, result.TotalCount)
+
+	require.Len(t, userEvents(t, repo, model.UserEventsFilter{UserID: "u2", Limit: 10}), 0)
+	require.Len(t, userEvents(t, repo, model.UserEventsFilter{TenantID: "acme", UserID: "u2", Limit: 10}), 1)
+
+	counts, err := repo.FetchUserEventCounts(context.Background(), "acme", "u1", base.Add(-time.Hour), base.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	require.Equal(t, uint64(1), counts[0].Count)
+
+	var users []string
+	err = repo.ExportEvents(context.Background(), model.MetricsFilter{
+		TenantID:  "acme",
+		EventName: "purchase",
+		From:      base,
+		To:        base.Add(time.Hour),
+	}, func(e model.Event) error {
+		users = append(users, e.UserID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"u1", "u2"}, users)
+}
+
+// testPrivacyLateEvents checks that access requests can read the late events
+// of one user in one tenant, which live outside the events table.
+func testPrivacyLateEvents(t *testing.T, repo repository.EventRepository, late repository.LateEventRepository, privacy repository.PrivacyRepository) {
+	ctx := context.Background()
+	receivedAt := base.Add(72 * time.Hour)
+	lateEvent := func(tenant, user string, offset time.Duration) model.Event {
+		e := event("purchase", "web", user, offset)
+		e.TenantID = tenant
+		e.CampaignID = "spring"
+		e.Metadata = map[string]any{"price": 9.5}
+		e.ReceivedAt = receivedAt
+		return e
+	}
+
+	seed(t, repo, event("purchase", "web", "u1", 0))
+	require.NoError(t, late.CreateBatch(ctx, []model.Event{
+		lateEvent("", "u1", -2*time.Hour),
+		lateEvent("", "u1", -time.Hour),
+		lateEvent("acme", "u1", -time.Hour),
+		lateEvent("", "u2", -time.Hour),
+	}))
+
+	events, err := privacy.FetchUserLateEvents(ctx, "", "u1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, base.Add(-time.Hour), events[0].Timestamp, "newest first")
+	require.Equal(t, base.Add(-2*time.Hour), events[1].Timestamp)
+	require.Equal(t, "spring", events[0].CampaignID)
+	require.Equal(t, map[string]any{"price": 9.5}, events[0].Metadata)
+	require.Equal(t, receivedAt, events[0].ReceivedAt)
+
+	events, err = privacy.FetchUserLateEvents(ctx, "acme", "u1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	events, err = privacy.FetchUserLateEvents(ctx, "other", "u1")
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// userEventsOrder sorts a user's timeline newest first. campaign_id is
+// compared as an empty string when NULL so the cursor tuple has no NULLs.
+const userEventsOrder = "ORDER BY ts DESC, event_name DESC, channel DESC, ifNull(campaign_id, '') DESC"
+
+const userEventCountsQuery = `
+	SELECT event_name, COUNT(*), min(ts), max(ts)
+	FROM events
+	WHERE tenant_id = ? AND user_id = ? AND ts >= ` + tsParam + ` AND ts <= ` + tsParam + `
+	GROUP BY event_name
+	ORDER BY event_name
+`
+
+func (r *eventRepository) FetchUserEvents(ctx context.Context, filter model.UserEventsFilter) ([]model.Event, error) {
+	whereParts := []string{"tenant_id = ?", "user_id = ?", "ts >= " + tsParam, "ts <= " + tsParam}
+	args := []any{model.TenantOrDefault(filter.TenantID), filter.UserID, tsArg(filter.From), tsArg(filter.To)}
+
+	if filter.EventName != "" {
+		whereParts = append(whereParts, "event_name = ?")
+		args = append(args, filter.EventName)
+	}
+
+	if after := filter.After; after != nil {
+		whereParts = append(whereParts, "(ts, event_name, channel, ifNull(campaign_id, '')) < ("+tsParam+", ?, ?, ?)")
+		args = append(args, tsArg(after.Timestamp), after.EventName, after.Channel, after.CampaignID)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM events WHERE %s %s LIMIT %d",
+		eventColumns, strings.Join(whereParts, " AND "), userEventsOrder, filter.Limit)
+
+	rows, err := r.conn.Query(r.queryContext(ctx, false), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query user events: %w", classifyQueryError(err))
+	}
+	defer rows.Close()
+
+	events := []model.Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user events: %w", classifyQueryError(err))
+	}
+	return events, nil
+}
+
+func (r *eventRepository) FetchUserEventCounts(ctx context.Context, tenantID, userID string, from, to time.Time) ([]model.UserEventCount, error) {
+	rows, err := r.conn.Query(r.queryContext(ctx, false), userEventCountsQuery, model.TenantOrDefault(tenantID), userID, tsArg(from), tsArg(to))
+	if err != nil {
+		return nil, fmt.Errorf("query user event counts: %w", classifyQueryError(err))
+	}
+	defer rows.Close()
+
+	counts := []model.UserEventCount{}
+	for rows.Next() {
+		var c model.UserEventCount
+		if err := rows.Scan(&c.EventName, &c.Count, &c.FirstSeen, &c.LastSeen); err != nil {
+			return nil, fmt.Errorf("scan user event count: %w", err)
+		}
+		c.FirstSeen = c.FirstSeen.UTC()
+		c.LastSeen = c.LastSeen.UTC()
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user event counts: %w", classifyQueryError(err))
+	}
+	return counts, nil
+}
+
+// eventColumns selects a full event row in the order scanEvent reads it.
+const eventColumns = "event_name, channel, ifNull(campaign_id, ''), user_id, ts, tags, metadata, received_at"
+
+func scanEvent(rows driver.Rows) (model.Event, error) {
+	var event model.Event
+	var metadata string
+	if err := rows.Scan(&event.EventName, &event.Channel, &event.CampaignID, &event.UserID, &event.Timestamp, &event.Tags, &metadata, &event.ReceivedAt); err != nil {
+		return model.Event{}, fmt.Errorf("scan event: %w", err)
+	}
+
+	var err error
+	if event.Metadata, err = unmarshalMetadata(metadata); err != nil {
+		return model.Event{}, err
+	}
+	event.Timestamp = event.Timestamp.UTC()
+	event.ReceivedAt = event.ReceivedAt.UTC()
+	return event, nil
+}
+
+// receivedAt returns when the event was accepted. Events built outside the
+// ingest API have no receive time and are stamped with the write time.
+func receivedAt(event model.Event) time.Time {
+	if event.ReceivedAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return event.ReceivedAt.UTC()
+}
+
+// unmarshalMetadata decodes the stored metadata JSON. Empty input yields an
+// empty map rather than nil so API responses always carry an object.
+func unmarshalMetadata(raw string) (map[string]any, error) {
+	metadata := map[string]any{}
+	if raw == "" {
+		return metadata, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+		return nil, fmt.Errorf("unmarshal metadata: %w", err)
+	}
+	return metadata, nil
+}
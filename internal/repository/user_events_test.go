@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockclickhouserows"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *EventRepositoryTestSuite) TestFetchUserEvents_CursorAndFilters() {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	after := &model.UserEventsCursor{Timestamp: to.Add(-time.Hour + 250*time.Millisecond), EventName: "purchase", Channel: "web"}
+	filter := model.UserEventsFilter{TenantID: "acme", UserID: "u1", From: from, To: to, EventName: "purchase", After: after, Limit: 3}
+
+	expectedQuery := "SELECT event_name, channel, ifNull(campaign_id, ''), user_id, ts, tags, metadata, received_at FROM events " +
+		"WHERE tenant_id = ? AND user_id = ? AND ts >= toDateTime64(?, 3, 'UTC') AND ts <= toDateTime64(?, 3, 'UTC') AND event_name = ? " +
+		"AND (ts, event_name, channel, ifNull(campaign_id, '')) < (toDateTime64(?, 3, 'UTC'), ?, ?, ?) " +
+		"ORDER BY ts DESC, event_name DESC, channel DESC, ifNull(campaign_id, '') DESC LIMIT 3"
+	// The cursor keeps its milliseconds so same-second events are not skipped.
+	expectedArgs := []any{"acme", "u1", "2025-01-01 00:00:00.000", "2025-01-02 00:00:00.000", "purchase", "2025-01-01 23:00:00.250", "purchase", "web", ""}
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, expectedArgs).Return(rows, nil).Once()
+
+	ts := to.Add(-2 * time.Hour)
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "purchase"
+		*args.Get(1).(*string) = "web"
+		*args.Get(2).(*string) = "cmp_1"
+		*args.Get(3).(*string) = "u1"
+		*args.Get(4).(*time.Time) = ts
+		*args.Get(5).(*[]string) = []string{"sale"}
+		*args.Get(6).(*string) = `{"amount":10}`
+		*args.Get(7).(*time.Time) = ts.Add(time.Minute)
+	}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	events, err := s.repository.FetchUserEvents(context.Background(), filter)
+
+	s.NoError(err)
+	s.Equal([]model.Event{{
+		EventName:  "purchase",
+		Channel:    "web",
+		CampaignID: "cmp_1",
+		UserID:     "u1",
+		Timestamp:  ts,
+		Tags:       []string{"sale"},
+		Metadata:   map[string]any{"amount": float64(10)},
+		ReceivedAt: ts.Add(time.Minute),
+	}}, events)
+	rows.AssertExpectations(s.T())
+}
+
+func (s *EventRepositoryTestSuite) TestFetchUserEvents_QueryError() {
+	expectedErr := errors.New("query error")
+	s.connMock.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&mockclickhouserows.Rows{}, expectedErr).Once()
+
+	_, err := s.repository.FetchUserEvents(context.Background(), model.UserEventsFilter{UserID: "u1", Limit: 1})
+
+	s.ErrorIs(err, expectedErr)
+	s.ErrorContains(err, "query user events")
+}
+
+func (s *EventRepositoryTestSuite) TestFetchUserEventCounts_Success() {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, userEventCountsQuery, []any{model.DefaultTenant, "u1", "2025-01-01 00:00:00.000", "2025-01-02 00:00:00.000"}).Return(rows, nil).Once()
+
+	rows.On("Next").Return(true).Once()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "purchase"
+		*args.Get(1).(*uint64) = 2
+		*args.Get(2).(*time.Time) = from.Add(time.Hour)
+		*args.Get(3).(*time.Time) = from.Add(2 * time.Hour)
+	}).Return(nil).Once()
+	rows.On("Next").Return(false).Once()
+	rows.On("Err").Return(nil).Once()
+	rows.On("Close").Return(nil).Once()
+
+	counts, err := s.repository.FetchUserEventCounts(context.Background(), model.DefaultTenant, "u1", from, to)
+
+	s.NoError(err)
+	s.Equal([]model.UserEventCount{
+		{EventName: "purchase", Count: 2, FirstSeen: from.Add(time.Hour), LastSeen: from.Add(2 * time.Hour)},
+	}, counts)
+	rows.AssertExpectations(s.T())
+}
+
+func (s *EventRepositoryTestSuite) TestExportEvents_StreamsWithoutGuardrails() {
+	s.repository.limits = QueryLimits{MaxRowsToRead: 10}
+	filter := model.MetricsFilter{EventName: "purchase", Consistency: model.ConsistencyDedup}
+
+	expectedQuery := "SELECT event_name, channel, ifNull(campaign_id, ''), user_id, ts, tags, metadata, received_at FROM events FINAL " +
+		"WHERE tenant_id = ? AND event_name = ? ORDER BY ts"
+
+	rows := &mockclickhouserows.Rows{}
+	s.connMock.On("Query", mock.Anything, expectedQuery, []any{model.DefaultTenant, "purchase"}).Return(rows, nil).Once()
+	rows.On("Next").Return(true).Twice()
+	rows.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(3).(*string) = "u1"
+		*args.Get(6).(*string) = "{}"
+	}).Return(nil).Twice()
+	rows.On("Close").Return(nil).Once()
+
+	stop := errors.New("client went away")
+	calls := 0
+	err := s.repository.ExportEvents(context.Background(), filter, func(e model.Event) error {
+		calls++
+		if calls == 2 {
+			return stop
+		}
+		return nil
+	})
+
+	s.ErrorIs(err, stop)
+	s.Equal(2, calls)
+	rows.AssertExpectations(s.T())
+}
@@ -6,12 +6,93 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// Register attaches all HTTP routes to the Fiber app.
-func Register(app *fiber.App, eventController controller.EventController) {
-	app.Post("/events", eventController.CreateEvent)
-	app.Get("/metrics", eventController.GetMetrics)
+// Guards holds the middleware protecting each group of routes. A nil guard
+// leaves its routes open.
+type Guards struct {
+	Ingest fiber.Handler
+	Query  fiber.Handler
+	Admin  fiber.Handler
 
+	// Tenant resolves the tenant of ingest and query requests. It runs
+	// after the scope guard so it can see the authenticated key.
+	Tenant fiber.Handler
+
+	// Signature verifies HMAC-signed ingest requests.
+	Signature fiber.Handler
+
+	// IngestLimit and QueryLimit enforce rate limits and quotas. They run
+	// last so they can see the key and the tenant.
+	IngestLimit fiber.Handler
+	QueryLimit  fiber.Handler
+
+	// Audit records metrics queries and admin requests. It runs first so
+	// requests the other guards reject are recorded too.
+	Audit fiber.Handler
+}
+
+// Register attaches all HTTP routes to the Fiber app. /health is never
+// guarded so orchestrators can probe it without credentials.
+func Register(app *fiber.App, eventController controller.EventController, guards Guards) {
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
+
+	app.Post("/events", guarded(eventController.CreateEvent, guards.Ingest, guards.Signature, guards.Tenant, guards.IngestLimit)...)
+	app.Get("/events/export", guarded(eventController.ExportEvents, guards.Audit, guards.Query, guards.Tenant, guards.QueryLimit)...)
+	app.Get("/metrics", guarded(eventController.GetMetrics, guards.Audit, guards.Query, guards.Tenant, guards.QueryLimit)...)
+	app.Get("/users/:user_id/events", guarded(eventController.GetUserEvents, guards.Query, guards.Tenant, guards.QueryLimit)...)
+	app.Get("/users/:user_id/summary", guarded(eventController.GetUserSummary, guards.Query, guards.Tenant, guards.QueryLimit)...)
+}
+
+// AdminControllers groups the handlers mounted under /admin.
+type AdminControllers struct {
+	Admin   controller.AdminController
+	Privacy controller.PrivacyController
+	Keys    controller.KeyController
+	Usage   controller.UsageController
+	Signing controller.SigningController
+	Audit   controller.AuditController
+	Rules   controller.RulesController
+}
+
+// RegisterAdmin attaches operational routes. They are only mounted when
+// admin endpoints are enabled in configuration.
+func RegisterAdmin(app *fiber.App, controllers AdminControllers, guards Guards) {
+	admin := app.Group("/admin")
+	for _, guard := range []fiber.Handler{guards.Audit, guards.Admin} {
+		if guard != nil {
+			admin.Use(guard)
+		}
+	}
+
+	admin.Get("/partitions", controllers.Admin.ListPartitions)
+	admin.Delete("/partitions", controllers.Admin.DropPartitions)
+	admin.Post("/partitions/optimize", controllers.Admin.OptimizePartitions)
+	admin.Get("/schemas", controllers.Admin.ListSchemas)
+	admin.Put("/schemas/:event_name", controllers.Admin.PutSchema)
+	admin.Delete("/schemas/:event_name", controllers.Admin.DeleteSchema)
+	admin.Post("/privacy/erasure", controllers.Privacy.RequestErasure)
+	admin.Post("/privacy/access", controllers.Privacy.RequestAccess)
+	admin.Get("/privacy/requests", controllers.Privacy.ListRequests)
+	admin.Get("/privacy/requests/:id", controllers.Privacy.GetRequest)
+	admin.Get("/keys", controllers.Keys.ListKeys)
+	admin.Post("/keys", controllers.Keys.CreateKey)
+	admin.Delete("/keys/:id", controllers.Keys.RevokeKey)
+	admin.Post("/keys/:id/rotate", controllers.Keys.RotateKey)
+	admin.Put("/keys/:id/limits", controllers.Keys.SetKeyLimits)
+	admin.Get("/usage", controllers.Usage.GetUsage)
+	admin.Get("/signing", controllers.Signing.GetSigningStats)
+	admin.Get("/audit", controllers.Audit.SearchAudit)
+	admin.Get("/rules", controllers.Rules.GetRuleStats)
+}
+
+// guarded chains the non-nil guards, in order, in front of handler.
+func guarded(handler fiber.Handler, guards ...fiber.Handler) []fiber.Handler {
+	var chain []fiber.Handler
+	for _, guard := range guards {
+		if guard != nil {
+			chain = append(chain, guard)
+		}
+	}
+	return append(chain, handler)
 }
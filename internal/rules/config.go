@@ -0,0 +1,131 @@
+// Package rules filters and rewrites events at ingest according to rules
+// loaded from a file.
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Action types.
+const (
+	ActionDrop   = "drop"
+	ActionRename = "rename"
+	ActionSet    = "set"
+	ActionRemove = "remove"
+	ActionSample = "sample"
+)
+
+// metadataField prefixes metadata keys in set and remove actions.
+const metadataField = "metadata."
+
+// Config is the rules file. Rules run in order.
+type Config struct {
+	Version int    `json:"version"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Rule applies its actions to events matching every condition of Match.
+type Rule struct {
+	Name    string   `json:"name"`
+	Match   Match    `json:"match"`
+	Actions []Action `json:"actions"`
+}
+
+// Match lists the conditions of a rule. Empty conditions match every event.
+type Match struct {
+	// EventNames and Channels match when the event has any of the values.
+	EventNames []string `json:"event_names,omitempty"`
+	Channels   []string `json:"channels,omitempty"`
+
+	// Tags match when the event carries all of them.
+	Tags []string `json:"tags,omitempty"`
+
+	// Metadata matches when each key holds the value, compared as text. The
+	// value "*" only requires the key to be present.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Action changes or drops a matched event.
+//
+//   - drop: discards the event.
+//   - rename: sets event_name to EventName.
+//   - set: sets Field to Value. Field is channel, campaign_id or
+//     metadata.<key>.
+//   - remove: clears Field, campaign_id or metadata.<key>.
+//   - sample: keeps Percent of the events and drops the rest.
+type Action struct {
+	Type      string  `json:"type"`
+	EventName string  `json:"event_name,omitempty"`
+	Field     string  `json:"field,omitempty"`
+	Value     any     `json:"value,omitempty"`
+	Percent   float64 `json:"percent,omitempty"`
+}
+
+// Validate reports the first invalid rule or action.
+func (c Config) Validate() error {
+	if c.Version <= 0 {
+		return fmt.Errorf("rules version must be positive")
+	}
+
+	names := map[string]bool{}
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d: name is required", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %s: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if len(rule.Actions) == 0 {
+			return fmt.Errorf("rule %s: at least one action is required", rule.Name)
+		}
+		for _, action := range rule.Actions {
+			if err := action.validate(); err != nil {
+				return fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (a Action) validate() error {
+	switch a.Type {
+	case ActionDrop:
+	case ActionRename:
+		if a.EventName == "" {
+			return fmt.Errorf("rename requires event_name")
+		}
+	case ActionSet:
+		if !slices.Contains([]string{"channel", "campaign_id"}, a.Field) && !isMetadataField(a.Field) {
+			return fmt.Errorf("set field must be channel, campaign_id or metadata.<key>, got %q", a.Field)
+		}
+		if a.Value == nil {
+			return fmt.Errorf("set requires a value")
+		}
+		if _, ok := a.Value.(string); !ok && !isMetadataField(a.Field) {
+			return fmt.Errorf("set %s requires a string value", a.Field)
+		}
+		if a.Field == "channel" && a.Value == "" {
+			return fmt.Errorf("channel must not be set to an empty value")
+		}
+	case ActionRemove:
+		if a.Field != "campaign_id" && !isMetadataField(a.Field) {
+			return fmt.Errorf("remove field must be campaign_id or metadata.<key>, got %q", a.Field)
+		}
+	case ActionSample:
+		if a.Percent <= 0 || a.Percent > 100 {
+			return fmt.Errorf("sample percent must be in (0, 100], got %v", a.Percent)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	return nil
+}
+
+func isMetadataField(field string) bool {
+	key, ok := strings.CutPrefix(field, metadataField)
+	return ok && key != ""
+}
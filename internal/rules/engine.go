@@ -0,0 +1,260 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+// Stats counts the events a rule matched and dropped since start. Counters
+// survive reloads for rules that keep their name.
+type Stats struct {
+	Name    string `json:"name"`
+	Hits    uint64 `json:"hits"`
+	Dropped uint64 `json:"dropped"`
+}
+
+type counters struct {
+	hits    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+type ruleSet struct {
+	version int
+	rules   []Rule
+	modTime time.Time
+	size    int64
+}
+
+// Engine applies the rules of a file to events and reloads the file when it
+// changes.
+type Engine struct {
+	path  string
+	rules atomic.Pointer[ruleSet]
+
+	mu       sync.Mutex
+	counters map[string]*counters
+}
+
+// Load reads the rules file at path. An empty path disables rules and
+// returns nil.
+func Load(path string) (*Engine, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	e := &Engine{path: path, counters: map[string]*counters{}}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// New builds an Engine from cfg, without a file to reload.
+func New(cfg Config) (*Engine, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	e := &Engine{counters: map[string]*counters{}}
+	e.rules.Store(&ruleSet{version: cfg.Version, rules: cfg.Rules})
+	return e, nil
+}
+
+// Version returns the version of the active rules.
+func (e *Engine) Version() int {
+	return e.rules.Load().version
+}
+
+// Reload reads the rules file again. On error the active rules are kept.
+func (e *Engine) Reload() error {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return fmt.Errorf("read rules: %w", err)
+	}
+	body, err := os.ReadFile(e.path)
+	if err != nil {
+		return fmt.Errorf("read rules: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		return fmt.Errorf("parse rules: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	e.rules.Store(&ruleSet{version: cfg.Version, rules: cfg.Rules, modTime: info.ModTime(), size: info.Size()})
+	return nil
+}
+
+// Watch checks the rules file every interval and reloads it when its size
+// or modification time changed, until ctx is done. Invalid files are logged
+// and ignored.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(e.path)
+			if err != nil {
+				log.Printf("[WARN] stat rules file: %v", err)
+				continue
+			}
+			active := e.rules.Load()
+			if info.ModTime().Equal(active.modTime) && info.Size() == active.size {
+				continue
+			}
+
+			if err := e.Reload(); err != nil {
+				log.Printf("[WARN] reload rules, keeping version %d: %v", active.version, err)
+				// Remember the broken file so it is not reported every tick.
+				e.rules.Store(&ruleSet{version: active.version, rules: active.rules, modTime: info.ModTime(), size: info.Size()})
+				continue
+			}
+			log.Printf("[INFO] rules reloaded (version %d)", e.Version())
+		}
+	}
+}
+
+// Apply runs the rules in order on event. Each rule sees the event as
+// changed by the rules before it. It returns false when a rule dropped the
+// event. A nil Engine keeps every event unchanged.
+func (e *Engine) Apply(event model.Event) (model.Event, bool) {
+	if e == nil {
+		return event, true
+	}
+
+	copied := false
+	for _, rule := range e.rules.Load().rules {
+		if !matches(rule.Match, event) {
+			continue
+		}
+		c := e.countersFor(rule.Name)
+		c.hits.Add(1)
+
+		if !copied {
+			// Rules never modify the caller's metadata map.
+			event.Metadata = maps.Clone(event.Metadata)
+			copied = true
+		}
+		if !apply(rule, &event) {
+			c.dropped.Add(1)
+			return event, false
+		}
+	}
+	return event, true
+}
+
+// Stats returns the counters of the active rules, in rule order.
+func (e *Engine) Stats() []Stats {
+	rules := e.rules.Load().rules
+	stats := make([]Stats, 0, len(rules))
+	for _, rule := range rules {
+		c := e.countersFor(rule.Name)
+		stats = append(stats, Stats{Name: rule.Name, Hits: c.hits.Load(), Dropped: c.dropped.Load()})
+	}
+	return stats
+}
+
+func (e *Engine) countersFor(name string) *counters {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, ok := e.counters[name]
+	if !ok {
+		c = &counters{}
+		e.counters[name] = c
+	}
+	return c
+}
+
+func matches(m Match, event model.Event) bool {
+	if len(m.EventNames) > 0 && !slices.Contains(m.EventNames, event.EventName) {
+		return false
+	}
+	if len(m.Channels) > 0 && !slices.Contains(m.Channels, event.Channel) {
+		return false
+	}
+	for _, tag := range m.Tags {
+		if !slices.Contains(event.Tags, tag) {
+			return false
+		}
+	}
+	for key, want := range m.Metadata {
+		value, ok := event.Metadata[key]
+		if !ok || (want != "*" && fmt.Sprint(value) != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply runs the actions of rule and reports whether the event is kept.
+func apply(rule Rule, event *model.Event) bool {
+	for _, action := range rule.Actions {
+		switch action.Type {
+		case ActionDrop:
+			return false
+		case ActionRename:
+			event.EventName = action.EventName
+		case ActionSet:
+			setField(event, action.Field, action.Value)
+		case ActionRemove:
+			setField(event, action.Field, nil)
+		case ActionSample:
+			if !sampled(rule.Name, event.UserID, action.Percent) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// setField sets field to value, or clears it when value is nil.
+func setField(event *model.Event, field string, value any) {
+	if key, ok := strings.CutPrefix(field, metadataField); ok {
+		if value == nil {
+			delete(event.Metadata, key)
+			return
+		}
+		if event.Metadata == nil {
+			event.Metadata = map[string]any{}
+		}
+		event.Metadata[key] = value
+		return
+	}
+
+	text, _ := value.(string)
+	switch field {
+	case "channel":
+		event.Channel = text
+	case "campaign_id":
+		event.CampaignID = text
+	}
+}
+
+// sampled keeps a stable share of users: the same user is always kept or
+// always dropped by a rule, so unique user counts scale with the share.
+func sampled(ruleName, userID string, percent float64) bool {
+	h := fnv.New64a()
+	h.Write([]byte(ruleName))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return float64(h.Sum64()%10000) < percent*100
+}
@@ -0,0 +1,203 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newEngine(t *testing.T, rules ...Rule) *Engine {
+	t.Helper()
+	e, err := New(Config{Version: 1, Rules: rules})
+	require.NoError(t, err)
+	return e
+}
+
+func TestApply_Matching(t *testing.T) {
+	e := newEngine(t, Rule{
+		Name: "tag-campaign",
+		Match: Match{
+			EventNames: []string{"click", "view"},
+			Channels:   []string{"web"},
+			Tags:       []string{"promo", "summer"},
+			Metadata:   map[string]string{"plan": "pro", "seats": "3", "ref": "*"},
+		},
+		Actions: []Action{{Type: ActionSet, Field: "campaign_id", Value: "summer"}},
+	})
+	base := model.Event{
+		EventName: "view",
+		Channel:   "web",
+		Tags:      []string{"summer", "promo", "extra"},
+		Metadata:  map[string]any{"plan": "pro", "seats": float64(3), "ref": "newsletter"},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		mutate func(*model.Event)
+		want   bool
+	}{
+		{"all conditions", func(*model.Event) {}, true},
+		{"other event name", func(e *model.Event) { e.EventName = "purchase" }, false},
+		{"other channel", func(e *model.Event) { e.Channel = "ios" }, false},
+		{"missing tag", func(e *model.Event) { e.Tags = []string{"promo"} }, false},
+		{"other metadata value", func(e *model.Event) { e.Metadata = map[string]any{"plan": "free", "seats": 3, "ref": "x"} }, false},
+		{"missing wildcard key", func(e *model.Event) { e.Metadata = map[string]any{"plan": "pro", "seats": 3} }, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			event := base
+			tt.mutate(&event)
+
+			got, keep := e.Apply(event)
+			require.True(t, keep)
+			require.Equal(t, tt.want, got.CampaignID == "summer")
+		})
+	}
+}
+
+func TestApply_Actions(t *testing.T) {
+	e := newEngine(t,
+		Rule{
+			Name:  "rewrite",
+			Match: Match{EventNames: []string{"tap"}},
+			Actions: []Action{
+				{Type: ActionRename, EventName: "click"},
+				{Type: ActionSet, Field: "channel", Value: "mobile"},
+				{Type: ActionSet, Field: "metadata.source", Value: "rules"},
+				{Type: ActionRemove, Field: "metadata.debug"},
+				{Type: ActionRemove, Field: "campaign_id"},
+			},
+		},
+		// Sees the event as rewritten by the rule before it.
+		Rule{Name: "drop-mobile-click", Match: Match{EventNames: []string{"click"}, Channels: []string{"mobile"}, Tags: []string{"internal"}}, Actions: []Action{{Type: ActionDrop}}},
+	)
+
+	metadata := map[string]any{"debug": true, "plan": "pro"}
+	got, keep := e.Apply(model.Event{EventName: "tap", Channel: "ios", CampaignID: "c1", Metadata: metadata})
+	require.True(t, keep)
+	require.Equal(t, model.Event{EventName: "click", Channel: "mobile", Metadata: map[string]any{"plan": "pro", "source": "rules"}}, got)
+	require.Equal(t, map[string]any{"debug": true, "plan": "pro"}, metadata, "caller metadata must not change")
+
+	_, keep = e.Apply(model.Event{EventName: "tap", Channel: "ios", Tags: []string{"internal"}})
+	require.False(t, keep)
+
+	require.Equal(t, []Stats{
+		{Name: "rewrite", Hits: 2},
+		{Name: "drop-mobile-click", Hits: 1, Dropped: 1},
+	}, e.Stats())
+}
+
+func TestApply_SampleIsStablePerUser(t *testing.T) {
+	e := newEngine(t, Rule{Name: "sample", Match: Match{EventNames: []string{"heartbeat"}}, Actions: []Action{{Type: ActionSample, Percent: 25}}})
+
+	kept := 0
+	for i := 0; i < 2000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		_, first := e.Apply(model.Event{EventName: "heartbeat", UserID: user})
+		_, second := e.Apply(model.Event{EventName: "heartbeat", UserID: user})
+		require.Equal(t, first, second)
+		if first {
+			kept++
+		}
+	}
+	require.InDelta(t, 500, kept, 100)
+
+	_, keep := e.Apply(model.Event{EventName: "click", UserID: "user-1"})
+	require.True(t, keep)
+}
+
+func TestApply_NilEngine(t *testing.T) {
+	var e *Engine
+	event := model.Event{EventName: "click"}
+
+	got, keep := e.Apply(event)
+	require.True(t, keep)
+	require.Equal(t, event, got)
+}
+
+func TestConfigValidate(t *testing.T) {
+	drop := []Action{{Type: ActionDrop}}
+	for _, tt := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"no version", Config{}},
+		{"no name", Config{Version: 1, Rules: []Rule{{Actions: drop}}}},
+		{"duplicate name", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: drop}, {Name: "a", Actions: drop}}}},
+		{"no actions", Config{Version: 1, Rules: []Rule{{Name: "a"}}}},
+		{"unknown action", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: []Action{{Type: "explode"}}}}}},
+		{"rename without name", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: []Action{{Type: ActionRename}}}}}},
+		{"set unknown field", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: []Action{{Type: ActionSet, Field: "user_id", Value: "x"}}}}}},
+		{"set channel to number", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: []Action{{Type: ActionSet, Field: "channel", Value: 1.0}}}}}},
+		{"remove channel", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: []Action{{Type: ActionRemove, Field: "channel"}}}}}},
+		{"sample above 100", Config{Version: 1, Rules: []Rule{{Name: "a", Actions: []Action{{Type: ActionSample, Percent: 150}}}}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.cfg.Validate())
+		})
+	}
+}
+
+func writeRules(t *testing.T, path, body string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestLoad(t *testing.T) {
+	e, err := Load("")
+	require.NoError(t, err)
+	require.Nil(t, e)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{"version": 1, "rules": [{"name": "bad", "actions": [{"type": "sample"}]}]}`, time.Now())
+	_, err = Load(path)
+	require.ErrorContains(t, err, "rule bad")
+}
+
+func TestWatch_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	start := time.Now().Add(-time.Hour)
+	writeRules(t, path, `{"version": 1, "rules": [{"name": "drop-test", "match": {"channels": ["test"]}, "actions": [{"type": "drop"}]}]}`, start)
+
+	e, err := Load(path)
+	require.NoError(t, err)
+	_, keep := e.Apply(model.Event{Channel: "test"})
+	require.False(t, keep)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Watch(ctx, 10*time.Millisecond)
+
+	// A broken file keeps the active rules.
+	writeRules(t, path, `{"version": 2, "rules": [`, start.Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 1, e.Version())
+
+	writeRules(t, path, `{"version": 3, "rules": [
+		{"name": "drop-test", "match": {"channels": ["test", "qa"]}, "actions": [{"type": "drop"}]},
+		{"name": "rename", "match": {"event_names": ["tap"]}, "actions": [{"type": "rename", "event_name": "click"}]}
+	]}`, start.Add(2*time.Minute))
+	require.Eventually(t, func() bool { return e.Version() == 3 }, time.Second, 10*time.Millisecond)
+
+	_, keep = e.Apply(model.Event{Channel: "qa"})
+	require.False(t, keep)
+	got, keep := e.Apply(model.Event{EventName: "tap", Channel: "web"})
+	require.True(t, keep)
+	require.Equal(t, "click", got.EventName)
+
+	// Counters of a rule kept across the reload continue.
+	require.Equal(t, []Stats{
+		{Name: "drop-test", Hits: 2, Dropped: 2},
+		{Name: "rename", Hits: 1},
+	}, e.Stats())
+}
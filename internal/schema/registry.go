@@ -0,0 +1,282 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"event-metrics-service/internal/model"
+)
+
+// Mode controls what happens when an event violates its schema.
+type Mode string
+
+const (
+	// ModeOff disables schema validation.
+	ModeOff Mode = "off"
+	// ModeEnforce rejects events that violate their schema.
+	ModeEnforce Mode = "enforce"
+	// ModeWarn logs and counts violations but accepts the event.
+	ModeWarn Mode = "warn"
+)
+
+// ParseMode validates a configured mode string.
+func ParseMode(raw string) (Mode, error) {
+	switch Mode(raw) {
+	case ModeOff, ModeEnforce, ModeWarn:
+		return Mode(raw), nil
+	default:
+		return "", fmt.Errorf("invalid schema mode %q, expected off, enforce or warn", raw)
+	}
+}
+
+// FieldType is a JSON type a metadata value may have.
+type FieldType string
+
+const (
+	TypeString  FieldType = "string"
+	TypeNumber  FieldType = "number"
+	TypeInteger FieldType = "integer"
+	TypeBoolean FieldType = "boolean"
+	TypeObject  FieldType = "object"
+	TypeArray   FieldType = "array"
+)
+
+// MetadataField describes one metadata key of an event.
+type MetadataField struct {
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required,omitempty"`
+}
+
+// TagConstraints limits the tags an event may carry.
+type TagConstraints struct {
+	// Allowed lists permitted tags; empty allows any tag.
+	Allowed []string `json:"allowed,omitempty"`
+
+	// MaxCount caps the number of tags; zero means no limit.
+	MaxCount int `json:"max_count,omitempty"`
+}
+
+// EventSchema defines what a valid event with a given name looks like.
+type EventSchema struct {
+	EventName string `json:"event_name"`
+
+	// Channels lists permitted channels; empty allows any channel.
+	Channels []string `json:"channels,omitempty"`
+
+	Metadata map[string]MetadataField `json:"metadata,omitempty"`
+
+	// AllowUnknownMetadata accepts metadata keys not listed in Metadata.
+	AllowUnknownMetadata bool `json:"allow_unknown_metadata,omitempty"`
+
+	Tags TagConstraints `json:"tags,omitempty"`
+}
+
+// Violation is a single field-level schema failure.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Document is the on-disk registry format.
+type Document struct {
+	// AllowUnregisteredEvents accepts event names without a schema.
+	AllowUnregisteredEvents bool          `json:"allow_unregistered_events,omitempty"`
+	Events                  []EventSchema `json:"events"`
+}
+
+// Registry holds event schemas and violation counters. It is safe for
+// concurrent use; schemas can be changed at runtime through the admin API.
+type Registry struct {
+	mu                sync.RWMutex
+	path              string
+	mode              Mode
+	allowUnregistered bool
+	schemas           map[string]EventSchema
+	violationsByEvent map[string]uint64
+}
+
+// NewRegistry creates an empty registry. When path is set, changes made at
+// runtime are written back to that file.
+func NewRegistry(mode Mode, path string) *Registry {
+	return &Registry{
+		path:              path,
+		mode:              mode,
+		schemas:           map[string]EventSchema{},
+		violationsByEvent: map[string]uint64{},
+	}
+}
+
+// LoadRegistry reads a registry document from path. A missing file yields an
+// empty registry that will be created on the first change.
+func LoadRegistry(mode Mode, path string) (*Registry, error) {
+	r := NewRegistry(mode, path)
+	if path == "" {
+		return r, nil
+	}
+
+	body, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read schema registry: %w", err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse schema registry: %w", err)
+	}
+
+	r.allowUnregistered = doc.AllowUnregisteredEvents
+	for _, s := range doc.Events {
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+		r.schemas[s.EventName] = s
+	}
+	return r, nil
+}
+
+// Mode returns the configured validation mode.
+func (r *Registry) Mode() Mode {
+	return r.mode
+}
+
+// Validate checks an event against its schema and returns every violation.
+func (r *Registry) Validate(event model.Event) []Violation {
+	r.mu.RLock()
+	s, ok := r.schemas[event.EventName]
+	allowUnregistered := r.allowUnregistered
+	r.mu.RUnlock()
+
+	if !ok {
+		if allowUnregistered {
+			return nil
+		}
+		return []Violation{{Field: "event_name", Message: fmt.Sprintf("event %q is not registered", event.EventName)}}
+	}
+	return s.check(event)
+}
+
+// UnregisteredEvents is the violation counter shared by all event names
+// without a schema. Client-chosen names never become keys of their own, so
+// the counters stay bounded.
+const UnregisteredEvents = "_unregistered"
+
+// RecordViolations increments the violation counter for an event name.
+func (r *Registry) RecordViolations(eventName string, count int) {
+	r.mu.Lock()
+	if _, ok := r.schemas[eventName]; !ok {
+		eventName = UnregisteredEvents
+	}
+	r.violationsByEvent[eventName] += uint64(count)
+	r.mu.Unlock()
+}
+
+// Violations returns a snapshot of violation counts per event name.
+func (r *Registry) Violations() map[string]uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]uint64, len(r.violationsByEvent))
+	for name, count := range r.violationsByEvent {
+		out[name] = count
+	}
+	return out
+}
+
+// List returns all schemas sorted by event name.
+func (r *Registry) List() []EventSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sortedLocked()
+}
+
+// Put adds or replaces a schema and persists the registry.
+func (r *Registry) Put(s EventSchema) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, existed := r.schemas[s.EventName]
+	r.schemas[s.EventName] = s
+	if err := r.saveLocked(); err != nil {
+		if existed {
+			r.schemas[s.EventName] = previous
+		} else {
+			delete(r.schemas, s.EventName)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a schema and persists the registry. It reports whether the
+// schema existed.
+func (r *Registry) Delete(eventName string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.schemas[eventName]
+	if !ok {
+		return false, nil
+	}
+
+	delete(r.schemas, eventName)
+	if err := r.saveLocked(); err != nil {
+		r.schemas[eventName] = previous
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Registry) sortedLocked() []EventSchema {
+	out := make([]EventSchema, 0, len(r.schemas))
+	for _, s := range r.schemas {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EventName < out[j].EventName })
+	return out
+}
+
+// saveLocked writes the registry to its file via a temp file and rename so a
+// crash never leaves a truncated document behind.
+func (r *Registry) saveLocked() error {
+	if r.path == "" {
+		return nil
+	}
+
+	body, err := json.MarshalIndent(Document{
+		AllowUnregisteredEvents: r.allowUnregistered,
+		Events:                  r.sortedLocked(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode schema registry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".schemas-*.json")
+	if err != nil {
+		return fmt.Errorf("write schema registry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(body, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write schema registry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write schema registry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("write schema registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func productView() EventSchema {
+	return EventSchema{
+		EventName: "product_view",
+		Channels:  []string{"web", "mobile_app"},
+		Metadata: map[string]MetadataField{
+			"price":    {Type: TypeNumber, Required: true},
+			"quantity": {Type: TypeInteger},
+			"currency": {Type: TypeString},
+		},
+		Tags: TagConstraints{Allowed: []string{"electronics", "sale"}, MaxCount: 2},
+	}
+}
+
+func TestRegistryValidate_Valid(t *testing.T) {
+	r := NewRegistry(ModeEnforce, "")
+	require.NoError(t, r.Put(productView()))
+
+	violations := r.Validate(model.Event{
+		EventName: "product_view",
+		Channel:   "web",
+		Tags:      []string{"sale"},
+		Metadata:  map[string]any{"price": 9.99, "quantity": float64(2)},
+	})
+	require.Empty(t, violations)
+}
+
+func TestRegistryValidate_ReportsEveryViolation(t *testing.T) {
+	r := NewRegistry(ModeEnforce, "")
+	require.NoError(t, r.Put(productView()))
+
+	violations := r.Validate(model.Event{
+		EventName: "product_view",
+		Channel:   "email",
+		Tags:      []string{"sale", "electronics", "clearance"},
+		Metadata:  map[string]any{"quantity": 1.5, "currency": 3, "colour": "red"},
+	})
+	require.Equal(t, []Violation{
+		{Field: "channel", Message: `channel "email" is not allowed`},
+		{Field: "tags", Message: "at most 2 tags are allowed"},
+		{Field: "tags", Message: `tag "clearance" is not allowed`},
+		{Field: "metadata.currency", Message: "must be of type string"},
+		{Field: "metadata.price", Message: "is required"},
+		{Field: "metadata.quantity", Message: "must be of type integer"},
+		{Field: "metadata.colour", Message: "is not allowed"},
+	}, violations)
+}
+
+func TestRegistryValidate_UnregisteredEvent(t *testing.T) {
+	r := NewRegistry(ModeEnforce, "")
+	require.NoError(t, r.Put(productView()))
+
+	violations := r.Validate(model.Event{EventName: "prodcut_view", Channel: "web"})
+	require.Equal(t, []Violation{{Field: "event_name", Message: `event "prodcut_view" is not registered`}}, violations)
+}
+
+func TestLoadRegistry_AllowUnregisteredEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"allow_unregistered_events": true, "events": []}`), 0o644))
+
+	r, err := LoadRegistry(ModeEnforce, path)
+	require.NoError(t, err)
+	require.Empty(t, r.Validate(model.Event{EventName: "anything", Channel: "web"}))
+}
+
+func TestLoadRegistry_RejectsUnknownType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.json")
+	body := `{"events": [{"event_name": "signup", "metadata": {"plan": {"type": "text"}}}]}`
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+
+	_, err := LoadRegistry(ModeEnforce, path)
+	require.ErrorContains(t, err, `unsupported type "text"`)
+}
+
+func TestRegistryPersistsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.json")
+
+	r, err := LoadRegistry(ModeEnforce, path)
+	require.NoError(t, err)
+	require.NoError(t, r.Put(productView()))
+	require.NoError(t, r.Put(EventSchema{EventName: "signup"}))
+
+	deleted, err := r.Delete("signup")
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	reloaded, err := LoadRegistry(ModeEnforce, path)
+	require.NoError(t, err)
+	require.Equal(t, []EventSchema{productView()}, reloaded.List())
+}
+
+func TestRegistryViolationCounters(t *testing.T) {
+	r := NewRegistry(ModeWarn, "")
+	require.NoError(t, r.Put(productView()))
+	r.RecordViolations("product_view", 2)
+	r.RecordViolations("product_view", 1)
+	r.RecordViolations("prodcut_view", 1)
+	r.RecordViolations("random-1234", 1)
+
+	require.Equal(t, map[string]uint64{"product_view": 3, UnregisteredEvents: 2}, r.Violations())
+}
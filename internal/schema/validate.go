@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"event-metrics-service/internal/model"
+)
+
+// Validate rejects schema definitions that could never match an event.
+func (s EventSchema) Validate() error {
+	if s.EventName == "" {
+		return fmt.Errorf("schema event_name is required")
+	}
+	if s.Tags.MaxCount < 0 {
+		return fmt.Errorf("schema %s: tags.max_count must not be negative", s.EventName)
+	}
+	for key, field := range s.Metadata {
+		switch field.Type {
+		case TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeObject, TypeArray:
+		default:
+			return fmt.Errorf("schema %s: metadata.%s has unsupported type %q", s.EventName, key, field.Type)
+		}
+	}
+	return nil
+}
+
+// check returns the violations of event against s, ordered by field.
+func (s EventSchema) check(event model.Event) []Violation {
+	var violations []Violation
+
+	if len(s.Channels) > 0 && !slices.Contains(s.Channels, event.Channel) {
+		violations = append(violations, Violation{
+			Field:   "channel",
+			Message: fmt.Sprintf("channel %q is not allowed", event.Channel),
+		})
+	}
+
+	if s.Tags.MaxCount > 0 && len(event.Tags) > s.Tags.MaxCount {
+		violations = append(violations, Violation{
+			Field:   "tags",
+			Message: fmt.Sprintf("at most %d tags are allowed", s.Tags.MaxCount),
+		})
+	}
+	if len(s.Tags.Allowed) > 0 {
+		for _, tag := range event.Tags {
+			if !slices.Contains(s.Tags.Allowed, tag) {
+				violations = append(violations, Violation{
+					Field:   "tags",
+					Message: fmt.Sprintf("tag %q is not allowed", tag),
+				})
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(s.Metadata))
+	for key := range s.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := s.Metadata[key]
+		value, present := event.Metadata[key]
+		if !present || value == nil {
+			if field.Required {
+				violations = append(violations, Violation{Field: "metadata." + key, Message: "is required"})
+			}
+			continue
+		}
+		if !matchesType(value, field.Type) {
+			violations = append(violations, Violation{
+				Field:   "metadata." + key,
+				Message: fmt.Sprintf("must be of type %s", field.Type),
+			})
+		}
+	}
+
+	if !s.AllowUnknownMetadata {
+		unknown := make([]string, 0)
+		for key := range event.Metadata {
+			if _, ok := s.Metadata[key]; !ok {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			violations = append(violations, Violation{Field: "metadata." + key, Message: "is not allowed"})
+		}
+	}
+
+	return violations
+}
+
+// matchesType checks a value decoded from JSON against a declared type.
+func matchesType(value any, typ FieldType) bool {
+	switch typ {
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case TypeNumber:
+		_, ok := number(value)
+		return ok
+	case TypeInteger:
+		f, ok := number(value)
+		return ok && f == math.Trunc(f)
+	case TypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case TypeArray:
+		_, ok := value.([]any)
+		return ok
+	default:
+		return false
+	}
+}
+
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+	"event-metrics-service/internal/schema"
+)
+
+// ErrNotSupported is returned for admin actions the storage backend lacks.
+var ErrNotSupported = repository.ErrNotSupported
+
+// ErrSchemaNotFound is returned when deleting a schema that is not registered.
+var ErrSchemaNotFound = errors.New("schema not found")
+
+type AdminService interface {
+	ListPartitions(ctx context.Context) ([]model.Partition, error)
+	DropPartitions(ctx context.Context, from, to time.Time) ([]model.Partition, error)
+	OptimizePartitions(ctx context.Context, from, to time.Time) ([]model.Partition, error)
+	ListSchemas() []schema.EventSchema
+	PutSchema(s schema.EventSchema) error
+	DeleteSchema(eventName string) error
+	SchemaViolations() map[string]uint64
+}
+
+// adminService implements operational actions on stored events.
+type adminService struct {
+	partitions repository.PartitionRepository
+	schemas    *schema.Registry
+}
+
+// NewAdminService constructs an adminService.
+func NewAdminService(partitions repository.PartitionRepository, schemas *schema.Registry) AdminService {
+	return &adminService{partitions: partitions, schemas: schemas}
+}
+
+// ListPartitions returns the active daily partitions of the events table.
+func (s *adminService) ListPartitions(ctx context.Context) ([]model.Partition, error) {
+	return s.partitions.ListPartitions(ctx)
+}
+
+// DropPartitions drops every daily partition whose date falls within
+// [from, to], both inclusive, and returns the partitions that were removed.
+func (s *adminService) DropPartitions(ctx context.Context, from, to time.Time) ([]model.Partition, error) {
+	return s.eachPartition(ctx, from, to, s.partitions.DropPartition)
+}
+
+// OptimizePartitions forces a final merge of every daily partition within
+// [from, to], both inclusive, so duplicates stop being counted by eventual
+// consistency reads. It returns the partitions that were merged.
+func (s *adminService) OptimizePartitions(ctx context.Context, from, to time.Time) ([]model.Partition, error) {
+	return s.eachPartition(ctx, from, to, s.partitions.OptimizePartition)
+}
+
+// eachPartition applies action to every partition in the inclusive date
+// range, stopping at the first error, and returns the partitions processed.
+func (s *adminService) eachPartition(ctx context.Context, from, to time.Time, action func(context.Context, string) error) ([]model.Partition, error) {
+	if from.IsZero() || to.IsZero() {
+		return nil, &ValidationError{Message: "from and to are required"}
+	}
+
+	if from.After(to) {
+		return nil, &ValidationError{Message: "from must be before to"}
+	}
+
+	partitions, err := s.partitions.ListPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	first := from.UTC().Format("20060102")
+	last := to.UTC().Format("20060102")
+
+	done := []model.Partition{}
+	for _, p := range partitions {
+		if p.ID < first || p.ID > last {
+			continue
+		}
+		if err := action(ctx, p.ID); err != nil {
+			return done, err
+		}
+		done = append(done, p)
+	}
+
+	return done, nil
+}
+
+// ListSchemas returns the registered event schemas.
+func (s *adminService) ListSchemas() []schema.EventSchema {
+	return s.schemas.List()
+}
+
+// PutSchema registers or replaces the schema for an event name.
+func (s *adminService) PutSchema(eventSchema schema.EventSchema) error {
+	if err := eventSchema.Validate(); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	return s.schemas.Put(eventSchema)
+}
+
+// DeleteSchema removes the schema for an event name.
+func (s *adminService) DeleteSchema(eventName string) error {
+	deleted, err := s.schemas.Delete(eventName)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSchemaNotFound
+	}
+	return nil
+}
+
+// SchemaViolations returns violation counts per event name since start.
+func (s *adminService) SchemaViolations() map[string]uint64 {
+	return s.schemas.Violations()
+}
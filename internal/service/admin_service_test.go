@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/schema"
+	mockrepository "event-metrics-service/internal/testdata/mockrepository"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminServiceTestSuite struct {
+	suite.Suite
+
+	partitions *mockrepository.PartitionRepository
+	service    AdminService
+}
+
+func TestAdminServiceSuite(t *testing.T) {
+	suite.Run(t, new(AdminServiceTestSuite))
+}
+
+func (s *AdminServiceTestSuite) SetupTest() {
+	s.partitions = &mockrepository.PartitionRepository{}
+	s.service = NewAdminService(s.partitions, schema.NewRegistry(schema.ModeEnforce, ""))
+}
+
+func (s *AdminServiceTestSuite) TearDownTest() {
+	s.partitions.AssertExpectations(s.T())
+}
+
+func (s *AdminServiceTestSuite) TestDropPartitions_InclusiveRange() {
+	ctx := context.Background()
+	partitions := []model.Partition{
+		{ID: "20250101", Rows: 10},
+		{ID: "20250102", Rows: 20},
+		{ID: "20250103", Rows: 30},
+		{ID: "20250104", Rows: 40},
+	}
+	s.partitions.On("ListPartitions", mock.Anything).Return(partitions, nil).Once()
+	s.partitions.On("DropPartition", mock.Anything, "20250102").Return(nil).Once()
+	s.partitions.On("DropPartition", mock.Anything, "20250103").Return(nil).Once()
+
+	from := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+	dropped, err := s.service.DropPartitions(ctx, from, to)
+
+	s.NoError(err)
+	s.Equal(partitions[1:3], dropped)
+}
+
+func (s *AdminServiceTestSuite) TestDropPartitions_StopsOnError() {
+	ctx := context.Background()
+	expectedErr := errors.New("drop failed")
+	partitions := []model.Partition{{ID: "20250101"}, {ID: "20250102"}}
+	s.partitions.On("ListPartitions", mock.Anything).Return(partitions, nil).Once()
+	s.partitions.On("DropPartition", mock.Anything, "20250101").Return(expectedErr).Once()
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	dropped, err := s.service.DropPartitions(ctx, day, day.Add(24*time.Hour))
+
+	s.ErrorIs(err, expectedErr)
+	s.Empty(dropped)
+}
+
+func (s *AdminServiceTestSuite) TestDropPartitions_Validation() {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	_, err := s.service.DropPartitions(context.Background(), time.Time{}, day)
+	s.IsType(&ValidationError{}, err)
+
+	_, err = s.service.DropPartitions(context.Background(), day, day.Add(-24*time.Hour))
+	s.IsType(&ValidationError{}, err)
+}
+
+func (s *AdminServiceTestSuite) TestOptimizePartitions_InclusiveRange() {
+	partitions := []model.Partition{{ID: "20250101"}, {ID: "20250102"}, {ID: "20250103"}}
+	s.partitions.On("ListPartitions", mock.Anything).Return(partitions, nil).Once()
+	s.partitions.On("OptimizePartition", mock.Anything, "20250102").Return(nil).Once()
+	s.partitions.On("OptimizePartition", mock.Anything, "20250103").Return(nil).Once()
+
+	from := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	optimized, err := s.service.OptimizePartitions(context.Background(), from, from.Add(24*time.Hour))
+
+	s.NoError(err)
+	s.Equal([]model.Partition{{ID: "20250102"}, {ID: "20250103"}}, optimized)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+)
+
+// ErrAPIKeyNotFound is returned for unknown API key IDs.
+var ErrAPIKeyNotFound = auth.ErrKeyNotFound
+
+// ErrAPIKeyInactive is returned when rotating a revoked or expired key.
+var ErrAPIKeyInactive = auth.ErrKeyInactive
+
+// CreatedAPIKey is a newly issued key together with its plaintext token,
+// which is not retrievable afterwards.
+type CreatedAPIKey struct {
+	auth.APIKey
+	Token string `json:"token"`
+}
+
+type APIKeyService interface {
+	ListKeys() []auth.APIKey
+	CreateKey(name string, scopes []string, tenant string) (CreatedAPIKey, error)
+	RevokeKey(id string) (auth.APIKey, error)
+	RotateKey(id string, grace time.Duration) (CreatedAPIKey, error)
+	SetKeyLimits(id string, limits model.ClientLimits) (auth.APIKey, error)
+}
+
+// apiKeyService manages API keys in a key store.
+type apiKeyService struct {
+	keys *auth.KeyStore
+}
+
+// NewAPIKeyService constructs an apiKeyService.
+func NewAPIKeyService(keys *auth.KeyStore) APIKeyService {
+	return &apiKeyService{keys: keys}
+}
+
+// ListKeys returns all keys without their secrets.
+func (s *apiKeyService) ListKeys() []auth.APIKey {
+	return s.keys.List()
+}
+
+// CreateKey issues a key with the given name and scopes, pinned to tenant
+// unless it is empty.
+func (s *apiKeyService) CreateKey(name string, scopes []string, tenant string) (CreatedAPIKey, error) {
+	if name == "" {
+		return CreatedAPIKey{}, &ValidationError{Message: "name is required"}
+	}
+	if tenant != "" && !model.TenantIDPattern.MatchString(tenant) {
+		return CreatedAPIKey{}, &ValidationError{Message: "invalid tenant"}
+	}
+	if len(scopes) == 0 {
+		return CreatedAPIKey{}, &ValidationError{Message: "at least one scope is required"}
+	}
+
+	parsed := make([]auth.Scope, 0, len(scopes))
+	for _, raw := range scopes {
+		scope, err := auth.ParseScope(raw)
+		if err != nil {
+			return CreatedAPIKey{}, &ValidationError{Message: err.Error()}
+		}
+		parsed = append(parsed, scope)
+	}
+	// Admin routes act across tenants (privacy requests, key management,
+	// partitions), so a tenant pin could not be enforced there.
+	if tenant != "" && slices.Contains(parsed, auth.ScopeAdmin) {
+		return CreatedAPIKey{}, &ValidationError{Message: "admin keys cannot be pinned to a tenant"}
+	}
+
+	key, token, err := s.keys.Create(name, parsed, tenant)
+	if err != nil {
+		return CreatedAPIKey{}, err
+	}
+	return CreatedAPIKey{APIKey: key, Token: token}, nil
+}
+
+// RevokeKey disables a key immediately.
+func (s *apiKeyService) RevokeKey(id string) (auth.APIKey, error) {
+	return s.keys.Revoke(id)
+}
+
+// RotateKey issues a replacement key; the old one stays valid for grace.
+// Revoked and expired keys cannot be rotated.
+func (s *apiKeyService) RotateKey(id string, grace time.Duration) (CreatedAPIKey, error) {
+	if grace < 0 {
+		return CreatedAPIKey{}, &ValidationError{Message: "grace must not be negative"}
+	}
+
+	key, token, err := s.keys.Rotate(id, grace)
+	if err != nil {
+		return CreatedAPIKey{}, err
+	}
+	return CreatedAPIKey{APIKey: key, Token: token}, nil
+}
+
+// SetKeyLimits replaces the rate limits and daily event quota of a key.
+// Zero limits restore the deployment-wide defaults.
+func (s *apiKeyService) SetKeyLimits(id string, limits model.ClientLimits) (auth.APIKey, error) {
+	if err := limits.Validate(); err != nil {
+		return auth.APIKey{}, &ValidationError{Message: err.Error()}
+	}
+	return s.keys.SetLimits(id, &limits)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	svc := NewAPIKeyService(auth.NewKeyStore(""))
+
+	created, err := svc.CreateKey("dashboard", []string{"read"}, "acme")
+	require.NoError(t, err)
+	require.NotEmpty(t, created.Token)
+	require.Equal(t, []auth.Scope{auth.ScopeRead}, created.Scopes)
+	require.Equal(t, "acme", created.Tenant)
+	require.Len(t, svc.ListKeys(), 1)
+}
+
+func TestAPIKeyService_CreateKeyValidation(t *testing.T) {
+	svc := NewAPIKeyService(auth.NewKeyStore(""))
+
+	_, err := svc.CreateKey("", []string{"read"}, "")
+	require.IsType(t, &ValidationError{}, err)
+
+	_, err = svc.CreateKey("dashboard", nil, "")
+	require.IsType(t, &ValidationError{}, err)
+
+	_, err = svc.CreateKey("dashboard", []string{"superuser"}, "")
+	require.IsType(t, &ValidationError{}, err)
+
+	_, err = svc.CreateKey("dashboard", []string{"read"}, "not a tenant")
+	require.IsType(t, &ValidationError{}, err)
+
+	_, err = svc.CreateKey("ops", []string{"read", "admin"}, "acme")
+	require.IsType(t, &ValidationError{}, err)
+	require.Empty(t, svc.ListKeys())
+}
+
+func TestAPIKeyService_RotateKey(t *testing.T) {
+	svc := NewAPIKeyService(auth.NewKeyStore(""))
+	created, err := svc.CreateKey("backend", []string{"write"}, "")
+	require.NoError(t, err)
+
+	_, err = svc.RotateKey(created.ID, -time.Minute)
+	require.IsType(t, &ValidationError{}, err)
+
+	_, err = svc.RotateKey("missing", 0)
+	require.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	rotated, err := svc.RotateKey(created.ID, time.Hour)
+	require.NoError(t, err)
+	require.NotEqual(t, created.ID, rotated.ID)
+	require.Len(t, svc.ListKeys(), 2)
+}
+
+func TestAPIKeyService_SetKeyLimits(t *testing.T) {
+	svc := NewAPIKeyService(auth.NewKeyStore(""))
+	created, err := svc.CreateKey("backend", []string{"write"}, "")
+	require.NoError(t, err)
+
+	_, err = svc.SetKeyLimits(created.ID, model.ClientLimits{IngestRate: -1})
+	require.IsType(t, &ValidationError{}, err)
+
+	key, err := svc.SetKeyLimits(created.ID, model.ClientLimits{IngestRate: 20})
+	require.NoError(t, err)
+	require.Equal(t, 20.0, key.Limits.IngestRate)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+)
+
+// Audit search page sizes.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditService searches the access audit log.
+type AuditService interface {
+	SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditService constructs an auditService.
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// SearchAudit returns the newest records matching query, at most
+// maxAuditLimit of them.
+func (s *auditService) SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	if query.Limit == 0 {
+		query.Limit = defaultAuditLimit
+	}
+	if query.Limit < 0 || query.Limit > maxAuditLimit {
+		return nil, &ValidationError{Message: fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit)}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return nil, &ValidationError{Message: "from must not be after to"}
+	}
+
+	return s.repo.SearchAudit(ctx, query)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockrepository"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchAudit_DefaultsLimit(t *testing.T) {
+	repo := &mockrepository.AuditRepository{}
+	records := []model.AuditRecord{{Action: "GET /metrics"}}
+	repo.On("SearchAudit", mock.Anything, model.AuditQuery{TenantID: "acme", Limit: defaultAuditLimit}).Return(records, nil).Once()
+
+	got, err := NewAuditService(repo).SearchAudit(context.Background(), model.AuditQuery{TenantID: "acme"})
+
+	require.NoError(t, err)
+	require.Equal(t, records, got)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchAudit_Validation(t *testing.T) {
+	now := time.Now()
+	tests := map[string]model.AuditQuery{
+		"negative limit": {Limit: -1},
+		"limit too high": {Limit: maxAuditLimit + 1},
+		"inverted range": {From: now, To: now.Add(-time.Hour)},
+	}
+
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := &mockrepository.AuditRepository{}
+
+			_, err := NewAuditService(repo).SearchAudit(context.Background(), query)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			repo.AssertNotCalled(t, "SearchAudit", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+)
+
+// AuditWorker writes audit records in the background, batching them like
+// batchEventWorker batches events.
+type AuditWorker interface {
+	Record(record model.AuditRecord)
+	Shutdown()
+}
+
+type auditWorker struct {
+	repo          repository.AuditRepository
+	queue         chan model.AuditRecord
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Uint64
+	wg            sync.WaitGroup
+}
+
+// NewAuditWorker starts a worker that flushes records to repo every
+// interval or once batchSize records are queued.
+func NewAuditWorker(repo repository.AuditRepository, bufferSize int, batchSize int, interval time.Duration) AuditWorker {
+	worker := &auditWorker{
+		repo:          repo,
+		queue:         make(chan model.AuditRecord, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: interval,
+	}
+	worker.wg.Add(1)
+	go worker.startLoop()
+	return worker
+}
+
+// Record queues a record. Unlike event ingestion it never blocks: audited
+// requests must not stall behind a slow audit table, so records are dropped
+// and counted when the buffer is full.
+func (w *auditWorker) Record(record model.AuditRecord) {
+	select {
+	case w.queue <- record:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Shutdown drains the queue and stops the worker.
+func (w *auditWorker) Shutdown() {
+	close(w.queue)
+	w.wg.Wait()
+}
+
+func (w *auditWorker) startLoop() {
+	defer w.wg.Done()
+
+	var batch []model.AuditRecord
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record, ok := <-w.queue:
+			if !ok {
+				if len(batch) > 0 {
+					w.bulkInsert(batch)
+				}
+				return
+			}
+
+			batch = append(batch, record)
+			if len(batch) >= w.batchSize {
+				w.bulkInsert(batch)
+				batch = nil
+			}
+
+		case <-ticker.C:
+			if dropped := w.dropped.Swap(0); dropped > 0 {
+				log.Printf("[WARN] audit buffer full, dropped %d records", dropped)
+			}
+			if len(batch) > 0 {
+				w.bulkInsert(batch)
+				batch = nil
+			}
+		}
+	}
+}
+
+func (w *auditWorker) bulkInsert(records []model.AuditRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.repo.InsertAudit(ctx, records); err != nil {
+		log.Printf("[ERROR] audit insert of %d records failed: %v", len(records), err)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/testdata/mockrepository"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditWorker_BatchSizeTrigger(t *testing.T) {
+	repo := &mockrepository.AuditRepository{}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	repo.On("InsertAudit", mock.Anything, mock.MatchedBy(func(records []model.AuditRecord) bool {
+		return len(records) == 2
+	})).Run(func(mock.Arguments) { wg.Done() }).Return(nil).Once()
+
+	worker := NewAuditWorker(repo, 10, 2, time.Hour)
+	defer worker.Shutdown()
+
+	worker.Record(model.AuditRecord{Action: "GET /metrics"})
+	worker.Record(model.AuditRecord{Action: "GET /admin/keys"})
+
+	waitAudit(t, &wg)
+	repo.AssertExpectations(t)
+}
+
+func TestAuditWorker_ShutdownFlushes(t *testing.T) {
+	repo := &mockrepository.AuditRepository{}
+	repo.On("InsertAudit", mock.Anything, mock.MatchedBy(func(records []model.AuditRecord) bool {
+		return len(records) == 3
+	})).Return(nil).Once()
+
+	worker := NewAuditWorker(repo, 10, 100, time.Hour)
+	for i := 0; i < 3; i++ {
+		worker.Record(model.AuditRecord{Action: "GET /metrics"})
+	}
+	worker.Shutdown()
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuditWorker_ErrorDoesNotStopWorker(t *testing.T) {
+	repo := &mockrepository.AuditRepository{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	repo.On("InsertAudit", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { wg.Done() }).Return(errors.New("audit_log is read-only")).Twice()
+
+	worker := NewAuditWorker(repo, 10, 1, time.Hour)
+	defer worker.Shutdown()
+
+	worker.Record(model.AuditRecord{Action: "GET /metrics"})
+	worker.Record(model.AuditRecord{Action: "GET /metrics"})
+
+	waitAudit(t, &wg)
+	repo.AssertExpectations(t)
+}
+
+func TestAuditWorker_DropsWhenFull(t *testing.T) {
+	repo := &mockrepository.AuditRepository{}
+	block := make(chan struct{})
+	repo.On("InsertAudit", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { <-block }).Return(nil)
+
+	worker := NewAuditWorker(repo, 1, 1, time.Hour).(*auditWorker)
+
+	// The first record is taken by the loop, which then blocks inserting
+	// it; the second fills the buffer and the rest are dropped.
+	worker.Record(model.AuditRecord{})
+	require.Eventually(t, func() bool { return len(worker.queue) == 0 }, time.Second, time.Millisecond)
+	for i := 0; i < 4; i++ {
+		worker.Record(model.AuditRecord{})
+	}
+
+	require.Equal(t, uint64(3), worker.dropped.Load())
+	close(block)
+	worker.Shutdown()
+}
+
+func waitAudit(t *testing.T, wg *sync.WaitGroup) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the audit worker")
+	}
+}
@@ -3,43 +3,193 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"event-metrics-service/internal/audit"
+	"event-metrics-service/internal/enrich"
 	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/pii"
 	"event-metrics-service/internal/repository"
+	"event-metrics-service/internal/rules"
+	"event-metrics-service/internal/schema"
 )
 
-// ValidationError represents user input issues.
+// ValidationError represents user input issues. Details lists individual
+// field failures when more than one thing can be wrong at once.
 type ValidationError struct {
 	Message string
+	Details []FieldError
+}
+
+// ErrEventDropped is returned by BuildEvent when an ingest rule drops the
+// event. The request is still accepted.
+var ErrEventDropped = errors.New("event dropped by rule")
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 func (e *ValidationError) Error() string {
 	return e.Message
 }
 
+// Guardrail errors surfaced from the repository. Callers match them with
+// errors.Is to pick an HTTP status.
+var (
+	ErrQueryTimeout       = repository.ErrQueryTimeout
+	ErrQueryLimitExceeded = repository.ErrQueryLimitExceeded
+)
+
+// MetricsLimits bounds what a single metrics request may ask for.
+type MetricsLimits struct {
+	// Timeout is the per-request deadline applied to the repository query.
+	Timeout time.Duration
+
+	// MaxWindow is the largest allowed to-from span, keyed by group_by.
+	// A missing or zero entry means no limit.
+	MaxWindow map[string]time.Duration
+}
+
+// Late event policies, applied to events whose timestamp lies more than the
+// past tolerance before they were received.
+const (
+	// LatePolicyAccept stores late events like any other.
+	LatePolicyAccept = "accept"
+	// LatePolicyReject answers late events with a validation error.
+	LatePolicyReject = "reject"
+	// LatePolicyRoute stores late events in the late_events table instead.
+	LatePolicyRoute = "route"
+)
+
+// LateEventPolicy decides what happens to events that arrive late.
+type LateEventPolicy struct {
+	// PastTolerance is how far before its receive time an event may be
+	// timestamped. Zero disables the policy.
+	PastTolerance time.Duration
+
+	// Policy is LatePolicyAccept, LatePolicyReject or LatePolicyRoute.
+	Policy string
+
+	// Worker writes routed events to the late events repository.
+	Worker BatchEventWorker
+}
+
+// isLate reports whether an event with timestamp ts received at receivedAt
+// is older than the tolerance allows.
+func (p LateEventPolicy) isLate(ts, receivedAt time.Time) bool {
+	return p.PastTolerance > 0 && ts.Before(receivedAt.Add(-p.PastTolerance))
+}
+
 // eventService wires business logic for events and metrics.
 type eventService struct {
 	repo            repository.EventRepository
 	worker          BatchEventWorker
 	now             func() time.Time
 	futureTolerance time.Duration
+	limits          MetricsLimits
+	schemas         *schema.Registry
+	tenantSchemas   map[string]*schema.Registry
+	consistency     string
+	exportLimits    ExportLimits
+	pii             *pii.Transformer
+	enrichers       enrich.Chain
+	rules           *rules.Engine
+	late            LateEventPolicy
+}
+
+// EventServiceOption configures optional ingest behaviour.
+type EventServiceOption func(*eventService)
+
+// WithSchemaRegistry validates built events against registered schemas.
+func WithSchemaRegistry(registry *schema.Registry) EventServiceOption {
+	return func(s *eventService) {
+		s.schemas = registry
+	}
+}
+
+// WithTenantSchemaRegistries validates the events of the listed tenants
+// against their own registries instead of the global one.
+func WithTenantSchemaRegistries(registries map[string]*schema.Registry) EventServiceOption {
+	return func(s *eventService) {
+		s.tenantSchemas = registries
+	}
+}
+
+// WithDefaultConsistency sets the consistency used when a metrics request
+// does not ask for one.
+func WithDefaultConsistency(consistency string) EventServiceOption {
+	return func(s *eventService) {
+		s.consistency = consistency
+	}
+}
+
+// WithPIITransforms pseudonymizes user_id and scrubs metadata of built
+// events. User lookups accept raw IDs and map them to the stored form.
+func WithPIITransforms(transformer *pii.Transformer) EventServiceOption {
+	return func(s *eventService) {
+		s.pii = transformer
+	}
+}
+
+// WithEnrichers adds server-derived fields to built events, after schema
+// checks and PII transforms.
+func WithEnrichers(chain enrich.Chain) EventServiceOption {
+	return func(s *eventService) {
+		s.enrichers = chain
+	}
+}
+
+// WithLateEventPolicy rejects or reroutes events timestamped further in the
+// past than policy.PastTolerance.
+func WithLateEventPolicy(policy LateEventPolicy) EventServiceOption {
+	return func(s *eventService) {
+		s.late = policy
+	}
+}
+
+// WithRules filters and rewrites events with a rules engine before they are
+// checked against their schema.
+func WithRules(engine *rules.Engine) EventServiceOption {
+	return func(s *eventService) {
+		s.rules = engine
+	}
+}
+
+// consistencyNotes explain the cost of each consistency level in the response.
+var consistencyNotes = map[string]string{
+	model.ConsistencyEventual: "duplicates are collapsed by background merges; recently ingested duplicates may be counted",
+	model.ConsistencyDedup:    "duplicates are collapsed at query time; slower on wide windows",
 }
 
 type EventService interface {
 	BuildEvent(req model.EventRequest) (model.Event, error)
 	ProcessEvent(ctx context.Context, event model.Event)
 	GetMetrics(ctx context.Context, filter model.MetricsFilter) (model.MetricsResponse, error)
+	GetUserEvents(ctx context.Context, filter model.UserEventsFilter, cursor string) (model.UserEventsResponse, error)
+	GetUserSummary(ctx context.Context, tenantID, userID string, from, to time.Time) (model.UserSummary, error)
+	ValidateExport(filter model.MetricsFilter) (model.MetricsFilter, error)
+	ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error
 }
 
 // NewEventService constructs an eventService.
-func NewEventService(repo repository.EventRepository, worker BatchEventWorker, futureTolerance time.Duration) EventService {
-	return &eventService{
+func NewEventService(repo repository.EventRepository, worker BatchEventWorker, futureTolerance time.Duration, limits MetricsLimits, opts ...EventServiceOption) EventService {
+	s := &eventService{
 		repo:            repo,
 		worker:          worker,
 		now:             time.Now,
 		futureTolerance: futureTolerance,
+		limits:          limits,
+		consistency:     model.ConsistencyEventual,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // BuildEvent validates and constructs an Event from an incoming request.
@@ -56,16 +206,23 @@ func (s *eventService) BuildEvent(req model.EventRequest) (model.Event, error) {
 		return model.Event{}, &ValidationError{Message: "user_id is required"}
 	}
 
-	if req.Timestamp == 0 {
+	if req.Timestamp == "" {
 		return model.Event{}, &ValidationError{Message: "timestamp is required"}
 	}
 
-	ts := time.Unix(req.Timestamp, 0).UTC()
+	ts, err := model.ParseTimestamp(string(req.Timestamp), req.TimestampUnit)
+	if err != nil {
+		return model.Event{}, &ValidationError{Message: err.Error()}
+	}
+	receivedAt := s.now()
 	if s.futureTolerance > 0 {
-		if err := ValidateTimestamp(ts, s.now(), s.futureTolerance); err != nil {
+		if err := ValidateTimestamp(ts, receivedAt, s.futureTolerance); err != nil {
 			return model.Event{}, &ValidationError{Message: err.Error()}
 		}
 	}
+	if s.late.Policy == LatePolicyReject && s.late.isLate(ts, receivedAt) {
+		return model.Event{}, &ValidationError{Message: fmt.Sprintf("timestamp is more than %s in the past", s.late.PastTolerance)}
+	}
 
 	tags := req.Tags
 	if tags == nil {
@@ -78,6 +235,7 @@ func (s *eventService) BuildEvent(req model.EventRequest) (model.Event, error) {
 	}
 
 	event := model.Event{
+		TenantID:   model.TenantOrDefault(req.TenantID),
 		EventName:  req.EventName,
 		Channel:    req.Channel,
 		CampaignID: campaignID,
@@ -85,13 +243,71 @@ func (s *eventService) BuildEvent(req model.EventRequest) (model.Event, error) {
 		Timestamp:  ts,
 		Tags:       tags,
 		Metadata:   req.Metadata,
+		ReceivedAt: receivedAt.UTC(),
+	}
+
+	// Rules run first so a renamed event is checked against the schema of
+	// its new name.
+	event, keep := s.rules.Apply(event)
+	if !keep {
+		return model.Event{}, ErrEventDropped
+	}
+
+	if err := s.checkSchema(event); err != nil {
+		return model.Event{}, err
 	}
 
+	// Schemas describe what clients send, so transforms run after checking.
+	if s.pii != nil {
+		event = s.pii.Apply(event)
+	}
+
+	event = s.enrichers.Apply(event, enrich.Source{
+		UserAgent:  req.UserAgent,
+		ClientIP:   req.ClientIP,
+		ReceivedAt: receivedAt,
+	})
+
 	return event, nil
 }
 
-// ProcessEvent persists a single event.
+// checkSchema validates an event against the registry. In warn mode
+// violations are logged and counted but the event is accepted.
+func (s *eventService) checkSchema(event model.Event) error {
+	schemas := s.schemas
+	if registry, ok := s.tenantSchemas[event.TenantID]; ok {
+		schemas = registry
+	}
+	if schemas == nil || schemas.Mode() == schema.ModeOff {
+		return nil
+	}
+
+	violations := schemas.Validate(event)
+	if len(violations) == 0 {
+		return nil
+	}
+	schemas.RecordViolations(event.EventName, len(violations))
+
+	details := make([]FieldError, len(violations))
+	for i, v := range violations {
+		details[i] = FieldError{Field: v.Field, Message: v.Message}
+	}
+
+	if schemas.Mode() == schema.ModeWarn {
+		log.Printf("[WARN] event %s violates schema: %v", event.EventName, details)
+		return nil
+	}
+
+	return &ValidationError{Message: "event does not match schema", Details: details}
+}
+
+// ProcessEvent persists a built event. Late events go to the late events
+// worker when the policy routes them.
 func (s *eventService) ProcessEvent(ctx context.Context, event model.Event) {
+	if s.late.Policy == LatePolicyRoute && s.late.isLate(event.Timestamp, event.ReceivedAt) {
+		s.late.Worker.Enqueue(event)
+		return
+	}
 	s.worker.Enqueue(event)
 }
 
@@ -109,24 +325,22 @@ func (s *eventService) GetMetrics(ctx context.Context, filter model.MetricsFilte
 		return model.MetricsResponse{}, &ValidationError{Message: "unsupported group_by"}
 	}
 
-	now := s.now().UTC()
-	if filter.To.IsZero() {
-		filter.To = now
-	} else {
-		filter.To = filter.To.UTC()
+	filter, err := s.normalizeFilter(filter)
+	if err != nil {
+		return model.MetricsResponse{}, err
 	}
+	audit.RecordFilter(ctx, filter)
 
-	if filter.From.IsZero() {
-		filter.From = filter.To.Add(-30 * 24 * time.Hour)
-	} else {
-		filter.From = filter.From.UTC()
+	if maxWindow := s.limits.MaxWindow[groupByKind(filter.GroupBy)]; maxWindow > 0 && filter.To.Sub(filter.From) > maxWindow {
+		return model.MetricsResponse{}, &ValidationError{
+			Message: fmt.Sprintf("time window exceeds maximum of %s for group_by=%s", maxWindow, filter.GroupBy),
+		}
 	}
 
-	if filter.From.After(filter.To) {
-		return model.MetricsResponse{}, &ValidationError{Message: "from must be before to"}
-	}
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
 
-	total, unique, groups, err := s.repo.FetchMetrics(ctx, filter)
+	result, err := s.repo.FetchMetrics(ctx, filter)
 	if err != nil {
 		return model.MetricsResponse{}, err
 	}
@@ -135,25 +349,79 @@ func (s *eventService) GetMetrics(ctx context.Context, filter model.MetricsFilte
 		Meta: model.MetricsMeta{
 			EventName: filter.EventName,
 			Period: model.MetricsPeriod{
-				Start: filter.From.UTC().Format(time.RFC3339),
-				End:   filter.To.UTC().Format(time.RFC3339),
+				Start: filter.From.UTC().Format(time.RFC3339Nano),
+				End:   filter.To.UTC().Format(time.RFC3339Nano),
 			},
-			GroupBy: filter.GroupBy,
+			GroupBy:         filter.GroupBy,
+			Consistency:     filter.Consistency,
+			ConsistencyNote: consistencyNotes[filter.Consistency],
 		},
 		Data: model.MetricsData{
-			TotalEventCount:  uint64(total),
-			UniqueEventCount: uint64(unique),
-			Groups:           groups,
+			TotalEventCount:  result.TotalCount,
+			UniqueEventCount: result.UniqueCount,
+			Groups:           result.Groups,
 		},
 	}
 
+	filters := map[string]any{}
 	if filter.Channel != nil && *filter.Channel != "" {
-		resp.Meta.Filters = map[string]any{"channel": *filter.Channel}
+		filters["channel"] = *filter.Channel
+	}
+	for key, value := range filter.Metadata {
+		filters[model.MetadataGroupPrefix+key] = value
+	}
+	if len(filters) > 0 {
+		resp.Meta.Filters = filters
 	}
 
 	return resp, nil
 }
 
+// normalizeFilter applies the checks and defaults shared by metrics queries
+// and exports: consistency, metadata filter keys and the time window.
+func (s *eventService) normalizeFilter(filter model.MetricsFilter) (model.MetricsFilter, error) {
+	if filter.Consistency == "" {
+		filter.Consistency = s.consistency
+	}
+
+	if _, ok := consistencyNotes[filter.Consistency]; !ok {
+		return model.MetricsFilter{}, &ValidationError{Message: "consistency must be eventual or dedup"}
+	}
+
+	for key := range filter.Metadata {
+		if !model.MetadataKeyPattern.MatchString(key) {
+			return model.MetricsFilter{}, &ValidationError{Message: fmt.Sprintf("invalid metadata filter key: %s", key)}
+		}
+	}
+
+	var err error
+	if filter.From, filter.To, err = s.resolveWindow(filter.From, filter.To); err != nil {
+		return model.MetricsFilter{}, err
+	}
+	return filter, nil
+}
+
+// resolveWindow fills in a missing to with now and a missing from with 30
+// days before to, and rejects inverted windows.
+func (s *eventService) resolveWindow(from, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = s.now().UTC()
+	} else {
+		to = to.UTC()
+	}
+
+	if from.IsZero() {
+		from = to.Add(-30 * 24 * time.Hour)
+	} else {
+		from = from.UTC()
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, &ValidationError{Message: "from must be before to"}
+	}
+	return from, to, nil
+}
+
 // ValidateTimestamp ensures timestamps are not too far in the future.
 func ValidateTimestamp(ts time.Time, now time.Time, tolerance time.Duration) error {
 	if tolerance <= 0 {
@@ -170,6 +438,16 @@ func isSupportedGroupBy(group string) bool {
 	case "channel", "hour", "day":
 		return true
 	default:
-		return false
+		key, ok := strings.CutPrefix(group, model.MetadataGroupPrefix)
+		return ok && model.MetadataKeyPattern.MatchString(key)
+	}
+}
+
+// groupByKind collapses metadata.<key> groupings into "metadata" so limits
+// can be configured per kind of grouping.
+func groupByKind(group string) string {
+	if strings.HasPrefix(group, model.MetadataGroupPrefix) {
+		return "metadata"
 	}
+	return group
 }
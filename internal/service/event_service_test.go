@@ -2,10 +2,15 @@ package service
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 
+	"event-metrics-service/internal/enrich"
 	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/pii"
+	"event-metrics-service/internal/rules"
+	"event-metrics-service/internal/schema"
 
 	// Adjust these paths based on your actual project structure
 	mockrepository "event-metrics-service/internal/testdata/mockrepository"
@@ -35,7 +40,7 @@ func (s *EventServiceTestSuite) SetupTest() {
 	s.worker = &mockworker.Worker{}
 
 	// Initialize the service and cast it to the concrete struct
-	svc := NewEventService(s.repo, s.worker, 0)
+	svc := NewEventService(s.repo, s.worker, 0, MetricsLimits{})
 	s.service = svc.(*eventService)
 
 	// Freeze time to a deterministic value for all tests
@@ -52,17 +57,17 @@ func (s *EventServiceTestSuite) TestBuildEvent_ValidationErrors() {
 	}{
 		{
 			name:   "Missing EventName",
-			req:    model.EventRequest{Channel: "web", UserID: "u1", Timestamp: 1000},
+			req:    model.EventRequest{Channel: "web", UserID: "u1", Timestamp: "1000"},
 			errMsg: "event_name is required",
 		},
 		{
 			name:   "Missing Channel",
-			req:    model.EventRequest{EventName: "login", UserID: "u1", Timestamp: 1000},
+			req:    model.EventRequest{EventName: "login", UserID: "u1", Timestamp: "1000"},
 			errMsg: "channel is required",
 		},
 		{
 			name:   "Missing UserID",
-			req:    model.EventRequest{EventName: "login", Channel: "web", Timestamp: 1000},
+			req:    model.EventRequest{EventName: "login", Channel: "web", Timestamp: "1000"},
 			errMsg: "user_id is required",
 		},
 		{
@@ -74,7 +79,7 @@ func (s *EventServiceTestSuite) TestBuildEvent_ValidationErrors() {
 			name: "Future Timestamp Error",
 			req: model.EventRequest{
 				EventName: "login", Channel: "web", UserID: "u1",
-				Timestamp: 1005, // 5 seconds in the future relative to frozen time (1000)
+				Timestamp: "1005", // 5 seconds in the future relative to frozen time (1000)
 			},
 			errMsg:    "timestamp cannot be in the future",
 			tolerance: 2 * time.Second, // Only allow 2 seconds of tolerance
@@ -105,7 +110,7 @@ func (s *EventServiceTestSuite) TestBuildEvent_SuccessLogic() {
 		EventName:  "purchase",
 		Channel:    "mobile",
 		UserID:     "user_123",
-		Timestamp:  1000, // Matches the frozen 'now' time
+		Timestamp:  "1000", // Matches the frozen 'now' time
 		CampaignID: &campaignID,
 		Tags:       nil, // Passing nil to ensure it converts to empty slice
 		Metadata:   map[string]any{"price": 100},
@@ -119,6 +124,67 @@ func (s *EventServiceTestSuite) TestBuildEvent_SuccessLogic() {
 	s.NotNil(event.Tags, "Tags should not be nil")
 	s.Empty(event.Tags, "Tags should be an empty slice, not nil")
 	s.Equal(time.Unix(1000, 0).UTC(), event.Timestamp)
+	s.Equal(time.Unix(1000, 0).UTC(), event.ReceivedAt)
+}
+
+func (s *EventServiceTestSuite) TestBuildEvent_TimestampFormats() {
+	want := time.Unix(999, 250*int64(time.Millisecond)).UTC()
+	for _, tt := range []struct {
+		name      string
+		timestamp model.RawTimestamp
+		unit      string
+		want      time.Time
+		wantErr   string
+	}{
+		{"seconds", "999", "", time.Unix(999, 0).UTC(), ""},
+		{"milliseconds with unit", "999250", model.UnitMilliseconds, want, ""},
+		{"fractional seconds", "999.25", "", want, ""},
+		{"rfc3339", "1970-01-01T00:16:39.250Z", "", want, ""},
+		{"ambiguous", "999250000000", "", time.Time{}, "is ambiguous"},
+		{"invalid unit", "999", "ns", time.Time{}, "timestamp_unit must be"},
+	} {
+		s.Run(tt.name, func() {
+			event, err := s.service.BuildEvent(model.EventRequest{
+				EventName: "click", Channel: "web", UserID: "u1", Timestamp: tt.timestamp, TimestampUnit: tt.unit,
+			})
+			if tt.wantErr != "" {
+				s.IsType(&ValidationError{}, err)
+				s.ErrorContains(err, tt.wantErr)
+				return
+			}
+			s.Require().NoError(err)
+			s.Equal(tt.want, event.Timestamp)
+		})
+	}
+}
+
+func (s *EventServiceTestSuite) TestBuildEvent_LatePolicy() {
+	req := model.EventRequest{EventName: "click", Channel: "web", UserID: "u1", Timestamp: "400"}
+
+	for _, tt := range []struct {
+		name    string
+		policy  LateEventPolicy
+		wantErr bool
+	}{
+		{"reject late", LateEventPolicy{PastTolerance: 5 * time.Minute, Policy: LatePolicyReject}, true},
+		{"reject within tolerance", LateEventPolicy{PastTolerance: time.Hour, Policy: LatePolicyReject}, false},
+		{"accept", LateEventPolicy{PastTolerance: 5 * time.Minute, Policy: LatePolicyAccept}, false},
+		{"route is decided when processing", LateEventPolicy{PastTolerance: 5 * time.Minute, Policy: LatePolicyRoute}, false},
+		{"disabled", LateEventPolicy{Policy: LatePolicyReject}, false},
+	} {
+		s.Run(tt.name, func() {
+			WithLateEventPolicy(tt.policy)(s.service)
+
+			_, err := s.service.BuildEvent(req)
+			if !tt.wantErr {
+				s.NoError(err)
+				return
+			}
+			var validationErr *ValidationError
+			s.Require().ErrorAs(err, &validationErr)
+			s.Equal("timestamp is more than 5m0s in the past", validationErr.Message)
+		})
+	}
 }
 
 // TestBuildEvent_FutureToleranceDisabled verifies that future dates are accepted
@@ -129,13 +195,109 @@ func (s *EventServiceTestSuite) TestBuildEvent_FutureToleranceDisabled() {
 	// Create a request with a timestamp 1 hour in the future
 	req := model.EventRequest{
 		EventName: "future_event", Channel: "web", UserID: "u1",
-		Timestamp: s.service.now().Add(1 * time.Hour).Unix(),
+		Timestamp: model.RawTimestamp(strconv.FormatInt(s.service.now().Add(1*time.Hour).Unix(), 10)),
 	}
 
 	_, err := s.service.BuildEvent(req)
 	s.NoError(err, "Future timestamps should be allowed when tolerance is 0")
 }
 
+// TestBuildEvent_SchemaEnforce verifies that schema violations are returned
+// as field-level validation details.
+func (s *EventServiceTestSuite) TestBuildEvent_SchemaEnforce() {
+	registry := schema.NewRegistry(schema.ModeEnforce, "")
+	s.Require().NoError(registry.Put(schema.EventSchema{
+		EventName: "purchase",
+		Channels:  []string{"web"},
+		Metadata:  map[string]schema.MetadataField{"price": {Type: schema.TypeNumber, Required: true}},
+	}))
+	s.service.schemas = registry
+
+	_, err := s.service.BuildEvent(model.EventRequest{
+		EventName: "purchase", Channel: "mobile", UserID: "u1", Timestamp: "1000",
+		Metadata: map[string]any{"price": "free"},
+	})
+
+	var validationErr *ValidationError
+	s.Require().ErrorAs(err, &validationErr)
+	s.Equal("event does not match schema", validationErr.Message)
+	s.Equal([]FieldError{
+		{Field: "channel", Message: `channel "mobile" is not allowed`},
+		{Field: "metadata.price", Message: "must be of type number"},
+	}, validationErr.Details)
+	s.Equal(map[string]uint64{"purchase": 2}, registry.Violations())
+}
+
+// TestBuildEvent_PIITransforms verifies that schemas see the raw request and
+// the built event carries the pseudonymized user_id and scrubbed metadata.
+func (s *EventServiceTestSuite) TestBuildEvent_PIITransforms() {
+	registry := schema.NewRegistry(schema.ModeEnforce, "")
+	s.Require().NoError(registry.Put(schema.EventSchema{
+		EventName:            "signup",
+		Metadata:             map[string]schema.MetadataField{"email": {Type: schema.TypeString, Required: true}},
+		AllowUnknownMetadata: true,
+	}))
+	s.service.schemas = registry
+
+	transforms, err := pii.New(pii.Config{
+		Version:  1,
+		UserID:   pii.UserIDConfig{Pseudonymize: true, KeyID: "k1"},
+		Metadata: pii.MetadataConfig{DropKeys: []string{"^email$"}},
+	}, map[string][]byte{"k1": []byte("0123456789abcdef")})
+	s.Require().NoError(err)
+	s.service.pii = transforms
+
+	event, err := s.service.BuildEvent(model.EventRequest{
+		EventName: "signup", Channel: "web", UserID: "u1", Timestamp: "1000",
+		Metadata: map[string]any{"email": "a@example.com", "plan": "pro"},
+	})
+
+	s.NoError(err)
+	s.Equal(transforms.UserID("u1"), event.UserID)
+	s.NotEqual("u1", event.UserID)
+	s.Equal(map[string]any{"plan": "pro"}, event.Metadata)
+}
+
+// TestBuildEvent_Enrichers verifies that enrichers see the request source
+// and run after PII transforms, so their fields are never scrubbed.
+func (s *EventServiceTestSuite) TestBuildEvent_Enrichers() {
+	transforms, err := pii.New(pii.Config{
+		Version:  1,
+		Metadata: pii.MetadataConfig{DropKeys: []string{"^device$"}},
+	}, nil)
+	s.Require().NoError(err)
+	s.service.pii = transforms
+	s.service.enrichers = enrich.Chain{enrich.ReceiveTime{}, enrich.UserAgent{}}
+
+	event, err := s.service.BuildEvent(model.EventRequest{
+		EventName: "signup", Channel: "web", UserID: "u1", Timestamp: "998",
+		UserAgent: "curl/8.5.0",
+		ClientIP:  "203.0.113.7",
+	})
+
+	s.NoError(err)
+	s.Equal(map[string]any{
+		enrich.KeyReceivedAt:  "1970-01-01T00:16:40.000Z",
+		enrich.KeyClockSkewMs: int64(2000),
+		enrich.KeyDevice:      enrich.DeviceBot,
+	}, event.Metadata)
+}
+
+// TestBuildEvent_SchemaWarn verifies that warn mode counts violations but
+// accepts the event.
+func (s *EventServiceTestSuite) TestBuildEvent_SchemaWarn() {
+	registry := schema.NewRegistry(schema.ModeWarn, "")
+	s.service.schemas = registry
+
+	event, err := s.service.BuildEvent(model.EventRequest{
+		EventName: "unknown", Channel: "web", UserID: "u1", Timestamp: "1000",
+	})
+
+	s.NoError(err)
+	s.Equal("unknown", event.EventName)
+	s.Equal(map[string]uint64{schema.UnregisteredEvents: 1}, registry.Violations())
+}
+
 // TestProcessEvent verifies that valid events are properly enqueued to the worker.
 func (s *EventServiceTestSuite) TestProcessEvent() {
 	ctx := context.Background()
@@ -150,6 +312,73 @@ func (s *EventServiceTestSuite) TestProcessEvent() {
 	s.worker.AssertExpectations(s.T())
 }
 
+func (s *EventServiceTestSuite) TestProcessEvent_RoutesLateEvents() {
+	late := &mockworker.Worker{}
+	WithLateEventPolicy(LateEventPolicy{PastTolerance: time.Hour, Policy: LatePolicyRoute, Worker: late})(s.service)
+
+	receivedAt := time.Unix(100_000, 0).UTC()
+	onTime := model.Event{EventName: "click", Timestamp: receivedAt.Add(-time.Minute), ReceivedAt: receivedAt}
+	old := model.Event{EventName: "click", Timestamp: receivedAt.Add(-2 * time.Hour), ReceivedAt: receivedAt}
+	s.worker.On("Enqueue", onTime).Return().Once()
+	late.On("Enqueue", old).Return().Once()
+
+	s.service.ProcessEvent(context.Background(), onTime)
+	s.service.ProcessEvent(context.Background(), old)
+
+	s.worker.AssertExpectations(s.T())
+	late.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestBuildEvent_Rules() {
+	engine, err := rules.New(rules.Config{Version: 1, Rules: []rules.Rule{
+		{Name: "drop-test", Match: rules.Match{Channels: []string{"test"}}, Actions: []rules.Action{{Type: rules.ActionDrop}}},
+		{Name: "rename-tap", Match: rules.Match{EventNames: []string{"tap"}}, Actions: []rules.Action{{Type: rules.ActionRename, EventName: "click"}}},
+	}})
+	s.Require().NoError(err)
+	WithRules(engine)(s.service)
+
+	event, err := s.service.BuildEvent(model.EventRequest{EventName: "tap", Channel: "web", UserID: "u1", Timestamp: "1000"})
+	s.NoError(err)
+	s.Equal("click", event.EventName)
+
+	_, err = s.service.BuildEvent(model.EventRequest{EventName: "tap", Channel: "test", UserID: "u1", Timestamp: "1000"})
+	s.ErrorIs(err, ErrEventDropped)
+}
+
+// TestBuildEvent_RulesBeforeSchemaEnforce verifies that a renamed event is
+// checked against the schema of its new name, not the one it arrived with.
+func (s *EventServiceTestSuite) TestBuildEvent_RulesBeforeSchemaEnforce() {
+	registry := schema.NewRegistry(schema.ModeEnforce, "")
+	s.Require().NoError(registry.Put(schema.EventSchema{
+		EventName: "click",
+		Metadata:  map[string]schema.MetadataField{"target": {Type: schema.TypeString, Required: true}},
+	}))
+	s.service.schemas = registry
+
+	engine, err := rules.New(rules.Config{Version: 1, Rules: []rules.Rule{
+		{Name: "rename-tap", Match: rules.Match{EventNames: []string{"tap"}}, Actions: []rules.Action{{Type: rules.ActionRename, EventName: "click"}}},
+	}})
+	s.Require().NoError(err)
+	WithRules(engine)(s.service)
+
+	// "tap" has no schema, so enforce mode would reject it before the rename.
+	event, err := s.service.BuildEvent(model.EventRequest{
+		EventName: "tap", Channel: "web", UserID: "u1", Timestamp: "1000",
+		Metadata: map[string]any{"target": "buy"},
+	})
+	s.NoError(err)
+	s.Equal("click", event.EventName)
+
+	// The renamed event must still satisfy the click schema.
+	_, err = s.service.BuildEvent(model.EventRequest{
+		EventName: "tap", Channel: "web", UserID: "u1", Timestamp: "1000",
+	})
+	var validationErr *ValidationError
+	s.Require().ErrorAs(err, &validationErr)
+	s.Equal([]FieldError{{Field: "metadata.target", Message: "is required"}}, validationErr.Details)
+	s.Equal(map[string]uint64{"click": 1}, registry.Violations())
+}
+
 func (s *EventServiceTestSuite) TestGetMetrics_Validation() {
 	_, err := s.service.GetMetrics(context.Background(), model.MetricsFilter{})
 	s.Error(err)
@@ -165,14 +394,16 @@ func (s *EventServiceTestSuite) TestGetMetrics_Success() {
 		EventName: "signup",
 	}
 	expectedFilter := model.MetricsFilter{
-		EventName: "signup",
-		GroupBy:   "channel",
-		To:        now,
-		From:      now.Add(-30 * 24 * time.Hour),
+		EventName:   "signup",
+		GroupBy:     "channel",
+		To:          now,
+		From:        now.Add(-30 * 24 * time.Hour),
+		Consistency: model.ConsistencyEventual,
 	}
 
 	groups := []model.MetricsGroup{{Key: "web", TotalCount: 8, UniqueUserCount: 2}}
-	s.repo.On("FetchMetrics", mock.Anything, expectedFilter).Return(uint64(10), uint64(3), groups, nil)
+	result := model.MetricsResult{TotalCount: 10, UniqueCount: 3, Groups: groups}
+	s.repo.On("FetchMetrics", mock.Anything, expectedFilter).Return(result, nil)
 
 	resp, err := s.service.GetMetrics(ctx, filter)
 
@@ -199,6 +430,94 @@ func (s *EventServiceTestSuite) TestGetMetrics_FromAfterTo() {
 	s.IsType(&ValidationError{}, err)
 }
 
+func (s *EventServiceTestSuite) TestGetMetrics_WindowExceedsLimit() {
+	s.service.limits.MaxWindow = map[string]time.Duration{"hour": 24 * time.Hour}
+
+	from := time.Unix(0, 0).UTC()
+	to := from.Add(48 * time.Hour)
+	_, err := s.service.GetMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "hour", From: from, To: to})
+
+	s.IsType(&ValidationError{}, err)
+	s.EqualError(err, "time window exceeds maximum of 24h0m0s for group_by=hour")
+	s.repo.AssertNotCalled(s.T(), "FetchMetrics", mock.Anything, mock.Anything)
+}
+
+func (s *EventServiceTestSuite) TestGetMetrics_MetadataWindowLimit() {
+	s.service.limits.MaxWindow = map[string]time.Duration{"channel": 48 * time.Hour, "metadata": 24 * time.Hour}
+
+	from := time.Unix(0, 0).UTC()
+	to := from.Add(36 * time.Hour)
+	_, err := s.service.GetMetrics(context.Background(), model.MetricsFilter{EventName: "signup", GroupBy: "metadata.plan", From: from, To: to})
+
+	s.IsType(&ValidationError{}, err)
+	s.EqualError(err, "time window exceeds maximum of 24h0m0s for group_by=metadata.plan")
+	s.repo.AssertNotCalled(s.T(), "FetchMetrics", mock.Anything, mock.Anything)
+}
+
+func (s *EventServiceTestSuite) TestGetMetrics_AppliesTimeout() {
+	s.service.limits.Timeout = time.Minute
+
+	from := time.Unix(0, 0).UTC()
+	to := from.Add(time.Hour)
+	deadlineSet := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	s.repo.On("FetchMetrics", deadlineSet, mock.Anything).Return(model.MetricsResult{}, ErrQueryTimeout).Once()
+
+	_, err := s.service.GetMetrics(context.Background(), model.MetricsFilter{EventName: "signup", From: from, To: to})
+
+	s.ErrorIs(err, ErrQueryTimeout)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestGetMetrics_MetadataGroupAndFilters() {
+	from := time.Unix(0, 0).UTC()
+	to := from.Add(time.Hour)
+	filter := model.MetricsFilter{
+		EventName:   "purchase",
+		GroupBy:     "metadata.currency",
+		From:        from,
+		To:          to,
+		Metadata:    map[string]string{"referrer": "google"},
+		Consistency: model.ConsistencyEventual,
+	}
+	s.repo.On("FetchMetrics", mock.Anything, filter).Return(model.MetricsResult{}, nil).Once()
+
+	resp, err := s.service.GetMetrics(context.Background(), filter)
+
+	s.NoError(err)
+	s.Equal(map[string]any{"metadata.referrer": "google"}, resp.Meta.Filters)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestGetMetrics_Consistency() {
+	s.service.consistency = model.ConsistencyDedup
+	dedup := mock.MatchedBy(func(f model.MetricsFilter) bool { return f.Consistency == model.ConsistencyDedup })
+	s.repo.On("FetchMetrics", mock.Anything, dedup).Return(model.MetricsResult{}, nil).Once()
+
+	resp, err := s.service.GetMetrics(context.Background(), model.MetricsFilter{EventName: "signup"})
+
+	s.NoError(err)
+	s.Equal(model.ConsistencyDedup, resp.Meta.Consistency)
+	s.NotEmpty(resp.Meta.ConsistencyNote)
+
+	_, err = s.service.GetMetrics(context.Background(), model.MetricsFilter{EventName: "signup", Consistency: "strong"})
+	s.IsType(&ValidationError{}, err)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestGetMetrics_InvalidMetadataKey() {
+	_, err := s.service.GetMetrics(context.Background(), model.MetricsFilter{EventName: "purchase", GroupBy: "metadata.Bad-Key"})
+	s.IsType(&ValidationError{}, err)
+
+	_, err = s.service.GetMetrics(context.Background(), model.MetricsFilter{
+		EventName: "purchase",
+		Metadata:  map[string]string{"bad key": "x"},
+	})
+	s.IsType(&ValidationError{}, err)
+}
+
 // TestValidateTimestamp_Helper tests the standalone helper function logic.
 func (s *EventServiceTestSuite) TestValidateTimestamp_Helper() {
 	now := time.Unix(1000, 0)
@@ -10,7 +10,7 @@ import (
 )
 
 type batchEventWorker struct {
-	repo          repository.EventRepository // use repository for persistence
+	repo          repository.BatchWriter // use repository for persistence
 	eventQueue    chan model.Event
 	batchSize     int
 	flushInterval time.Duration
@@ -23,7 +23,7 @@ type BatchEventWorker interface {
 }
 
 // Constructor: inject repository instead of raw sql.DB
-func NewbatchEventWorker(repo repository.EventRepository, bufferSize int, batchSize int, interval time.Duration) *batchEventWorker {
+func NewbatchEventWorker(repo repository.BatchWriter, bufferSize int, batchSize int, interval time.Duration) *batchEventWorker {
 	worker := &batchEventWorker{
 		repo:          repo, // dependency injection
 		eventQueue:    make(chan model.Event, bufferSize),
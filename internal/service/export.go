@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+// ExportLimits bounds raw exports. They are separate from MetricsLimits
+// because an export is expected to read far more rows than an aggregation.
+type ExportLimits struct {
+	// Timeout caps how long a single export may stream. Zero means no limit.
+	Timeout time.Duration
+
+	// MaxWindow is the largest allowed to-from span. Zero means no limit.
+	MaxWindow time.Duration
+}
+
+// WithExportLimits sets the limits applied to raw exports.
+func WithExportLimits(limits ExportLimits) EventServiceOption {
+	return func(s *eventService) {
+		s.exportLimits = limits
+	}
+}
+
+// ValidateExport checks an export filter and fills in defaults. Callers that
+// stream to a client should call it before committing to a response status.
+func (s *eventService) ValidateExport(filter model.MetricsFilter) (model.MetricsFilter, error) {
+	if filter.EventName == "" {
+		return model.MetricsFilter{}, &ValidationError{Message: "event_name is required"}
+	}
+
+	filter, err := s.normalizeFilter(filter)
+	if err != nil {
+		return model.MetricsFilter{}, err
+	}
+
+	if maxWindow := s.exportLimits.MaxWindow; maxWindow > 0 && filter.To.Sub(filter.From) > maxWindow {
+		return model.MetricsFilter{}, &ValidationError{
+			Message: fmt.Sprintf("time window exceeds maximum of %s for exports", maxWindow),
+		}
+	}
+	return filter, nil
+}
+
+// ExportEvents streams the raw events matching filter to fn in timestamp
+// order. Returning an error from fn stops the export.
+func (s *eventService) ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error {
+	filter, err := s.ValidateExport(filter)
+	if err != nil {
+		return err
+	}
+
+	if s.exportLimits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.exportLimits.Timeout)
+		defer cancel()
+	}
+
+	return s.repo.ExportEvents(ctx, filter, fn)
+}
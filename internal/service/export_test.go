@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *EventServiceTestSuite) TestValidateExport_Defaults() {
+	now := time.Unix(2000, 0).UTC()
+	s.service.now = func() time.Time { return now }
+
+	filter, err := s.service.ValidateExport(model.MetricsFilter{EventName: "purchase"})
+
+	s.NoError(err)
+	s.Equal(model.MetricsFilter{
+		EventName:   "purchase",
+		From:        now.Add(-30 * 24 * time.Hour),
+		To:          now,
+		Consistency: model.ConsistencyEventual,
+	}, filter)
+}
+
+func (s *EventServiceTestSuite) TestValidateExport_Errors() {
+	s.service.exportLimits.MaxWindow = time.Hour
+	from := time.Unix(0, 0).UTC()
+
+	tests := []struct {
+		name   string
+		filter model.MetricsFilter
+		errMsg string
+	}{
+		{name: "missing event", filter: model.MetricsFilter{}, errMsg: "event_name is required"},
+		{name: "bad consistency", filter: model.MetricsFilter{EventName: "x", Consistency: "strong"}, errMsg: "consistency must be eventual or dedup"},
+		{name: "bad metadata key", filter: model.MetricsFilter{EventName: "x", Metadata: map[string]string{"a b": "1"}}, errMsg: "invalid metadata filter key: a b"},
+		{name: "window too wide", filter: model.MetricsFilter{EventName: "x", From: from, To: from.Add(2 * time.Hour)}, errMsg: "time window exceeds maximum of 1h0m0s for exports"},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, err := s.service.ValidateExport(tt.filter)
+			s.IsType(&ValidationError{}, err)
+			s.EqualError(err, tt.errMsg)
+		})
+	}
+}
+
+func (s *EventServiceTestSuite) TestExportEvents_StreamsWithTimeout() {
+	s.service.exportLimits.Timeout = time.Minute
+	from := time.Unix(0, 0).UTC()
+	to := from.Add(time.Hour)
+
+	deadlineSet := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	expected := model.MetricsFilter{EventName: "purchase", From: from, To: to, Consistency: model.ConsistencyEventual}
+	s.repo.On("ExportEvents", deadlineSet, expected, mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(2).(func(model.Event) error)
+		_ = fn(model.Event{EventName: "purchase", UserID: "u1"})
+		_ = fn(model.Event{EventName: "purchase", UserID: "u2"})
+	}).Return(nil).Once()
+
+	var users []string
+	err := s.service.ExportEvents(context.Background(), model.MetricsFilter{EventName: "purchase", From: from, To: to}, func(e model.Event) error {
+		users = append(users, e.UserID)
+		return nil
+	})
+
+	s.NoError(err)
+	s.Equal([]string{"u1", "u2"}, users)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestExportEvents_ValidationSkipsRepository() {
+	err := s.service.ExportEvents(context.Background(), model.MetricsFilter{}, func(model.Event) error { return nil })
+
+	s.IsType(&ValidationError{}, err)
+	s.repo.AssertNotCalled(s.T(), "ExportEvents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *EventServiceTestSuite) TestExportEvents_RepositoryError() {
+	expectedErr := errors.New("boom")
+	s.repo.On("ExportEvents", mock.Anything, mock.Anything, mock.Anything).Return(expectedErr).Once()
+
+	err := s.service.ExportEvents(context.Background(), model.MetricsFilter{EventName: "purchase"}, func(model.Event) error { return nil })
+
+	s.ErrorIs(err, expectedErr)
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/pii"
+	"event-metrics-service/internal/repository"
+)
+
+// ErrPrivacyRequestNotFound is returned for unknown privacy request IDs.
+var ErrPrivacyRequestNotFound = errors.New("privacy request not found")
+
+// accessWindowEnd bounds access exports from above. It is the last day
+// ClickHouse DateTime64 can represent, so no stored event is left out.
+var accessWindowEnd = time.Date(2299, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// accessPageSize is how many events an access export reads per query.
+const accessPageSize = 1000
+
+// PrivacyService handles GDPR erasure and access requests. Every request and
+// each of its status changes is written to the privacy audit trail.
+type PrivacyService interface {
+	RequestErasure(ctx context.Context, input model.PrivacyRequestInput) (model.PrivacyRequest, error)
+	RequestAccess(ctx context.Context, input model.PrivacyRequestInput) (model.UserDataExport, error)
+	GetRequest(ctx context.Context, id string) (model.PrivacyRequest, error)
+	ListRequests(ctx context.Context) ([]model.PrivacyRequest, error)
+}
+
+type privacyService struct {
+	privacy repository.PrivacyRepository
+	events  repository.EventRepository
+	pii     *pii.Transformer
+	now     func() time.Time
+	newID   func() string
+}
+
+// PrivacyServiceOption configures optional privacy request behaviour.
+type PrivacyServiceOption func(*privacyService)
+
+// WithPseudonyms makes requests cover every stored form of the user ID: the
+// raw ID and its pseudonym under each configured HMAC key.
+func WithPseudonyms(transformer *pii.Transformer) PrivacyServiceOption {
+	return func(s *privacyService) {
+		s.pii = transformer
+	}
+}
+
+// NewPrivacyService constructs a privacyService.
+func NewPrivacyService(privacy repository.PrivacyRepository, events repository.EventRepository, opts ...PrivacyServiceOption) PrivacyService {
+	s := &privacyService{privacy: privacy, events: events, now: time.Now, newID: newRequestID}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RequestErasure records the request and starts deleting the user's events.
+// The returned request is completed when the backend deleted synchronously
+// and running while ClickHouse mutations are still in flight.
+func (s *privacyService) RequestErasure(ctx context.Context, input model.PrivacyRequestInput) (model.PrivacyRequest, error) {
+	entry, err := s.submit(ctx, model.PrivacyRequestErasure, input)
+	if err != nil {
+		return model.PrivacyRequest{}, err
+	}
+	entries := []model.PrivacyAuditEntry{entry}
+
+	for _, userID := range s.pii.UserIDs(input.UserID) {
+		if err := s.privacy.DeleteUserEvents(ctx, entry.TenantID, userID); err != nil {
+			failed := s.record(ctx, entry, model.PrivacyStatusFailed, err.Error())
+			return foldPrivacyRequest(append(entries, failed)), fmt.Errorf("erase user %s: %w", input.UserID, err)
+		}
+	}
+
+	status := model.PrivacyStatusRunning
+	if pending, err := s.pendingDeletes(ctx, entry.TenantID, input.UserID); err != nil {
+		log.Printf("[WARN] privacy request %s: %v", entry.RequestID, err)
+	} else if pending == 0 {
+		status = model.PrivacyStatusCompleted
+	}
+
+	return foldPrivacyRequest(append(entries, s.record(ctx, entry, status, ""))), nil
+}
+
+// RequestAccess records the request and returns every stored event of the
+// user.
+func (s *privacyService) RequestAccess(ctx context.Context, input model.PrivacyRequestInput) (model.UserDataExport, error) {
+	entry, err := s.submit(ctx, model.PrivacyRequestAccess, input)
+	if err != nil {
+		return model.UserDataExport{}, err
+	}
+	entries := []model.PrivacyAuditEntry{entry}
+
+	events, err := s.userEvents(ctx, entry.TenantID, input.UserID)
+	if err != nil {
+		s.record(ctx, entry, model.PrivacyStatusFailed, err.Error())
+		return model.UserDataExport{}, fmt.Errorf("export user %s: %w", input.UserID, err)
+	}
+
+	done := s.record(ctx, entry, model.PrivacyStatusCompleted, fmt.Sprintf("exported %d events", len(events)))
+	return model.UserDataExport{
+		Request: foldPrivacyRequest(append(entries, done)),
+		Events:  events,
+	}, nil
+}
+
+// GetRequest returns the current state of a request, first checking whether
+// a running erasure has finished.
+func (s *privacyService) GetRequest(ctx context.Context, id string) (model.PrivacyRequest, error) {
+	entries, err := s.privacy.ListAudit(ctx, id)
+	if err != nil {
+		return model.PrivacyRequest{}, err
+	}
+	if len(entries) == 0 {
+		return model.PrivacyRequest{}, ErrPrivacyRequestNotFound
+	}
+	return s.refresh(ctx, entries), nil
+}
+
+// ListRequests returns every privacy request, newest first.
+func (s *privacyService) ListRequests(ctx context.Context) ([]model.PrivacyRequest, error) {
+	entries, err := s.privacy.ListAudit(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string][]model.PrivacyAuditEntry{}
+	var order []string
+	for _, e := range entries {
+		if _, ok := byID[e.RequestID]; !ok {
+			order = append(order, e.RequestID)
+		}
+		byID[e.RequestID] = append(byID[e.RequestID], e)
+	}
+
+	requests := make([]model.PrivacyRequest, 0, len(order))
+	for _, id := range order {
+		requests = append(requests, s.refresh(ctx, byID[id]))
+	}
+	sortPrivacyRequests(requests)
+	return requests, nil
+}
+
+// refresh folds a request's entries and, for a running erasure, records
+// completion once no delete is pending anymore.
+func (s *privacyService) refresh(ctx context.Context, entries []model.PrivacyAuditEntry) model.PrivacyRequest {
+	req := foldPrivacyRequest(entries)
+	if req.Type != model.PrivacyRequestErasure || req.Status != model.PrivacyStatusRunning {
+		return req
+	}
+
+	pending, err := s.pendingDeletes(ctx, req.TenantID, req.UserID)
+	if err != nil {
+		log.Printf("[WARN] privacy request %s: %v", req.ID, err)
+		return req
+	}
+	if pending > 0 {
+		return req
+	}
+
+	done := s.record(ctx, entries[0], model.PrivacyStatusCompleted, "")
+	return foldPrivacyRequest(append(entries, done))
+}
+
+func (s *privacyService) pendingDeletes(ctx context.Context, tenantID, userID string) (int, error) {
+	total := 0
+	for _, id := range s.pii.UserIDs(userID) {
+		pending, err := s.privacy.PendingUserDeletes(ctx, tenantID, id)
+		if err != nil {
+			return 0, err
+		}
+		total += pending
+	}
+	return total, nil
+}
+
+func (s *privacyService) submit(ctx context.Context, requestType string, input model.PrivacyRequestInput) (model.PrivacyAuditEntry, error) {
+	if input.UserID == "" {
+		return model.PrivacyAuditEntry{}, &ValidationError{Message: "user_id is required"}
+	}
+	tenantID := model.TenantOrDefault(input.TenantID)
+	if !model.TenantIDPattern.MatchString(tenantID) {
+		return model.PrivacyAuditEntry{}, &ValidationError{Message: "invalid tenant_id"}
+	}
+
+	entry := model.PrivacyAuditEntry{
+		RequestID: s.newID(),
+		Type:      requestType,
+		TenantID:  tenantID,
+		UserID:    input.UserID,
+		Status:    model.PrivacyStatusSubmitted,
+		Actor:     input.RequestedBy,
+		Detail:    input.Reason,
+		At:        s.now().UTC(),
+	}
+	if err := s.privacy.AppendAudit(ctx, entry); err != nil {
+		// Nothing is erased or exported without an audit record.
+		return model.PrivacyAuditEntry{}, err
+	}
+	return entry, nil
+}
+
+// record appends a status change. The action it describes has already
+// happened, so a failed write is logged rather than undoing it.
+func (s *privacyService) record(ctx context.Context, submitted model.PrivacyAuditEntry, status, detail string) model.PrivacyAuditEntry {
+	entry := submitted
+	entry.Status = status
+	entry.Detail = detail
+	entry.At = s.now().UTC()
+	if err := s.privacy.AppendAudit(ctx, entry); err != nil {
+		log.Printf("[ERROR] privacy request %s: record %s: %v", entry.RequestID, status, err)
+	}
+	return entry
+}
+
+// userEvents collects the user's timeline followed by the events that were
+// stored apart as late.
+func (s *privacyService) userEvents(ctx context.Context, tenantID, userID string) ([]model.UserEvent, error) {
+	events := []model.UserEvent{}
+	for _, id := range s.pii.UserIDs(userID) {
+		var err error
+		if events, err = s.appendUserEvents(ctx, events, tenantID, id); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range s.pii.UserIDs(userID) {
+		late, err := s.privacy.FetchUserLateEvents(ctx, tenantID, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range late {
+			events = append(events, toUserEvent(e))
+		}
+	}
+	return events, nil
+}
+
+func (s *privacyService) appendUserEvents(ctx context.Context, events []model.UserEvent, tenantID, userID string) ([]model.UserEvent, error) {
+	filter := model.UserEventsFilter{
+		TenantID: tenantID,
+		UserID:   userID,
+		From:     time.Unix(0, 0).UTC(),
+		To:       accessWindowEnd,
+		Limit:    accessPageSize,
+	}
+
+	for {
+		page, err := s.events.FetchUserEvents(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range page {
+			events = append(events, toUserEvent(e))
+		}
+
+		if len(page) < filter.Limit {
+			return events, nil
+		}
+
+		last := page[len(page)-1]
+		filter.After = &model.UserEventsCursor{
+			Timestamp:  last.Timestamp,
+			EventName:  last.EventName,
+			Channel:    last.Channel,
+			CampaignID: last.CampaignID,
+		}
+	}
+}
+
+// privacyStatusRank orders statuses written within the same millisecond.
+var privacyStatusRank = map[string]int{
+	model.PrivacyStatusSubmitted: 0,
+	model.PrivacyStatusRunning:   1,
+	model.PrivacyStatusCompleted: 2,
+	model.PrivacyStatusFailed:    2,
+}
+
+// foldPrivacyRequest builds the current state of a request from its audit
+// entries: identity from the submission, status from the latest change.
+func foldPrivacyRequest(entries []model.PrivacyAuditEntry) model.PrivacyRequest {
+	first, latest := entries[0], entries[0]
+	for _, e := range entries[1:] {
+		if e.At.Before(first.At) {
+			first = e
+		}
+		if e.At.After(latest.At) || (e.At.Equal(latest.At) && privacyStatusRank[e.Status] > privacyStatusRank[latest.Status]) {
+			latest = e
+		}
+	}
+
+	return model.PrivacyRequest{
+		ID:          first.RequestID,
+		Type:        first.Type,
+		TenantID:    model.TenantOrDefault(first.TenantID),
+		UserID:      first.UserID,
+		Status:      latest.Status,
+		RequestedBy: first.Actor,
+		Detail:      latest.Detail,
+		CreatedAt:   first.At,
+		UpdatedAt:   latest.At,
+	}
+}
+
+func sortPrivacyRequests(requests []model.PrivacyRequest) {
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].CreatedAt.Equal(requests[j].CreatedAt) {
+			return requests[i].CreatedAt.After(requests[j].CreatedAt)
+		}
+		return requests[i].ID < requests[j].ID
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
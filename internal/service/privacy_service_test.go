@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/pii"
+	mockrepository "event-metrics-service/internal/testdata/mockrepository"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PrivacyServiceTestSuite struct {
+	suite.Suite
+
+	privacy *mockrepository.PrivacyRepository
+	events  *mockrepository.Repository
+	service *privacyService
+	now     time.Time
+}
+
+func TestPrivacyServiceSuite(t *testing.T) {
+	suite.Run(t, new(PrivacyServiceTestSuite))
+}
+
+func (s *PrivacyServiceTestSuite) SetupTest() {
+	s.privacy = &mockrepository.PrivacyRepository{}
+	s.events = &mockrepository.Repository{}
+	s.now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.service = NewPrivacyService(s.privacy, s.events).(*privacyService)
+	s.service.now = func() time.Time { return s.now }
+	s.service.newID = func() string { return "req-1" }
+}
+
+func (s *PrivacyServiceTestSuite) TearDownTest() {
+	s.privacy.AssertExpectations(s.T())
+	s.events.AssertExpectations(s.T())
+}
+
+func (s *PrivacyServiceTestSuite) auditEntry(requestType, status, detail string) model.PrivacyAuditEntry {
+	return model.PrivacyAuditEntry{
+		RequestID: "req-1",
+		Type:      requestType,
+		TenantID:  model.DefaultTenant,
+		UserID:    "u1",
+		Status:    status,
+		Actor:     "dpo",
+		Detail:    detail,
+		At:        s.now,
+	}
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_Running() {
+	input := model.PrivacyRequestInput{UserID: "u1", RequestedBy: "dpo", Reason: "ticket 42"}
+	s.privacy.On("AppendAudit", mock.Anything, s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusSubmitted, "ticket 42")).Return(nil).Once()
+	s.privacy.On("DeleteUserEvents", mock.Anything, model.DefaultTenant, "u1").Return(nil).Once()
+	s.privacy.On("PendingUserDeletes", mock.Anything, model.DefaultTenant, "u1").Return(1, nil).Once()
+	s.privacy.On("AppendAudit", mock.Anything, s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusRunning, "")).Return(nil).Once()
+
+	req, err := s.service.RequestErasure(context.Background(), input)
+
+	s.NoError(err)
+	s.Equal(model.PrivacyRequest{
+		ID:          "req-1",
+		Type:        model.PrivacyRequestErasure,
+		TenantID:    model.DefaultTenant,
+		UserID:      "u1",
+		Status:      model.PrivacyStatusRunning,
+		RequestedBy: "dpo",
+		CreatedAt:   s.now,
+		UpdatedAt:   s.now,
+	}, req)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_CompletedWhenNothingPending() {
+	s.privacy.On("AppendAudit", mock.Anything, mock.Anything).Return(nil).Twice()
+	s.privacy.On("DeleteUserEvents", mock.Anything, model.DefaultTenant, "u1").Return(nil).Once()
+	s.privacy.On("PendingUserDeletes", mock.Anything, model.DefaultTenant, "u1").Return(0, nil).Once()
+
+	req, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{UserID: "u1"})
+
+	s.NoError(err)
+	s.Equal(model.PrivacyStatusCompleted, req.Status)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_CoversPseudonyms() {
+	transforms, err := pii.New(pii.Config{
+		Version: 2,
+		UserID:  pii.UserIDConfig{Pseudonymize: true, KeyID: "k2"},
+	}, map[string][]byte{"k1": []byte("0123456789abcdef"), "k2": []byte("fedcba9876543210")})
+	s.Require().NoError(err)
+	s.service.pii = transforms
+
+	s.privacy.On("AppendAudit", mock.Anything, mock.Anything).Return(nil).Twice()
+	for _, id := range transforms.UserIDs("u1") {
+		s.privacy.On("DeleteUserEvents", mock.Anything, model.DefaultTenant, id).Return(nil).Once()
+		s.privacy.On("PendingUserDeletes", mock.Anything, model.DefaultTenant, id).Return(0, nil).Once()
+	}
+
+	req, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{UserID: "u1"})
+
+	s.NoError(err)
+	s.Equal("u1", req.UserID)
+	s.privacy.AssertNumberOfCalls(s.T(), "DeleteUserEvents", 3)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_DeleteFailureIsAudited() {
+	expectedErr := errors.New("exec error")
+	s.privacy.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e model.PrivacyAuditEntry) bool {
+		return e.Status == model.PrivacyStatusSubmitted
+	})).Return(nil).Once()
+	s.privacy.On("DeleteUserEvents", mock.Anything, model.DefaultTenant, "u1").Return(expectedErr).Once()
+	s.privacy.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e model.PrivacyAuditEntry) bool {
+		return e.Status == model.PrivacyStatusFailed && e.Detail == "exec error"
+	})).Return(nil).Once()
+
+	req, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{UserID: "u1"})
+
+	s.ErrorIs(err, expectedErr)
+	s.Equal("req-1", req.ID)
+	s.Equal(model.PrivacyStatusFailed, req.Status)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_AuditFailureStopsErasure() {
+	expectedErr := errors.New("insert error")
+	s.privacy.On("AppendAudit", mock.Anything, mock.Anything).Return(expectedErr).Once()
+
+	_, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{UserID: "u1"})
+
+	s.ErrorIs(err, expectedErr)
+	s.privacy.AssertNotCalled(s.T(), "DeleteUserEvents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_ScopedToTenant() {
+	submitted := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusSubmitted, "")
+	submitted.TenantID = "acme"
+	completed := submitted
+	completed.Status = model.PrivacyStatusCompleted
+	s.privacy.On("AppendAudit", mock.Anything, submitted).Return(nil).Once()
+	s.privacy.On("DeleteUserEvents", mock.Anything, "acme", "u1").Return(nil).Once()
+	s.privacy.On("PendingUserDeletes", mock.Anything, "acme", "u1").Return(0, nil).Once()
+	s.privacy.On("AppendAudit", mock.Anything, completed).Return(nil).Once()
+
+	req, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{TenantID: "acme", UserID: "u1", RequestedBy: "dpo"})
+
+	s.NoError(err)
+	s.Equal("acme", req.TenantID)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_InvalidTenant() {
+	_, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{TenantID: "../acme", UserID: "u1"})
+	s.IsType(&ValidationError{}, err)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestErasure_RequiresUserID() {
+	_, err := s.service.RequestErasure(context.Background(), model.PrivacyRequestInput{})
+
+	s.IsType(&ValidationError{}, err)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestAccess_PagesThroughEvents() {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := make([]model.Event, accessPageSize)
+	for i := range page {
+		page[i] = model.Event{EventName: "click", Channel: "web", UserID: "u1", Timestamp: ts.Add(-time.Duration(i) * time.Second)}
+	}
+	last := page[len(page)-1]
+	tail := []model.Event{{EventName: "signup", Channel: "web", UserID: "u1", Timestamp: ts.Add(-time.Hour)}}
+
+	s.privacy.On("AppendAudit", mock.Anything, s.auditEntry(model.PrivacyRequestAccess, model.PrivacyStatusSubmitted, "")).Return(nil).Once()
+	s.events.On("FetchUserEvents", mock.Anything, model.UserEventsFilter{
+		TenantID: model.DefaultTenant, UserID: "u1", From: time.Unix(0, 0).UTC(), To: accessWindowEnd, Limit: accessPageSize,
+	}).Return(page, nil).Once()
+	s.events.On("FetchUserEvents", mock.Anything, model.UserEventsFilter{
+		TenantID: model.DefaultTenant, UserID: "u1", From: time.Unix(0, 0).UTC(), To: accessWindowEnd, Limit: accessPageSize,
+		After: &model.UserEventsCursor{Timestamp: last.Timestamp, EventName: "click", Channel: "web"},
+	}).Return(tail, nil).Once()
+	s.privacy.On("FetchUserLateEvents", mock.Anything, model.DefaultTenant, "u1").Return([]model.Event{}, nil).Once()
+	s.privacy.On("AppendAudit", mock.Anything, s.auditEntry(model.PrivacyRequestAccess, model.PrivacyStatusCompleted, "exported 1001 events")).Return(nil).Once()
+
+	export, err := s.service.RequestAccess(context.Background(), model.PrivacyRequestInput{UserID: "u1", RequestedBy: "dpo"})
+
+	s.NoError(err)
+	s.Len(export.Events, accessPageSize+1)
+	s.Equal("signup", export.Events[accessPageSize].EventName)
+	s.Equal(model.PrivacyStatusCompleted, export.Request.Status)
+}
+
+func (s *PrivacyServiceTestSuite) TestRequestAccess_IncludesLateEvents() {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := []model.Event{{EventName: "purchase", Channel: "web", UserID: "u1", Timestamp: ts.Add(-48 * time.Hour), ReceivedAt: ts}}
+
+	s.privacy.On("AppendAudit", mock.Anything, s.auditEntry(model.PrivacyRequestAccess, model.PrivacyStatusSubmitted, "")).Return(nil).Once()
+	s.events.On("FetchUserEvents", mock.Anything, mock.Anything).
+		Return([]model.Event{{EventName: "click", Channel: "web", UserID: "u1", Timestamp: ts}}, nil).Once()
+	s.privacy.On("FetchUserLateEvents", mock.Anything, model.DefaultTenant, "u1").Return(late, nil).Once()
+	s.privacy.On("AppendAudit", mock.Anything, s.auditEntry(model.PrivacyRequestAccess, model.PrivacyStatusCompleted, "exported 2 events")).Return(nil).Once()
+
+	export, err := s.service.RequestAccess(context.Background(), model.PrivacyRequestInput{UserID: "u1", RequestedBy: "dpo"})
+
+	s.NoError(err)
+	s.Require().Len(export.Events, 2)
+	s.Equal("purchase", export.Events[1].EventName)
+	s.Equal(ts, export.Events[1].ReceivedAt)
+}
+
+func (s *PrivacyServiceTestSuite) TestGetRequest_NotFound() {
+	s.privacy.On("ListAudit", mock.Anything, "missing").Return([]model.PrivacyAuditEntry{}, nil).Once()
+
+	_, err := s.service.GetRequest(context.Background(), "missing")
+
+	s.ErrorIs(err, ErrPrivacyRequestNotFound)
+}
+
+func (s *PrivacyServiceTestSuite) TestGetRequest_CompletesFinishedErasure() {
+	submitted := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusSubmitted, "")
+	running := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusRunning, "")
+	s.privacy.On("ListAudit", mock.Anything, "req-1").Return([]model.PrivacyAuditEntry{submitted, running}, nil).Once()
+	s.privacy.On("PendingUserDeletes", mock.Anything, model.DefaultTenant, "u1").Return(0, nil).Once()
+
+	later := s.now.Add(time.Minute)
+	s.service.now = func() time.Time { return later }
+	completed := submitted
+	completed.Status = model.PrivacyStatusCompleted
+	completed.At = later
+	s.privacy.On("AppendAudit", mock.Anything, completed).Return(nil).Once()
+
+	req, err := s.service.GetRequest(context.Background(), "req-1")
+
+	s.NoError(err)
+	s.Equal(model.PrivacyStatusCompleted, req.Status)
+	s.Equal(s.now, req.CreatedAt)
+	s.Equal(later, req.UpdatedAt)
+}
+
+func (s *PrivacyServiceTestSuite) TestGetRequest_StillRunning() {
+	submitted := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusSubmitted, "")
+	running := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusRunning, "")
+	s.privacy.On("ListAudit", mock.Anything, "req-1").Return([]model.PrivacyAuditEntry{running, submitted}, nil).Once()
+	s.privacy.On("PendingUserDeletes", mock.Anything, model.DefaultTenant, "u1").Return(1, nil).Once()
+
+	req, err := s.service.GetRequest(context.Background(), "req-1")
+
+	s.NoError(err)
+	s.Equal(model.PrivacyStatusRunning, req.Status)
+}
+
+// TestGetRequest_IgnoresOtherTenants verifies that a running erasure of the
+// same user in another tenant does not keep the request running.
+func (s *PrivacyServiceTestSuite) TestGetRequest_IgnoresOtherTenants() {
+	submitted := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusSubmitted, "")
+	submitted.TenantID = "acme"
+	running := submitted
+	running.Status = model.PrivacyStatusRunning
+	s.privacy.On("ListAudit", mock.Anything, "req-1").Return([]model.PrivacyAuditEntry{submitted, running}, nil).Once()
+	s.privacy.On("PendingUserDeletes", mock.Anything, model.DefaultTenant, "u1").Return(1, nil).Maybe()
+	s.privacy.On("PendingUserDeletes", mock.Anything, "acme", "u1").Return(0, nil).Once()
+	s.privacy.On("AppendAudit", mock.Anything, mock.Anything).Return(nil).Once()
+
+	req, err := s.service.GetRequest(context.Background(), "req-1")
+
+	s.NoError(err)
+	s.Equal(model.PrivacyStatusCompleted, req.Status)
+	s.privacy.AssertNotCalled(s.T(), "PendingUserDeletes", mock.Anything, model.DefaultTenant, "u1")
+}
+
+func (s *PrivacyServiceTestSuite) TestListRequests_NewestFirst() {
+	older := s.auditEntry(model.PrivacyRequestAccess, model.PrivacyStatusCompleted, "exported 0 events")
+	older.RequestID = "a"
+	newer := s.auditEntry(model.PrivacyRequestErasure, model.PrivacyStatusCompleted, "")
+	newer.RequestID = "b"
+	newer.At = s.now.Add(time.Hour)
+	s.privacy.On("ListAudit", mock.Anything, "").Return([]model.PrivacyAuditEntry{older, newer}, nil).Once()
+
+	requests, err := s.service.ListRequests(context.Background())
+
+	s.NoError(err)
+	s.Require().Len(requests, 2)
+	s.Equal("b", requests[0].ID)
+	s.Equal("a", requests[1].ID)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"event-metrics-service/internal/model"
+)
+
+const (
+	defaultUserEventsLimit = 100
+	maxUserEventsLimit     = 1000
+)
+
+// GetUserEvents returns one page of a user's raw events, newest first. The
+// page is over-fetched by one event to tell whether a next_cursor is needed.
+func (s *eventService) GetUserEvents(ctx context.Context, filter model.UserEventsFilter, cursor string) (model.UserEventsResponse, error) {
+	if filter.UserID == "" {
+		return model.UserEventsResponse{}, &ValidationError{Message: "user_id is required"}
+	}
+
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultUserEventsLimit
+	case filter.Limit < 0 || filter.Limit > maxUserEventsLimit:
+		return model.UserEventsResponse{}, &ValidationError{Message: "limit must be between 1 and 1000"}
+	}
+
+	var err error
+	if filter.From, filter.To, err = s.resolveWindow(filter.From, filter.To); err != nil {
+		return model.UserEventsResponse{}, err
+	}
+
+	if cursor != "" {
+		after, err := decodeUserEventsCursor(cursor)
+		if err != nil {
+			return model.UserEventsResponse{}, &ValidationError{Message: "invalid cursor"}
+		}
+		filter.After = &after
+	}
+
+	page := filter
+	page.UserID = s.pii.UserID(filter.UserID)
+	page.Limit = filter.Limit + 1
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	events, err := s.repo.FetchUserEvents(ctx, page)
+	if err != nil {
+		return model.UserEventsResponse{}, err
+	}
+
+	resp := model.UserEventsResponse{UserID: filter.UserID, Events: []model.UserEvent{}}
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+		last := events[len(events)-1]
+		resp.NextCursor = encodeUserEventsCursor(model.UserEventsCursor{
+			Timestamp:  last.Timestamp,
+			EventName:  last.EventName,
+			Channel:    last.Channel,
+			CampaignID: last.CampaignID,
+		})
+	}
+
+	for _, event := range events {
+		resp.Events = append(resp.Events, toUserEvent(event))
+	}
+
+	return resp, nil
+}
+
+// toUserEvent drops the fields a user's own timeline does not repeat.
+func toUserEvent(event model.Event) model.UserEvent {
+	return model.UserEvent{
+		EventName:  event.EventName,
+		Channel:    event.Channel,
+		CampaignID: event.CampaignID,
+		Timestamp:  event.Timestamp,
+		ReceivedAt: event.ReceivedAt,
+		Tags:       event.Tags,
+		Metadata:   event.Metadata,
+	}
+}
+
+// GetUserSummary returns first/last seen times and per-event counts for a
+// user within the window.
+func (s *eventService) GetUserSummary(ctx context.Context, tenantID, userID string, from, to time.Time) (model.UserSummary, error) {
+	if userID == "" {
+		return model.UserSummary{}, &ValidationError{Message: "user_id is required"}
+	}
+
+	from, to, err := s.resolveWindow(from, to)
+	if err != nil {
+		return model.UserSummary{}, err
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	counts, err := s.repo.FetchUserEventCounts(ctx, tenantID, s.pii.UserID(userID), from, to)
+	if err != nil {
+		return model.UserSummary{}, err
+	}
+
+	summary := model.UserSummary{
+		UserID: userID,
+		Period: model.MetricsPeriod{
+			Start: from.Format(time.RFC3339Nano),
+			End:   to.Format(time.RFC3339Nano),
+		},
+		EventCounts: counts,
+	}
+
+	for _, c := range counts {
+		summary.TotalCount += c.Count
+		if summary.FirstSeen == nil || c.FirstSeen.Before(*summary.FirstSeen) {
+			firstSeen := c.FirstSeen
+			summary.FirstSeen = &firstSeen
+		}
+		if summary.LastSeen == nil || c.LastSeen.After(*summary.LastSeen) {
+			lastSeen := c.LastSeen
+			summary.LastSeen = &lastSeen
+		}
+	}
+
+	return summary, nil
+}
+
+func (s *eventService) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.limits.Timeout > 0 {
+		return context.WithTimeout(ctx, s.limits.Timeout)
+	}
+	return ctx, func() {}
+}
+
+// Cursors are opaque to clients: base64url-encoded JSON of the last event's
+// sorting key.
+func encodeUserEventsCursor(cursor model.UserEventsCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeUserEventsCursor(raw string) (model.UserEventsCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return model.UserEventsCursor{}, err
+	}
+
+	var cursor model.UserEventsCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return model.UserEventsCursor{}, err
+	}
+	if cursor.Timestamp.IsZero() || cursor.EventName == "" {
+		return model.UserEventsCursor{}, errors.New("incomplete cursor")
+	}
+	return cursor, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *EventServiceTestSuite) TestGetUserEvents_DefaultsAndNextCursor() {
+	now := time.Unix(2000, 0).UTC()
+	s.service.now = func() time.Time { return now }
+
+	expectedFilter := model.UserEventsFilter{
+		UserID: "u1",
+		From:   now.Add(-30 * 24 * time.Hour),
+		To:     now,
+		Limit:  3,
+	}
+	events := []model.Event{
+		{EventName: "purchase", Channel: "web", UserID: "u1", Timestamp: now.Add(-time.Minute), Tags: []string{}},
+		{EventName: "view", Channel: "web", UserID: "u1", Timestamp: now.Add(-2 * time.Minute), Tags: []string{}},
+		{EventName: "view", Channel: "app", UserID: "u1", Timestamp: now.Add(-3 * time.Minute), Tags: []string{}},
+	}
+	s.repo.On("FetchUserEvents", mock.Anything, expectedFilter).Return(events, nil).Once()
+
+	resp, err := s.service.GetUserEvents(context.Background(), model.UserEventsFilter{UserID: "u1", Limit: 2}, "")
+
+	s.NoError(err)
+	s.Equal("u1", resp.UserID)
+	s.Len(resp.Events, 2)
+	s.Equal("view", resp.Events[1].EventName)
+	s.NotEmpty(resp.NextCursor)
+
+	// The cursor round-trips to the last returned event.
+	after, err := decodeUserEventsCursor(resp.NextCursor)
+	s.NoError(err)
+	s.Equal(model.UserEventsCursor{Timestamp: now.Add(-2 * time.Minute), EventName: "view", Channel: "web"}, after)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestGetUserEvents_LastPageHasNoCursor() {
+	cursor := model.UserEventsCursor{Timestamp: time.Unix(900, 0).UTC(), EventName: "view", Channel: "web"}
+	withCursor := mock.MatchedBy(func(f model.UserEventsFilter) bool {
+		return f.After != nil && *f.After == cursor && f.Limit == defaultUserEventsLimit+1
+	})
+	s.repo.On("FetchUserEvents", mock.Anything, withCursor).Return([]model.Event{}, nil).Once()
+
+	resp, err := s.service.GetUserEvents(context.Background(), model.UserEventsFilter{UserID: "u1"}, encodeUserEventsCursor(cursor))
+
+	s.NoError(err)
+	s.Empty(resp.Events)
+	s.NotNil(resp.Events)
+	s.Empty(resp.NextCursor)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *EventServiceTestSuite) TestGetUserEvents_Validation() {
+	tests := []struct {
+		name   string
+		filter model.UserEventsFilter
+		cursor string
+		errMsg string
+	}{
+		{name: "missing user", filter: model.UserEventsFilter{}, errMsg: "user_id is required"},
+		{name: "limit too large", filter: model.UserEventsFilter{UserID: "u1", Limit: 1001}, errMsg: "limit must be between 1 and 1000"},
+		{name: "negative limit", filter: model.UserEventsFilter{UserID: "u1", Limit: -1}, errMsg: "limit must be between 1 and 1000"},
+		{name: "from after to", filter: model.UserEventsFilter{UserID: "u1", From: time.Unix(20, 0), To: time.Unix(10, 0)}, errMsg: "from must be before to"},
+		{name: "garbage cursor", filter: model.UserEventsFilter{UserID: "u1"}, cursor: "%%%", errMsg: "invalid cursor"},
+		{name: "empty cursor object", filter: model.UserEventsFilter{UserID: "u1"}, cursor: "e30", errMsg: "invalid cursor"},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, err := s.service.GetUserEvents(context.Background(), tt.filter, tt.cursor)
+			s.IsType(&ValidationError{}, err)
+			s.EqualError(err, tt.errMsg)
+		})
+	}
+	s.repo.AssertNotCalled(s.T(), "FetchUserEvents", mock.Anything, mock.Anything)
+}
+
+func (s *EventServiceTestSuite) TestGetUserSummary_Aggregates() {
+	from := time.Unix(0, 0).UTC()
+	to := time.Unix(1000, 0).UTC()
+	counts := []model.UserEventCount{
+		{EventName: "purchase", Count: 1, FirstSeen: time.Unix(500, 0).UTC(), LastSeen: time.Unix(500, 0).UTC()},
+		{EventName: "view", Count: 4, FirstSeen: time.Unix(100, 0).UTC(), LastSeen: time.Unix(400, 0).UTC()},
+	}
+	s.repo.On("FetchUserEventCounts", mock.Anything, "acme", "u1", from, to).Return(counts, nil).Once()
+
+	summary, err := s.service.GetUserSummary(context.Background(), "acme", "u1", from, to)
+
+	s.NoError(err)
+	s.Equal(uint64(5), summary.TotalCount)
+	s.Equal(time.Unix(100, 0).UTC(), *summary.FirstSeen)
+	s.Equal(time.Unix(500, 0).UTC(), *summary.LastSeen)
+	s.Equal(counts, summary.EventCounts)
+	s.Equal(model.MetricsPeriod{Start: from.Format(time.RFC3339), End: to.Format(time.RFC3339)}, summary.Period)
+}
+
+func (s *EventServiceTestSuite) TestGetUserSummary_NoActivity() {
+	s.repo.On("FetchUserEventCounts", mock.Anything, model.DefaultTenant, "u1", mock.Anything, mock.Anything).Return([]model.UserEventCount{}, nil).Once()
+
+	summary, err := s.service.GetUserSummary(context.Background(), model.DefaultTenant, "u1", time.Time{}, time.Time{})
+
+	s.NoError(err)
+	s.Nil(summary.FirstSeen)
+	s.Nil(summary.LastSeen)
+	s.Zero(summary.TotalCount)
+}
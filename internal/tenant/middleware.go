@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// HeaderTenantID selects the tenant of a request.
+const HeaderTenantID = "X-Tenant-ID"
+
+// localsKey stores the resolved tenant ID in fiber.Ctx locals.
+const localsKey = "tenant.id"
+
+// Middleware resolves the tenant of each request. It must run after
+// auth.RequireScope when authentication is enabled:
+//
+//   - a key pinned to a tenant always acts for that tenant;
+//   - an admin key may pick any tenant with the X-Tenant-ID header;
+//   - any other key acts for the default tenant;
+//   - an identity token may pick any tenant listed in its claims, and
+//     defaults to the first of them;
+//   - without authentication the header decides, defaulting to the default
+//     tenant.
+//
+// A header naming a tenant the key may not act for is rejected with 403
+// rather than ignored, so misconfigured clients notice.
+func Middleware(registry *Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requested := utils.Trim(c.Get(HeaderTenantID), ' ')
+
+		tenantID := requested
+		if key, ok := auth.KeyFromContext(c); ok && (key.Tenant != "" || !key.Has(auth.ScopeAdmin)) {
+			tenantID = model.TenantOrDefault(key.Tenant)
+			if requested != "" && requested != tenantID {
+				return fiber.NewError(fiber.StatusForbidden, "api key is not valid for tenant "+requested)
+			}
+		}
+		if principal, ok := auth.PrincipalFromContext(c); ok {
+			if tenantID == "" {
+				tenantID = principal.DefaultTenant()
+			}
+			if !principal.AllowsTenant(tenantID) {
+				return fiber.NewError(fiber.StatusForbidden, "token is not valid for tenant "+tenantID)
+			}
+		}
+		tenantID = model.TenantOrDefault(tenantID)
+
+		if !model.TenantIDPattern.MatchString(tenantID) {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid tenant id")
+		}
+		if !registry.Known(tenantID) {
+			return fiber.NewError(fiber.StatusForbidden, "unknown tenant "+tenantID)
+		}
+
+		c.Locals(localsKey, tenantID)
+		return c.Next()
+	}
+}
+
+// FromContext returns the tenant resolved by Middleware, or the default
+// tenant when the middleware did not run.
+func FromContext(c *fiber.Ctx) string {
+	tenantID, _ := c.Locals(localsKey).(string)
+	return model.TenantOrDefault(tenantID)
+}
+
+// Resolved returns the tenant resolved by Middleware and whether it ran.
+func Resolved(c *fiber.Ctx) (string, bool) {
+	tenantID, ok := c.Locals(localsKey).(string)
+	return tenantID, ok
+}
@@ -0,0 +1,141 @@
+package tenant
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	registry, err := NewRegistry([]Config{{ID: "acme"}, {ID: "globex"}})
+	require.NoError(t, err)
+
+	store := auth.NewKeyStore("")
+	_, writeToken, err := store.Create("web-sdk", []auth.Scope{auth.ScopeWrite}, "")
+	require.NoError(t, err)
+	_, pinnedToken, err := store.Create("acme-sdk", []auth.Scope{auth.ScopeWrite}, "acme")
+	require.NoError(t, err)
+	_, adminToken, err := store.Create("ops", []auth.Scope{auth.ScopeWrite, auth.ScopeAdmin}, "")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error { return c.SendString(FromContext(c)) }
+	app.Get("/open", Middleware(registry), handler)
+	app.Get("/keyed", auth.RequireScope(store, auth.ScopeWrite), Middleware(registry), handler)
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		tenant string
+		status int
+		want   string
+	}{
+		{"no header", "/open", "", "", http.StatusOK, model.DefaultTenant},
+		{"header", "/open", "", "acme", http.StatusOK, "acme"},
+		{"unknown tenant", "/open", "", "initech", http.StatusForbidden, ""},
+		{"invalid tenant", "/open", "", "bad*tenant", http.StatusBadRequest, ""},
+		{"unpinned key", "/keyed", writeToken, "", http.StatusOK, model.DefaultTenant},
+		{"unpinned key picks tenant", "/keyed", writeToken, "acme", http.StatusForbidden, ""},
+		{"pinned key", "/keyed", pinnedToken, "", http.StatusOK, "acme"},
+		{"pinned key same header", "/keyed", pinnedToken, "acme", http.StatusOK, "acme"},
+		{"pinned key other tenant", "/keyed", pinnedToken, "globex", http.StatusForbidden, ""},
+		{"admin key picks tenant", "/keyed", adminToken, "globex", http.StatusOK, "globex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set(auth.HeaderAPIKey, tt.token)
+			}
+			if tt.tenant != "" {
+				req.Header.Set(HeaderTenantID, tt.tenant)
+			}
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+			if tt.want != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(body))
+			}
+		})
+	}
+}
+
+func TestMiddleware_TokenTenants(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "k1", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())},
+	}})
+	require.NoError(t, err)
+	keys, err := auth.NewJWKS(jwks)
+	require.NoError(t, err)
+
+	sign := func(tenants []string) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		claims, _ := json.Marshal(map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "tenants": tenants})
+		signed := b64(header) + "." + b64(claims)
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signed + "." + b64(signature)
+	}
+
+	app := fiber.New()
+	app.Get("/metrics",
+		auth.RequireTokenOrScope(auth.NewTokenVerifier(keys, auth.TokenConfig{TenantsClaim: "tenants"}), nil, auth.ScopeRead),
+		Middleware(nil),
+		func(c *fiber.Ctx) error { return c.SendString(FromContext(c)) },
+	)
+
+	tests := []struct {
+		name    string
+		tenants []string
+		header  string
+		status  int
+		want    string
+	}{
+		{"first listed tenant", []string{"acme", "globex"}, "", http.StatusOK, "acme"},
+		{"listed tenant", []string{"acme", "globex"}, "globex", http.StatusOK, "globex"},
+		{"unlisted tenant", []string{"acme"}, "globex", http.StatusForbidden, ""},
+		{"no tenants claim", nil, "", http.StatusOK, model.DefaultTenant},
+		{"wildcard", []string{auth.AllTenants}, "globex", http.StatusOK, "globex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+sign(tt.tenants))
+			if tt.header != "" {
+				req.Header.Set(HeaderTenantID, tt.header)
+			}
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+			if tt.want != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"event-metrics-service/internal/model"
+)
+
+// Config holds the settings of one tenant. Zero values fall back to the
+// deployment-wide configuration.
+type Config struct {
+	ID string `json:"id"`
+
+	// RetentionDays overrides EVENTS_RETENTION_DAYS for the tenant's events.
+	RetentionDays int `json:"retention_days,omitempty"`
+
+	// SchemaRegistryFile replaces SCHEMA_REGISTRY_FILE for the tenant's
+	// events.
+	SchemaRegistryFile string `json:"schema_registry_file,omitempty"`
+
+	// Limits caps the requests and daily events of all the tenant's clients
+	// together.
+	Limits *model.ClientLimits `json:"limits,omitempty"`
+}
+
+// Document is the on-disk tenants file format.
+type Document struct {
+	Tenants []Config `json:"tenants"`
+}
+
+// Registry holds the configured tenants. A nil Registry accepts every
+// well-formed tenant ID and configures none of them.
+type Registry struct {
+	tenants map[string]Config
+}
+
+// NewRegistry builds a registry from tenant configs. The default tenant is
+// always known.
+func NewRegistry(configs []Config) (*Registry, error) {
+	r := &Registry{tenants: map[string]Config{model.DefaultTenant: {ID: model.DefaultTenant}}}
+	for _, cfg := range configs {
+		if !model.TenantIDPattern.MatchString(cfg.ID) {
+			return nil, fmt.Errorf("invalid tenant id %q", cfg.ID)
+		}
+		if cfg.RetentionDays < 0 {
+			return nil, fmt.Errorf("tenant %s: retention days must not be negative", cfg.ID)
+		}
+		if cfg.Limits != nil {
+			if err := cfg.Limits.Validate(); err != nil {
+				return nil, fmt.Errorf("tenant %s: %w", cfg.ID, err)
+			}
+		}
+		r.tenants[cfg.ID] = cfg
+	}
+	return r, nil
+}
+
+// Load reads the tenants file at path. An empty path allows any tenant and
+// returns nil.
+func Load(path string) (*Registry, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tenants: %w", err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse tenants: %w", err)
+	}
+	return NewRegistry(doc.Tenants)
+}
+
+// Known reports whether requests may use tenantID.
+func (r *Registry) Known(tenantID string) bool {
+	if r == nil {
+		return true
+	}
+	_, ok := r.tenants[tenantID]
+	return ok
+}
+
+// List returns the configured tenants sorted by ID.
+func (r *Registry) List() []Config {
+	if r == nil {
+		return nil
+	}
+
+	out := make([]Config, 0, len(r.tenants))
+	for _, cfg := range r.tenants {
+		out = append(out, cfg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// RetentionDays returns the tenants that override the global retention.
+func (r *Registry) RetentionDays() map[string]int {
+	days := map[string]int{}
+	for _, cfg := range r.List() {
+		if cfg.RetentionDays > 0 {
+			days[cfg.ID] = cfg.RetentionDays
+		}
+	}
+	return days
+}
+
+// Limits returns the limits configured for tenantID, or nil.
+func (r *Registry) Limits(tenantID string) *model.ClientLimits {
+	if r == nil {
+		return nil
+	}
+	return r.tenants[tenantID].Limits
+}
@@ -0,0 +1,48 @@
+package tenant
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tenants": [
+		{"id": "acme", "retention_days": 30},
+		{"id": "globex", "schema_registry_file": "globex.json"}
+	]}`), 0o600))
+
+	registry, err := Load(path)
+	require.NoError(t, err)
+	require.True(t, registry.Known(model.DefaultTenant))
+	require.True(t, registry.Known("acme"))
+	require.False(t, registry.Known("initech"))
+	require.Equal(t, map[string]int{"acme": 30}, registry.RetentionDays())
+
+	var ids []string
+	for _, cfg := range registry.List() {
+		ids = append(ids, cfg.ID)
+	}
+	require.Equal(t, []string{"acme", model.DefaultTenant, "globex"}, ids)
+}
+
+func TestLoad_EmptyPathAllowsAnyTenant(t *testing.T) {
+	registry, err := Load("")
+	require.NoError(t, err)
+	require.Nil(t, registry)
+	require.True(t, registry.Known("anything"))
+	require.Empty(t, registry.RetentionDays())
+}
+
+func TestNewRegistry_Invalid(t *testing.T) {
+	_, err := NewRegistry([]Config{{ID: "bad tenant"}})
+	require.Error(t, err)
+
+	_, err = NewRegistry([]Config{{ID: "acme", RetentionDays: -1}})
+	require.Error(t, err)
+}
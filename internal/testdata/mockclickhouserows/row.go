@@ -0,0 +1,28 @@
+package mockclickhouserows
+
+import (
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/mock"
+)
+
+type Row struct {
+	mock.Mock
+}
+
+var _ driver.Row = &Row{}
+
+func (m *Row) Err() error {
+	mockArgs := m.Called()
+	return mockArgs.Error(0)
+}
+
+func (m *Row) Scan(dest ...any) error {
+	callArgs := []any{}
+	callArgs = append(callArgs, dest...)
+	return m.Called(callArgs...).Error(0)
+}
+
+func (m *Row) ScanStruct(dest any) error {
+	mockArgs := m.Called(dest)
+	return mockArgs.Error(0)
+}
@@ -0,0 +1,54 @@
+package mockclickhouserows
+
+import (
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/mock"
+)
+
+type Rows struct {
+	mock.Mock
+}
+
+var _ driver.Rows = &Rows{}
+
+func (m *Rows) Next() bool {
+	mockArgs := m.Called()
+	return mockArgs.Bool(0)
+}
+
+func (m *Rows) Scan(dest ...any) error {
+	callArgs := []any{}
+	callArgs = append(callArgs, dest...)
+	return m.Called(callArgs...).Error(0)
+}
+
+func (m *Rows) ScanStruct(dest any) error {
+	mockArgs := m.Called(dest)
+	return mockArgs.Error(0)
+}
+
+func (m *Rows) ColumnTypes() []driver.ColumnType {
+	mockArgs := m.Called()
+	return mockArgs.Get(0).([]driver.ColumnType)
+}
+
+func (m *Rows) Totals(dest ...any) error {
+	callArgs := []any{}
+	callArgs = append(callArgs, dest...)
+	return m.Called(callArgs...).Error(0)
+}
+
+func (m *Rows) Columns() []string {
+	mockArgs := m.Called()
+	return mockArgs.Get(0).([]string)
+}
+
+func (m *Rows) Close() error {
+	mockArgs := m.Called()
+	return mockArgs.Error(0)
+}
+
+func (m *Rows) Err() error {
+	mockArgs := m.Called()
+	return mockArgs.Error(0)
+}
@@ -0,0 +1,32 @@
+package mockpostgresconnection
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+)
+
+type Connection struct {
+	mock.Mock
+}
+
+func (m *Connection) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	callArgs := append([]any{ctx, sql}, args...)
+	ret := m.Called(callArgs...)
+	return pgconn.CommandTag{}, ret.Error(0)
+}
+
+func (m *Connection) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	callArgs := append([]any{ctx, sql}, args...)
+	ret := m.Called(callArgs...)
+	rows, _ := ret.Get(0).(pgx.Rows)
+	return rows, ret.Error(1)
+}
+
+func (m *Connection) Begin(ctx context.Context) (pgx.Tx, error) {
+	ret := m.Called(ctx)
+	tx, _ := ret.Get(0).(pgx.Tx)
+	return tx, ret.Error(1)
+}
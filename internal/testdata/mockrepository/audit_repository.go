@@ -0,0 +1,27 @@
+package mockrepository
+
+import (
+	"context"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditRepository struct {
+	mock.Mock
+}
+
+// Interface compliance check
+var _ repository.AuditRepository = &AuditRepository{}
+
+func (m *AuditRepository) InsertAudit(ctx context.Context, records []model.AuditRecord) error {
+	args := m.Called(ctx, records)
+	return args.Error(0)
+}
+
+func (m *AuditRepository) SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]model.AuditRecord), args.Error(1)
+}
@@ -0,0 +1,32 @@
+package mockrepository
+
+import (
+	"context"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PartitionRepository struct {
+	mock.Mock
+}
+
+// Interface compliance check
+var _ repository.PartitionRepository = &PartitionRepository{}
+
+func (m *PartitionRepository) ListPartitions(ctx context.Context) ([]model.Partition, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Partition), args.Error(1)
+}
+
+func (m *PartitionRepository) DropPartition(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *PartitionRepository) OptimizePartition(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
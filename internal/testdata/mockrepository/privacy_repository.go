@@ -0,0 +1,42 @@
+package mockrepository
+
+import (
+	"context"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PrivacyRepository struct {
+	mock.Mock
+}
+
+// Interface compliance check
+var _ repository.PrivacyRepository = &PrivacyRepository{}
+
+func (m *PrivacyRepository) DeleteUserEvents(ctx context.Context, tenantID, userID string) error {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Error(0)
+}
+
+func (m *PrivacyRepository) FetchUserLateEvents(ctx context.Context, tenantID, userID string) ([]model.Event, error) {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Get(0).([]model.Event), args.Error(1)
+}
+
+func (m *PrivacyRepository) PendingUserDeletes(ctx context.Context, tenantID, userID string) (int, error) {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *PrivacyRepository) AppendAudit(ctx context.Context, entry model.PrivacyAuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *PrivacyRepository) ListAudit(ctx context.Context, requestID string) ([]model.PrivacyAuditEntry, error) {
+	args := m.Called(ctx, requestID)
+	return args.Get(0).([]model.PrivacyAuditEntry), args.Error(1)
+}
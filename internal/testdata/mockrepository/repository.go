@@ -2,6 +2,7 @@ package mockrepository
 
 import (
 	"context"
+	"time"
 
 	"event-metrics-service/internal/model"
 	"event-metrics-service/internal/repository"
@@ -26,8 +27,22 @@ func (m *Repository) CreateBatch(ctx context.Context, events []model.Event) erro
 	return args.Error(0)
 }
 
-func (m *Repository) FetchMetrics(ctx context.Context, filter model.MetricsFilter) (uint64, uint64, []model.MetricsGroup, error) {
+func (m *Repository) FetchMetrics(ctx context.Context, filter model.MetricsFilter) (model.MetricsResult, error) {
 	args := m.Called(ctx, filter)
-	// Return type casting requires caution; ensure mocks are set up correctly in tests
-	return args.Get(0).(uint64), args.Get(1).(uint64), args.Get(2).([]model.MetricsGroup), args.Error(3)
+	return args.Get(0).(model.MetricsResult), args.Error(1)
+}
+
+func (m *Repository) FetchUserEvents(ctx context.Context, filter model.UserEventsFilter) ([]model.Event, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.Event), args.Error(1)
+}
+
+func (m *Repository) FetchUserEventCounts(ctx context.Context, tenantID, userID string, from, to time.Time) ([]model.UserEventCount, error) {
+	args := m.Called(ctx, tenantID, userID, from, to)
+	return args.Get(0).([]model.UserEventCount), args.Error(1)
+}
+
+func (m *Repository) ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
 }
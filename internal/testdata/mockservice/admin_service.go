@@ -0,0 +1,50 @@
+package mockservice
+
+import (
+	"context"
+	"time"
+
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/schema"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AdminService struct {
+	mock.Mock
+}
+
+func (m *AdminService) ListPartitions(ctx context.Context) ([]model.Partition, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Partition), args.Error(1)
+}
+
+func (m *AdminService) DropPartitions(ctx context.Context, from, to time.Time) ([]model.Partition, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]model.Partition), args.Error(1)
+}
+
+func (m *AdminService) OptimizePartitions(ctx context.Context, from, to time.Time) ([]model.Partition, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]model.Partition), args.Error(1)
+}
+
+func (m *AdminService) ListSchemas() []schema.EventSchema {
+	args := m.Called()
+	return args.Get(0).([]schema.EventSchema)
+}
+
+func (m *AdminService) PutSchema(s schema.EventSchema) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
+
+func (m *AdminService) DeleteSchema(eventName string) error {
+	args := m.Called(eventName)
+	return args.Error(0)
+}
+
+func (m *AdminService) SchemaViolations() map[string]uint64 {
+	args := m.Called()
+	return args.Get(0).(map[string]uint64)
+}
@@ -0,0 +1,40 @@
+package mockservice
+
+import (
+	"time"
+
+	"event-metrics-service/internal/auth"
+	"event-metrics-service/internal/model"
+	"event-metrics-service/internal/service"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeyService struct {
+	mock.Mock
+}
+
+func (m *APIKeyService) ListKeys() []auth.APIKey {
+	args := m.Called()
+	return args.Get(0).([]auth.APIKey)
+}
+
+func (m *APIKeyService) CreateKey(name string, scopes []string, tenant string) (service.CreatedAPIKey, error) {
+	args := m.Called(name, scopes, tenant)
+	return args.Get(0).(service.CreatedAPIKey), args.Error(1)
+}
+
+func (m *APIKeyService) RevokeKey(id string) (auth.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(auth.APIKey), args.Error(1)
+}
+
+func (m *APIKeyService) RotateKey(id string, grace time.Duration) (service.CreatedAPIKey, error) {
+	args := m.Called(id, grace)
+	return args.Get(0).(service.CreatedAPIKey), args.Error(1)
+}
+
+func (m *APIKeyService) SetKeyLimits(id string, limits model.ClientLimits) (auth.APIKey, error) {
+	args := m.Called(id, limits)
+	return args.Get(0).(auth.APIKey), args.Error(1)
+}
@@ -0,0 +1,18 @@
+package mockservice
+
+import (
+	"context"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditService struct {
+	mock.Mock
+}
+
+func (m *AuditService) SearchAudit(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]model.AuditRecord), args.Error(1)
+}
@@ -0,0 +1,33 @@
+package mockservice
+
+import (
+	"context"
+
+	"event-metrics-service/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PrivacyService struct {
+	mock.Mock
+}
+
+func (m *PrivacyService) RequestErasure(ctx context.Context, input model.PrivacyRequestInput) (model.PrivacyRequest, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(model.PrivacyRequest), args.Error(1)
+}
+
+func (m *PrivacyService) RequestAccess(ctx context.Context, input model.PrivacyRequestInput) (model.UserDataExport, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(model.UserDataExport), args.Error(1)
+}
+
+func (m *PrivacyService) GetRequest(ctx context.Context, id string) (model.PrivacyRequest, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.PrivacyRequest), args.Error(1)
+}
+
+func (m *PrivacyService) ListRequests(ctx context.Context) ([]model.PrivacyRequest, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.PrivacyRequest), args.Error(1)
+}
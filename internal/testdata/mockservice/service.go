@@ -2,6 +2,7 @@ package mockservice
 
 import (
 	"context"
+	"time"
 
 	"event-metrics-service/internal/model"
 
@@ -25,3 +26,23 @@ func (m *Service) GetMetrics(ctx context.Context, filter model.MetricsFilter) (m
 	args := m.Called(ctx, filter)
 	return args.Get(0).(model.MetricsResponse), args.Error(1)
 }
+
+func (m *Service) GetUserEvents(ctx context.Context, filter model.UserEventsFilter, cursor string) (model.UserEventsResponse, error) {
+	args := m.Called(ctx, filter, cursor)
+	return args.Get(0).(model.UserEventsResponse), args.Error(1)
+}
+
+func (m *Service) GetUserSummary(ctx context.Context, tenantID, userID string, from, to time.Time) (model.UserSummary, error) {
+	args := m.Called(ctx, tenantID, userID, from, to)
+	return args.Get(0).(model.UserSummary), args.Error(1)
+}
+
+func (m *Service) ValidateExport(filter model.MetricsFilter) (model.MetricsFilter, error) {
+	args := m.Called(filter)
+	return args.Get(0).(model.MetricsFilter), args.Error(1)
+}
+
+func (m *Service) ExportEvents(ctx context.Context, filter model.MetricsFilter, fn func(model.Event) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}